@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/Jisin0/filmigobot/plugins"
 	"github.com/PaulSonOfLars/gotgbot/v2"
@@ -23,6 +24,8 @@ const (
 
 // Handles all incoming traffic from webhooks.
 func Bot(w http.ResponseWriter, r *http.Request) {
+	receivedAt := time.Now()
+
 	url := r.URL.Path
 
 	_, botToken := path.Split(url)
@@ -55,7 +58,7 @@ func Bot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = plugins.Dispatcher.ProcessUpdate(bot, &update, map[string]interface{}{})
+	err = plugins.Dispatcher.ProcessUpdate(bot, &update, map[string]interface{}{plugins.ReceivedAtKey: receivedAt})
 	if err != nil {
 		fmt.Printf("error while processing update: %v", err)
 	}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Jisin0/filmigobot/plugins"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// Time background jobs can run for in a single request, unfinished jobs resume on the next call.
+const cronBudget = 50 * time.Second
+
+// Cron runs due background jobs like availability alerts and should be called periodically by a scheduler.
+// Requests must carry the CRON_SECRET in an "Authorization: Bearer <secret>" header like vercel cron jobs do.
+func Cron(w http.ResponseWriter, r *http.Request) {
+	auth := []byte(r.Header.Get("Authorization"))
+
+	if plugins.CronSecret == "" || subtle.ConstantTimeCompare(auth, []byte("Bearer "+plugins.CronSecret)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// Jobs send messages with the first allowed token.
+	tokens := strings.Fields(plugins.BotToken)
+	if len(tokens) < 1 {
+		fmt.Fprint(w, "no BOT_TOKEN set to run jobs with")
+		return
+	}
+
+	bot, err := gotgbot.NewBot(tokens[0], &gotgbot.BotOpts{DisableTokenCheck: true})
+	if err != nil {
+		fmt.Printf("cron: %v\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), cronBudget)
+	defer cancel()
+
+	plugins.RunDueJobs(ctx, bot)
+
+	w.WriteHeader(statusCodeSuccess)
+}
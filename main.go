@@ -66,13 +66,16 @@ func main() {
 	updater := ext.NewUpdater(plugins.Dispatcher, &ext.UpdaterOpts{})
 
 	// Start receiving updates.
-	err = updater.StartPolling(b, &ext.PollingOpts{DropPendingUpdates: true, GetUpdatesOpts: &gotgbot.GetUpdatesOpts{AllowedUpdates: []string{"message", "callback_query", "inline_query", "chosen_inline_result"}}})
+	err = updater.StartPolling(b, &ext.PollingOpts{DropPendingUpdates: true, GetUpdatesOpts: &gotgbot.GetUpdatesOpts{AllowedUpdates: []string{"message", "callback_query", "inline_query", "chosen_inline_result", "poll"}}})
 	if err != nil {
 		panic("failed to start polling: " + err.Error())
 	}
 
 	fmt.Printf("@%s Started !\n", b.User.Username)
 
+	// Run background jobs like availability alerts.
+	plugins.StartJobs(b)
+
 	// Idle, to keep updates coming in, and avoid bot stopping.
 	updater.Idle()
 }
@@ -0,0 +1,260 @@
+// (c) Jisin0
+// Alert users when titles on their watchlist start streaming in their country.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jisin0/filmigo/justwatch"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const (
+	// Minimum time between checks of a user's watchlist, it's under a day so daily cron jobs that run a little early don't skip a day.
+	alertInterval = 20 * time.Hour
+	// Number of justwatch results searched to find an imdb title.
+	jWMatchLimit = 5
+)
+
+// streamingTypes are the monetization types that make a title available to stream along with their labels.
+var streamingTypes = map[string]string{"FLATRATE": "Stream", "FREE": "Free", "ADS": "Ads"}
+
+// availabilitySnapshot is the last known streaming availability of a user's watchlist.
+type availabilitySnapshot struct {
+	// Country the providers were checked in, a change resets the snapshot.
+	Country string `json:"country"`
+	// Names of the streaming providers of each title keyed by <method>_<id>.
+	Providers map[string][]string `json:"providers"`
+	CheckedAt time.Time           `json:"checked_at"`
+}
+
+// streamingOffer is a provider streaming a title.
+type streamingOffer struct {
+	Name string
+	Type string
+	URL  string
+}
+
+// The job runs hourly but each user is only checked once every alertInterval.
+var availabilityJob = registerJob(&backgroundJob{Name: "availability", Interval: time.Hour, Run: checkAvailability})
+
+func alertsKey(userID int64) string {
+	return fmt.Sprintf("alerts:%d", userID)
+}
+
+// watchlistUsers returns the ids of every user with a watchlist.
+func watchlistUsers() []int64 {
+	var (
+		keys  = userStore.Keys(watchlistKeyPrefix)
+		users = make([]int64, 0, len(keys))
+	)
+
+	for _, k := range keys {
+		if id, err := strconv.ParseInt(strings.TrimPrefix(k, watchlistKeyPrefix), 10, 64); err == nil {
+			users = append(users, id)
+		}
+	}
+
+	return users
+}
+
+// checkAvailability checks the watchlists of users that weren't checked recently, the least recently checked first.
+func checkAvailability(ctx context.Context, bot *gotgbot.Bot) error {
+	var (
+		users     = watchlistUsers()
+		snapshots = make(map[int64]*availabilitySnapshot, len(users))
+	)
+
+	for _, id := range users {
+		snap := &availabilitySnapshot{}
+		loadRecord(alertsKey(id), snap)
+		snapshots[id] = snap
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		return snapshots[users[i]].CheckedAt.Before(snapshots[users[j]].CheckedAt)
+	})
+
+	for _, id := range users {
+		if time.Since(snapshots[id].CheckedAt) < alertInterval {
+			continue
+		}
+
+		prefs := getUserPrefs(id)
+		if prefs.NoAlerts {
+			continue
+		}
+
+		if err := checkUserAvailability(withPrefs(ctx, prefs), bot, id, snapshots[id]); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			fmt.Printf("alerts: %d: %v\n", id, err)
+		}
+	}
+
+	return nil
+}
+
+// checkUserAvailability compares the streaming providers of each title on a user's watchlist with the last snapshot and alerts them of new ones.
+// Titles seen for the first time or after the user changed their country are only recorded.
+func checkUserAvailability(ctx context.Context, bot *gotgbot.Bot, userID int64, snap *availabilitySnapshot) error {
+	var (
+		country  = prefsFrom(ctx).Country
+		items    = getWatchlist(userID)
+		baseline = snap.Country != country
+		next     = make(map[string][]string, len(items))
+	)
+
+	for _, item := range items {
+		key := item.Method + "_" + item.ID
+		if old, ok := snap.Providers[key]; ok && !baseline {
+			next[key] = old
+		}
+	}
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			// Progress is saved so alerts that were sent aren't repeated.
+			return saveRecord(alertsKey(userID), availabilitySnapshot{Country: country, Providers: next, CheckedAt: snap.CheckedAt})
+		}
+
+		jwID, err := jWIDFor(ctx, item)
+		if err != nil || jwID == "" {
+			continue
+		}
+
+		offers, err := jWStreamingOffers(ctx, jwID, country)
+		if err != nil {
+			fmt.Printf("alerts: %s: %v\n", jwID, err)
+			continue
+		}
+
+		key := item.Method + "_" + item.ID
+		old, seen := next[key]
+
+		names := make([]string, 0, len(offers))
+		for _, o := range offers {
+			names = append(names, o.Name)
+		}
+
+		next[key] = names
+
+		if !seen {
+			continue
+		}
+
+		var added []streamingOffer
+
+		for _, o := range offers {
+			if !Contains(old, o.Name) {
+				added = append(added, o)
+			}
+		}
+
+		if len(added) > 0 {
+			if err := sendAvailabilityAlert(bot, userID, item, jwID, country, added); err != nil {
+				fmt.Printf("alerts: %d: %v\n", userID, err)
+			}
+		}
+	}
+
+	return saveRecord(alertsKey(userID), availabilitySnapshot{Country: country, Providers: next, CheckedAt: time.Now()})
+}
+
+// jWIDFor returns the justwatch id of a watchlist item, imdb titles are matched by searching their name on justwatch.
+func jWIDFor(ctx context.Context, item watchlistItem) (string, error) {
+	if item.Method == searchMethodJW {
+		return item.ID, nil
+	}
+
+	prefs := prefsFrom(ctx)
+
+	return cached(cacheKey("jwid", item.ID), titleCacheTTL, func() (string, error) {
+		results, err := jWSearch(ctx, item.Title, jWMatchLimit, prefs.Country, prefs.Language)
+		if err != nil {
+			return "", err
+		}
+
+		for _, r := range results {
+			// Years sometimes differ by one between databases.
+			if item.Year > 0 && r.Year > 0 && (r.Year < item.Year-1 || r.Year > item.Year+1) {
+				continue
+			}
+
+			if imdbID, err := jWIMDbID(ctx, r.ID); err == nil && imdbID == item.ID {
+				return r.ID, nil
+			}
+		}
+
+		return "", nil
+	})
+}
+
+// jWStreamingOffers fetches the current streaming offers of a justwatch title in a country, bypassing the cache.
+func jWStreamingOffers(ctx context.Context, id, country string) ([]streamingOffer, error) {
+	title, err := withLimits(ctx, jWHost, func() (*justwatch.Title, error) {
+		return jWClientFor(country).GetTitle(id, &justwatch.GetTitleOptions{Language: prefsFrom(ctx).Language})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		offers []streamingOffer
+		seen   = make(map[string]bool)
+	)
+
+	for _, o := range title.Offers {
+		if o == nil || o.Package == nil || o.Package.ClearName == "" || seen[o.Package.ClearName] {
+			continue
+		}
+
+		label, ok := streamingTypes[strings.ToUpper(o.MonetizationType)]
+		if !ok {
+			continue
+		}
+
+		seen[o.Package.ClearName] = true
+		offers = append(offers, streamingOffer{Name: o.Package.ClearName, Type: label, URL: o.URL})
+	}
+
+	sort.Slice(offers, func(i, j int) bool { return offers[i].Name < offers[j].Name })
+
+	return offers, nil
+}
+
+// sendAvailabilityAlert tells a user that a title on their watchlist is streaming on new providers.
+func sendAvailabilityAlert(bot *gotgbot.Bot, userID int64, item watchlistItem, jwID, country string, offers []streamingOffer) error {
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "<b>🔔 %s</b> <i>is now available in %s on:</i>\n\n", escapeHTML(item.Name()), countryLabel(country))
+
+	for _, o := range offers {
+		name := escapeHTML(o.Name)
+		if o.URL != "" {
+			name = htmlLink(o.URL, o.Name)
+		}
+
+		fmt.Fprintf(&text, "• %s <i>(%s)</i>\n", name, o.Type)
+	}
+
+	text.WriteString("\n<i>Turn these alerts off anytime in /settings.</i>")
+
+	_, err := bot.SendMessage(userID, text.String(), &gotgbot.SendMessageOpts{
+		ParseMode:          gotgbot.ParseModeHTML,
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true},
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: "📺 See All Offers", CallbackData: fmt.Sprintf("open_%s_%s_%s", searchMethodJW, jwID, country)}},
+		}},
+	})
+
+	return err
+}
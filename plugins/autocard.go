@@ -0,0 +1,312 @@
+// (c) Jisin0
+// Reply to imdb and justwatch links sent in groups with title cards.
+
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jisin0/filmigo/justwatch"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	// Cards sent in a chat every second and the burst allowed, about 3 a minute.
+	autoCardRate  = 3.0 / 60
+	autoCardBurst = 3
+	// Time before a link to the same title gets a card again in a chat.
+	autoCardRepeat = 10 * time.Minute
+	// Time between cleanups of idle chats and expired titles.
+	autoCardSweep = 10 * time.Minute
+)
+
+var (
+	imdbLinkPattern = regexp.MustCompile(`(?i)imdb\.com/(?:[a-z]{2}/)?title/(tt\d+)`)
+	jWLinkPattern   = regexp.MustCompile(`(?i)(?:https?://)?(?:www\.)?justwatch\.com/([a-z]{2})/[^\s/?#]+/[^\s/?#]+`)
+	bareIMDbPattern = regexp.MustCompile(`\btt\d{7,}\b`)
+)
+
+// chatSettings are the settings of a group chat.
+type chatSettings struct {
+	// Reply to links of titles with cards.
+	AutoCard bool `json:"auto_card,omitempty"`
+}
+
+func chatKey(chatID int64) string {
+	return fmt.Sprintf("chat:%d", chatID)
+}
+
+func getChatSettings(chatID int64) chatSettings {
+	var s chatSettings
+
+	loadRecord(chatKey(chatID), &s)
+
+	return s
+}
+
+// autoCardLimiter stops a chat from being flooded with cards.
+type autoCardLimiter struct {
+	mu      sync.Mutex
+	buckets map[int64]*tokenBucket
+	// Time at which each title was last sent in a chat keyed by <chat id>:<title>.
+	recent map[string]time.Time
+	// Time of the last cleanup.
+	swept time.Time
+}
+
+var autoCards = &autoCardLimiter{buckets: make(map[int64]*tokenBucket), recent: make(map[string]time.Time)}
+
+// Allow reports whether a card for a title can be sent in a chat now.
+func (l *autoCardLimiter) Allow(chatID int64, title string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	key := fmt.Sprintf("%d:%s", chatID, title)
+
+	if last, ok := l.recent[key]; ok && now.Sub(last) < autoCardRepeat {
+		return false
+	}
+
+	b, ok := l.buckets[chatID]
+	if !ok {
+		b = newTokenBucket(autoCardRate, autoCardBurst)
+		l.buckets[chatID] = b
+	}
+
+	if !b.Allow() {
+		return false
+	}
+
+	l.recent[key] = now
+
+	if now.Sub(l.swept) >= autoCardSweep {
+		l.sweep(now)
+	}
+
+	return true
+}
+
+// sweep drops the buckets of chats that have been idle long enough to be full again and titles that can be sent again, l.mu must be held.
+func (l *autoCardLimiter) sweep(now time.Time) {
+	for id, b := range l.buckets {
+		if b.Full() {
+			delete(l.buckets, id)
+		}
+	}
+
+	for k, t := range l.recent {
+		if now.Sub(t) >= autoCardRepeat {
+			delete(l.recent, k)
+		}
+	}
+
+	l.swept = now
+}
+
+// titleLink is a reference to a title found in a message.
+type titleLink struct {
+	Method string
+	// Id of the title or the url of a justwatch title.
+	Ref string
+	// Country of a justwatch url.
+	Country string
+}
+
+// findTitleLink returns the first imdb or justwatch link in text or a bare imdb id.
+func findTitleLink(text string) (titleLink, bool) {
+	if m := imdbLinkPattern.FindStringSubmatch(text); m != nil {
+		return titleLink{Method: searchMethodIMDb, Ref: m[1]}, true
+	}
+
+	if m := jWLinkPattern.FindStringSubmatch(text); m != nil {
+		return titleLink{Method: searchMethodJW, Ref: m[0], Country: strings.ToUpper(m[1])}, true
+	}
+
+	if id := bareIMDbPattern.FindString(text); id != "" {
+		return titleLink{Method: searchMethodIMDb, Ref: id}, true
+	}
+
+	return titleLink{}, false
+}
+
+// jWIDFromURL returns the id of the justwatch title at a url.
+func jWIDFromURL(ctx context.Context, link, country string) (string, error) {
+	return cached(cacheKey("jwurl", strings.ToLower(link)), titleCacheTTL, func() (string, error) {
+		details, err := withLimits(ctx, jWHost, func() (*justwatch.URLDetails, error) {
+			return jWClientFor(country).GetTitleFromURL(link)
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if details.Data == nil || !jWIDPattern.MatchString(details.Data.ID) {
+			return "", errors.New("justwatch: no title at " + link)
+		}
+
+		return details.Data.ID, nil
+	})
+}
+
+// messageText returns the text or caption of a message.
+func messageText(msg *gotgbot.Message) string {
+	if msg.Text != "" {
+		return msg.Text
+	}
+
+	return msg.Caption
+}
+
+// autoCardFilter matches messages with a title link in groups that turned on auto cards.
+// Commands are left to the command handlers and messages sent by bots or through inline mode are ignored.
+func autoCardFilter(msg *gotgbot.Message) bool {
+	if msg.Chat.Type != gotgbot.ChatTypeGroup && msg.Chat.Type != gotgbot.ChatTypeSupergroup {
+		return false
+	}
+
+	if msg.ViaBot != nil || (msg.From != nil && msg.From.IsBot) {
+		return false
+	}
+
+	text := messageText(msg)
+	if strings.HasPrefix(text, "/") {
+		return false
+	}
+
+	if _, ok := findTitleLink(text); !ok {
+		return false
+	}
+
+	return getChatSettings(msg.Chat.Id).AutoCard
+}
+
+// AutoCard replies to a message containing a title link with a compact card of the title.
+// Nothing is sent if the title couldn't be found to keep groups free of noise.
+func AutoCard(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		msg     = ctx.EffectiveMessage
+		link, _ = findTitleLink(messageText(msg))
+	)
+
+	if !autoCards.Allow(msg.Chat.Id, link.Method+"_"+link.Ref) {
+		return ext.EndGroups
+	}
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	prefs := prefsFrom(reqCtx)
+	prefs.Verbosity = verbosityCompact
+	reqCtx = withPrefs(reqCtx, prefs)
+
+	if isCountryCode(link.Country) {
+		reqCtx = withCountry(reqCtx, link.Country)
+	}
+
+	id := link.Ref
+
+	if link.Method == searchMethodJW {
+		var err error
+
+		id, err = jWIDFromURL(reqCtx, link.Ref, link.Country)
+		if err != nil {
+			fmt.Printf("autocard: %v\n", err)
+			return ext.EndGroups
+		}
+	}
+
+	p, ok := getProvider(link.Method)
+	if !ok {
+		return ext.EndGroups
+	}
+
+	card, err := p.GetTitle(reqCtx, id, nil)
+	if err != nil {
+		fmt.Printf("autocard: %v\n", err)
+		return ext.EndGroups
+	}
+
+	if err := sendCard(bot, msg.Chat.Id, card, &gotgbot.ReplyParameters{MessageId: msg.MessageId, AllowSendingWithoutReply: true}); err != nil {
+		fmt.Printf("autocard: %v\n", err)
+	}
+
+	return ext.EndGroups
+}
+
+// isChatAdmin reports whether the sender of a message is an admin of it's chat, anonymous admins included.
+func isChatAdmin(bot *gotgbot.Bot, msg *gotgbot.Message) bool {
+	if msg.SenderChat != nil && msg.SenderChat.Id == msg.Chat.Id {
+		return true
+	}
+
+	return msg.From != nil && isAdmin(bot, msg.Chat.Id, msg.From.Id)
+}
+
+// isAdmin reports whether a user is an admin of a chat.
+func isAdmin(bot *gotgbot.Bot, chatID, userID int64) bool {
+	member, err := bot.GetChatMember(chatID, userID, nil)
+	if err != nil {
+		fmt.Printf("isadmin: %v\n", err)
+		return false
+	}
+
+	status := member.GetStatus()
+
+	return status == "creator" || status == "administrator"
+}
+
+// AutoCardCommand handles the /autocard command which lets admins turn auto cards on or off in a group.
+func AutoCardCommand(bot *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+
+	reply := func(text string) {
+		if _, err := msg.Reply(bot, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML}); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	if msg.Chat.Type != gotgbot.ChatTypeGroup && msg.Chat.Type != gotgbot.ChatTypeSupergroup {
+		reply("<i>Auto cards can only be used in groups 👥</i>")
+		return ext.EndGroups
+	}
+
+	var (
+		settings = getChatSettings(msg.Chat.Id)
+		args     = strings.Fields(strings.ToLower(msg.GetText()))
+		state    = "off"
+	)
+
+	if settings.AutoCard {
+		state = "on"
+	}
+
+	if len(args) < 2 || (args[1] != "on" && args[1] != "off") {
+		reply(fmt.Sprintf("<i>Auto cards are <b>%s</b> in this chat.\nWhen they're on I reply to IMDb and JustWatch links with title cards.\nAdmins can use</i> <code>/autocard on</code> <i>or</i> <code>/autocard off</code> <i>to change it.</i>", state))
+		return ext.EndGroups
+	}
+
+	if !isChatAdmin(bot, msg) {
+		reply("<i>Only admins can turn auto cards on or off 🙅</i>")
+		return ext.EndGroups
+	}
+
+	settings.AutoCard = args[1] == "on"
+
+	if err := saveRecord(chatKey(msg.Chat.Id), settings); err != nil {
+		fmt.Printf("autocard: %v\n", err)
+		reply("<i>I Couldn't Save The Setting 🤧\nPlease Try Again Later !</i>")
+
+		return ext.EndGroups
+	}
+
+	reply(fmt.Sprintf("<i>Auto cards are now <b>%s</b> in this chat ✅</i>", args[1]))
+
+	return ext.EndGroups
+}
@@ -0,0 +1,278 @@
+// (c) Jisin0
+// Response cache for searches, titles and telegraph pages.
+
+package plugins
+
+import (
+	"container/list"
+	"crypto/sha1" //nolint:gosec // only used to build file names.
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Time for which each kind of response is cached.
+const (
+	searchCacheTTL    = 6 * time.Hour
+	titleCacheTTL     = 24 * time.Hour
+	telegraphCacheTTL = 7 * 24 * time.Hour
+
+	// Maximum number of entries in the in-memory cache.
+	defaultCacheSize = 1000
+)
+
+// Cache stores responses from upstream apis for a limited time.
+type Cache interface {
+	// Get returns the value saved for a key if it exists and hasn't expired.
+	Get(key string) ([]byte, bool)
+	// Set saves the value of a key for the given duration, a zero ttl never expires.
+	Set(key string, value []byte, ttl time.Duration)
+	// Delete removes a key from the cache.
+	Delete(key string)
+}
+
+// responseCache is the cache used for all upstream responses.
+var responseCache Cache
+
+// initCache sets up the response cache from the environment.
+func initCache() {
+	switch {
+	case RedisURL != "":
+		c, err := newRedisCache(RedisURL)
+		if err == nil {
+			responseCache = c
+			return
+		}
+
+		fmt.Printf("failed to connect to redis, using in-memory cache: %v\n", err)
+	case CacheDir != "":
+		c, err := newFileCache(CacheDir)
+		if err == nil {
+			responseCache = c
+			return
+		}
+
+		fmt.Printf("failed to create cache directory, using in-memory cache: %v\n", err)
+	}
+
+	responseCache = newMemoryCache(defaultCacheSize)
+}
+
+// cached returns the value saved at key or calls fetch and saves it's result for ttl.
+func cached[T any](key string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	var v T
+
+	if data, ok := responseCache.Get(key); ok && json.Unmarshal(data, &v) == nil {
+		return v, nil
+	}
+
+	v, err := fetch()
+	if err != nil {
+		return v, err
+	}
+
+	if data, err := json.Marshal(v); err == nil {
+		responseCache.Set(key, data, ttl)
+	}
+
+	return v, nil
+}
+
+// refreshed calls fetch skipping the value saved at key and saves it's result for ttl, the saved value is kept if it fails.
+func refreshed[T any](key string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	v, err := fetch()
+	if err != nil {
+		return v, err
+	}
+
+	if data, err := json.Marshal(v); err == nil {
+		responseCache.Set(key, data, ttl)
+	}
+
+	return v, nil
+}
+
+// cacheKey joins the parts of a key into a normalized string.
+func cacheKey(parts ...string) string {
+	for i, p := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// cacheEntry is a single value in the memory or file cache.
+type cacheEntry struct {
+	Key     string    `json:"key"`
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+func (e *cacheEntry) expired() bool {
+	return !e.Expires.IsZero() && time.Now().After(e.Expires)
+}
+
+func newCacheEntry(key string, value []byte, ttl time.Duration) *cacheEntry {
+	e := &cacheEntry{Key: key, Value: value}
+	if ttl > 0 {
+		e.Expires = time.Now().Add(ttl)
+	}
+
+	return e
+}
+
+// memoryCache is a size bounded least recently used cache.
+type memoryCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+// newMemoryCache creates an in-memory lru cache holding at most size entries.
+func newMemoryCache(size int) *memoryCache {
+	return &memoryCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := el.Value.(*cacheEntry) //nolint:errcheck // only entries are stored.
+	if entry.expired() {
+		c.order.Remove(el)
+		delete(c.items, key)
+
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+
+	return entry.Value, true
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value = newCacheEntry(key, value, ttl)
+		c.order.MoveToFront(el)
+
+		return
+	}
+
+	c.items[key] = c.order.PushFront(newCacheEntry(key, value, ttl))
+
+	for c.size > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).Key) //nolint:errcheck // only entries are stored.
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *memoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Range calls fn with each unexpired entry from the least to the most recently used.
+func (c *memoryCache) Range(fn func(key string, value []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for el := c.order.Back(); el != nil; el = el.Prev() {
+		entry := el.Value.(*cacheEntry) //nolint:errcheck // only entries are stored.
+		if !entry.expired() {
+			fn(entry.Key, entry.Value)
+		}
+	}
+}
+
+func (c *memoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// fileCache saves each entry as a json file in a directory so it survives restarts.
+type fileCache struct {
+	dir string
+}
+
+// newFileCache creates a file backed cache in dir.
+func newFileCache(dir string) (*fileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:mnd // standard directory permissions.
+		return nil, err
+	}
+
+	return &fileCache{dir: dir}, nil
+}
+
+func (c *fileCache) path(key string) string {
+	sum := sha1.Sum([]byte(key)) //nolint:gosec // not used for security.
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *fileCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.Key != key {
+		return nil, false
+	}
+
+	if entry.expired() {
+		c.Delete(key)
+		return nil, false
+	}
+
+	return entry.Value, true
+}
+
+func (c *fileCache) Set(key string, value []byte, ttl time.Duration) {
+	data, err := json.Marshal(newCacheEntry(key, value, ttl))
+	if err != nil {
+		return
+	}
+
+	// Write to a temporary file first so readers never see a partial entry.
+	tmp := c.path(key) + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o600); err != nil { //nolint:mnd // owner only permissions.
+		fmt.Printf("filecache: %v\n", err)
+		return
+	}
+
+	if err := os.Rename(tmp, c.path(key)); err != nil {
+		fmt.Printf("filecache: %v\n", err)
+	}
+}
+
+func (c *fileCache) Delete(key string) {
+	os.Remove(c.path(key)) //nolint:errcheck // it doesn't matter if the file doesn't exist.
+}
@@ -0,0 +1,191 @@
+// (c) Jisin0
+// Send and edit title cards as photos or text messages.
+
+package plugins
+
+import (
+	"fmt"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// messageTarget identifies a sent message that should be edited.
+type messageTarget struct {
+	ChatID          int64
+	MessageID       int64
+	InlineMessageID string
+	// Media indicates wether the message is a photo, only known for messages that aren't inline.
+	Media bool
+}
+
+// callbackTarget returns the message that a callback query originated from.
+func callbackTarget(cq *gotgbot.CallbackQuery) messageTarget {
+	if cq.InlineMessageId != "" || cq.Message == nil {
+		return messageTarget{InlineMessageID: cq.InlineMessageId}
+	}
+
+	target := messageTarget{ChatID: cq.Message.GetChat().Id, MessageID: cq.Message.GetMessageId()}
+
+	if m, ok := cq.Message.(gotgbot.Message); ok {
+		target.Media = len(m.Photo) > 0
+	}
+
+	return target
+}
+
+// sendCard sends a card to a chat as a photo or as text with a link preview, reply is optional.
+func sendCard(bot *gotgbot.Bot, chatID int64, card *TitleCard, reply *gotgbot.ReplyParameters) error {
+	if card.Photo {
+		_, err := bot.SendPhoto(chatID, gotgbot.InputFileByURL(card.Poster), &gotgbot.SendPhotoOpts{
+			Caption:         safeHTML(truncateHTML(card.Caption, captionLimit)),
+			ParseMode:       gotgbot.ParseModeHTML,
+			ReplyMarkup:     gotgbot.InlineKeyboardMarkup{InlineKeyboard: card.Buttons},
+			HasSpoiler:      card.Spoiler,
+			ReplyParameters: reply,
+		})
+
+		return err
+	}
+
+	_, err := bot.SendMessage(chatID, cardText(card), &gotgbot.SendMessageOpts{
+		ParseMode:       gotgbot.ParseModeHTML,
+		ReplyMarkup:     gotgbot.InlineKeyboardMarkup{InlineKeyboard: card.Buttons},
+		ReplyParameters: reply,
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			IsDisabled:    false,
+			ShowAboveText: true,
+			Url:           card.Poster,
+		},
+	})
+
+	return err
+}
+
+// editCard edits a message into a card.
+// Photo messages are always edited using EditMessageMedia and text messages using EditMessageText,
+// inline messages are edited according to the card type and then the other way if it fails.
+func editCard(bot *gotgbot.Bot, target messageTarget, card *TitleCard) error {
+	asMedia := card.Photo
+	if target.InlineMessageID == "" {
+		asMedia = target.Media
+	}
+
+	err := editCardAs(bot, target, card, asMedia)
+	if err != nil && target.InlineMessageID != "" {
+		err = editCardAs(bot, target, card, !asMedia)
+	}
+
+	return err
+}
+
+func editCardAs(bot *gotgbot.Bot, target messageTarget, card *TitleCard, media bool) error {
+	markup := gotgbot.InlineKeyboardMarkup{InlineKeyboard: card.Buttons}
+
+	if media {
+		_, _, err := bot.EditMessageMedia(gotgbot.InputMediaPhoto{
+			Media:      gotgbot.InputFileByURL(card.Poster),
+			Caption:    safeHTML(truncateHTML(card.Caption, captionLimit)),
+			ParseMode:  gotgbot.ParseModeHTML,
+			HasSpoiler: card.Spoiler,
+		}, &gotgbot.EditMessageMediaOpts{
+			ChatId:          target.ChatID,
+			MessageId:       target.MessageID,
+			InlineMessageId: target.InlineMessageID,
+			ReplyMarkup:     markup,
+		})
+
+		return err
+	}
+
+	_, _, err := bot.EditMessageText(cardText(card), &gotgbot.EditMessageTextOpts{
+		ChatId:          target.ChatID,
+		MessageId:       target.MessageID,
+		InlineMessageId: target.InlineMessageID,
+		ParseMode:       gotgbot.ParseModeHTML,
+		ReplyMarkup:     markup,
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			IsDisabled:    false,
+			ShowAboveText: true,
+			Url:           card.Poster,
+		},
+	})
+
+	return err
+}
+
+// editMarkup replaces the buttons of the target message.
+func editMarkup(bot *gotgbot.Bot, target messageTarget, buttons [][]gotgbot.InlineKeyboardButton) error {
+	_, _, err := bot.EditMessageReplyMarkup(&gotgbot.EditMessageReplyMarkupOpts{
+		ChatId:          target.ChatID,
+		MessageId:       target.MessageID,
+		InlineMessageId: target.InlineMessageID,
+		ReplyMarkup:     gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons},
+	})
+
+	return err
+}
+
+// relabelButton replaces the button with the same callback data on the message of a callback query.
+// The keyboard of inline messages isn't sent with the query so they're left as they are,
+// buttons in groups are shared by everyone so they keep their neutral labels.
+func relabelButton(bot *gotgbot.Bot, cq *gotgbot.CallbackQuery, button gotgbot.InlineKeyboardButton) {
+	m, ok := cq.Message.(gotgbot.Message)
+	if !ok || m.ReplyMarkup == nil || m.Chat.Type != gotgbot.ChatTypePrivate {
+		return
+	}
+
+	var (
+		rows    = make([][]gotgbot.InlineKeyboardButton, len(m.ReplyMarkup.InlineKeyboard))
+		changed bool
+	)
+
+	for i, row := range m.ReplyMarkup.InlineKeyboard {
+		rows[i] = append([]gotgbot.InlineKeyboardButton(nil), row...)
+
+		for j, b := range row {
+			if b.CallbackData == button.CallbackData && b.Text != button.Text {
+				rows[i][j] = button
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return
+	}
+
+	if err := editMarkup(bot, callbackTarget(cq), rows); err != nil {
+		fmt.Printf("relabelbutton: %v\n", err)
+	}
+}
+
+// progressUpdater returns a function that shows a status message by editing the text or caption of the target.
+func progressUpdater(bot *gotgbot.Bot, target messageTarget) func(string) {
+	return func(msg string) {
+		if !target.Media {
+			_, _, err := bot.EditMessageText(msg, &gotgbot.EditMessageTextOpts{
+				ChatId:          target.ChatID,
+				MessageId:       target.MessageID,
+				InlineMessageId: target.InlineMessageID,
+				ParseMode:       gotgbot.ParseModeHTML,
+			})
+			if err == nil || target.InlineMessageID == "" {
+				return
+			}
+		}
+
+		bot.EditMessageCaption(&gotgbot.EditMessageCaptionOpts{ //nolint:errcheck // status updates are best effort
+			ChatId:          target.ChatID,
+			MessageId:       target.MessageID,
+			InlineMessageId: target.InlineMessageID,
+			Caption:         msg,
+			ParseMode:       gotgbot.ParseModeHTML,
+		})
+	}
+}
+
+// cardText returns the text of a card with a hidden link to the poster for the link preview.
+// Captions are rendered to fit but they're truncated and validated here too since telegram rejects the whole message otherwise.
+func cardText(card *TitleCard) string {
+	return fmt.Sprintf("<a href=\"%s\">&#8203;</a>%s", escapeHTML(card.Poster), safeHTML(truncateHTML(card.Caption, messageLimit-1)))
+}
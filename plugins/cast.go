@@ -0,0 +1,118 @@
+// (c) Jisin0
+// Browse the full cast and crew of a title grouped by department.
+
+package plugins
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// Number of people on each page of a credit group.
+const castPageSize = 20
+
+func castData(id string, group, page int) string {
+	return fmt.Sprintf("cst_%s_%d_%d", id, group, page)
+}
+
+// castCard renders a page of a credit group of a title with buttons to switch between groups.
+func castCard(t *Title, poster string, group, page int) *TitleCard {
+	group = max(0, min(group, len(t.Credits)-1))
+
+	var (
+		cb      captionBuilder
+		credits = t.Credits[group]
+		pages   = pageCount(len(credits.People), castPageSize)
+		buttons [][]gotgbot.InlineKeyboardButton
+		row     []gotgbot.InlineKeyboardButton
+	)
+
+	page = max(0, min(page, pages-1))
+
+	cb.Add(0, fmt.Sprintf("<b>👥 %s %s</b>\n<i>%s (%d)</i>\n\n", escapeHTML(t.Name), t.YearString(), escapeHTML(credits.Name), len(credits.People)))
+
+	for _, p := range credits.People[page*castPageSize : min((page+1)*castPageSize, len(credits.People))] {
+		line := "• " + p.Link()
+		if p.Role != "" {
+			line += " — <i>" + escapeHTML(p.Role) + "</i>"
+		}
+
+		cb.Add(0, line+"\n")
+	}
+
+	if nav := pageButtons(page, pages, func(n int) string { return castData(t.ID, group, n) }); len(nav) > 0 {
+		buttons = append(buttons, nav)
+	}
+
+	for i, g := range t.Credits {
+		text := g.Name
+		if i == group {
+			text = "✅ " + text
+		}
+
+		row = append(row, gotgbot.InlineKeyboardButton{Text: text, CallbackData: castData(t.ID, i, 0)})
+
+		if len(row) == 3 { //nolint:mnd // buttons in each row.
+			buttons = append(buttons, row)
+			row = nil
+		}
+	}
+
+	if len(row) > 0 {
+		buttons = append(buttons, row)
+	}
+
+	return &TitleCard{
+		Poster:  poster,
+		Caption: cb.Fit(messageLimit - 1),
+		Buttons: append(buttons, []gotgbot.InlineKeyboardButton{backToTitleButton(t.ID)}),
+	}
+}
+
+// CbCast handles the buttons of the cast and crew pager, data is like cst_<imdb id>_<group>_<page>.
+func CbCast(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.Split(update.Data, "_")
+	)
+
+	if len(split) < 4 || !imdbIDPattern.MatchString(split[1]) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	var (
+		id       = split[1]
+		group, _ = strconv.Atoi(split[2])
+		page, _  = strconv.Atoi(split[3])
+		target   = callbackTarget(update)
+	)
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	t, err := getTitleData(reqCtx, id, nil)
+	if err != nil || len(t.Credits) < 1 {
+		fmt.Printf("cbcast: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Find The Cast of This Title 🤧", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	poster := t.Poster
+	if poster == "" || poster == notAvailable {
+		poster = omdbBanner
+	}
+
+	if err := editCard(bot, target, castCard(t, poster, group, page)); err != nil {
+		fmt.Printf("cbcast: %v\n", err)
+	}
+
+	update.Answer(bot, nil)
+
+	return ext.EndGroups
+}
@@ -5,6 +5,7 @@ package plugins
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
@@ -28,18 +29,34 @@ This bot is only for limited non-commercial use of <a href='https://help.imdb.co
 `
 
 	PrivacyText = `
-This bot does not connect to any datbase and hence does not store any user data in any form.
+<i>This bot only stores what's needed for the features you use, linked to your telegram user id:</i>
+
+○ <b>Settings</b>: the search method, country, language, spoiler and caption style you choose with /settings.
+○ <b>Watchlist</b>: the id, name, year, type, poster link and date added of each title you save with /watchlist.
+○ <b>Availability Alerts</b>: the streaming services each saved title was last seen on in your country, used to alert you when new ones are added.
+○ <b>Reminders</b>: the id and name of each title you set a reminder for with the <b>🔔 Remind Me</b> button, when it's due and the last episode you were reminded of.
+○ <b>Groups</b>: whether admins turned on auto cards with /autocard, linked to the group's id.
+○ <b>Movie Nights</b>: the titles added to a /movienight poll and the id of the user who started it, deleted once the poll is closed or after 7 days. Titles saved for the next poll are deleted 7 days after the last one was added.
+
+<i>Nothing else like your messages or searches is stored and your data is <b>never shared</b> with anyone.
+Use /forgetme to permanently delete all of it.</i>
 `
 )
 
-var (
-	startButtons = append([][]gotgbot.InlineKeyboardButton{{aboutButton, helpButton}}, inlineSearchButtons...)
-)
+// startButtons returns the buttons for the start message.
+func startButtons() [][]gotgbot.InlineKeyboardButton {
+	return append([][]gotgbot.InlineKeyboardButton{{aboutButton, helpButton}}, inlineSearchButtons()...)
+}
 
 func Start(bot *gotgbot.Bot, ctx *ext.Context) error {
 	update := ctx.EffectiveMessage
 
-	_, err := bot.SendMessage(update.Chat.Id, fmt.Sprintf(startText, mention(update.From)), &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true}, ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: startButtons}})
+	// Deep link from the inline watchlist button.
+	if args := strings.Fields(update.GetText()); len(args) > 1 && args[1] == "watchlist" {
+		return Watchlist(bot, ctx)
+	}
+
+	_, err := bot.SendMessage(update.Chat.Id, fmt.Sprintf(startText, mention(update.From)), &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true}, ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: startButtons()}})
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -66,7 +83,7 @@ func CbCommand(bot *gotgbot.Bot, ctx *ext.Context) error {
 	switch cmd {
 	case "START":
 		text = fmt.Sprintf(startText, mention(ctx.EffectiveUser))
-		buttons = startButtons
+		buttons = startButtons()
 	default:
 		if s, k := allTexts[cmd]; k {
 			text = s
@@ -74,7 +91,7 @@ func CbCommand(bot *gotgbot.Bot, ctx *ext.Context) error {
 			text, _ = allTexts["NOTFOUND"]
 		}
 
-		buttons, _ = allButtons[cmd]
+		buttons = commandButtons(cmd)
 	}
 
 	_, _, err := update.Message.EditText(bot, text, &gotgbot.EditMessageTextOpts{ParseMode: gotgbot.ParseModeHTML, ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons}, LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true}})
@@ -102,7 +119,7 @@ func CommandHandler(bot *gotgbot.Bot, ctx *ext.Context) error {
 		text, _ = allTexts["NOTFOUND"]
 	}
 
-	buttons, _ := allButtons[cmd]
+	buttons := commandButtons(cmd)
 
 	_, err := bot.SendMessage(update.Chat.Id, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true}, ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons}})
 	if err != nil {
@@ -111,3 +128,61 @@ func CommandHandler(bot *gotgbot.Bot, ctx *ext.Context) error {
 
 	return nil
 }
+
+// ForgetMe handles the /forgetme command by asking the user to confirm deleting their data.
+func ForgetMe(bot *gotgbot.Bot, ctx *ext.Context) error {
+	update := ctx.EffectiveMessage
+
+	text := "<i>⚠️ This will <b>permanently delete</b> your settings, watchlist, alerts and reminders, are you sure ?</i>"
+	buttons := [][]gotgbot.InlineKeyboardButton{{
+		{Text: "🗑 Delete My Data", CallbackData: fmt.Sprintf("fgt_yes_%d", ctx.EffectiveUser.Id)},
+		{Text: "✖️ Cancel", CallbackData: fmt.Sprintf("fgt_no_%d", ctx.EffectiveUser.Id)},
+	}}
+
+	_, err := bot.SendMessage(update.Chat.Id, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons}})
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	return ext.EndGroups
+}
+
+// CbForgetMe handles the confirmation buttons of /forgetme, data is like fgt_<yes|no>_<user id>.
+func CbForgetMe(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.Split(update.Data, "_")
+	)
+
+	if len(split) < 3 {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	if split[2] != strconv.FormatInt(update.From.Id, 10) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Only The User Who Sent /forgetme Can Use These Buttons 🙅", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	text := "<i>Cancelled, your data was not deleted 👍</i>"
+
+	if split[1] == "yes" {
+		if err := forgetUser(update.From.Id); err != nil {
+			fmt.Printf("forgetme: %v\n", err)
+			update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Delete Your Data 🤧\nPlease Try Again Later !", ShowAlert: true})
+
+			return ext.EndGroups
+		}
+
+		text = "<i>✅ All your data has been deleted.</i>"
+	}
+
+	_, _, err := update.Message.EditText(bot, text, &gotgbot.EditMessageTextOpts{ParseMode: gotgbot.ParseModeHTML})
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	update.Answer(bot, nil)
+
+	return ext.EndGroups
+}
@@ -5,7 +5,7 @@ package plugins
 import "github.com/PaulSonOfLars/gotgbot/v2"
 
 var allTexts map[string]string = map[string]string{
-	"PRIVACY": `<i>This bot does not connect to any datbase and hence <b>does not store any user data</b> in any form.</i>`,
+	"PRIVACY": PrivacyText,
 
 	"ABOUT": `
 ○ <b>Language</b>: <a href='https://go.dev'>GO</a>
@@ -22,8 +22,19 @@ var allTexts map[string]string = map[string]string{
 /about : Get some data about the bot.
 /help  : Display this help message.
 /privacy: Leran how this bot uses your data.
+/settings: Change your default search, country, language and caption style.
 /imdb: Search or get a movie from IMDb.
-/jw: Search or get a movie from Justwatch
+/jw: Search or get a movie from Justwatch, add a country code to search another region like <code>/jw IN Inception</code>
+/person: Search or get the profile and filmography of an actor or director.
+/watchlist: See the titles you saved with the <b>➕ Watchlist</b> button, share them inline with <code>wl</code> followed by a name.
+/forgetme: Delete your settings, watchlist, alerts and reminders.
+/autocard: Let admins turn <code>on</code> or <code>off</code> replying to IMDb and JustWatch links in a group with title cards.
+/movienight: Start a poll to pick what to watch like <code>/movienight Dune; Arrival</code>, reply to my cards with it to add them.
+
+<i>Tap <b>🔔 Remind Me</b> on an upcoming movie or a running series to get a message when it's released or a new episode airs.</i>
+
+<i>Narrow down inline searches with filters like</i> <code>dune y:2021 type:movie rating&gt;7</code>
+<i>Available filters are</i> <code>y:2010-2015</code>, <code>type:movie|series|episode</code>, <code>rating&gt;7</code> <i>&amp;</i> <code>lang:es</code> <i>for titles originally in a language.</i>
 
 <i>Use the <b>buttons</b> below to search for a movie here 👇</i>
 `,
@@ -36,7 +47,16 @@ Check /help to see how to use me.</i>
 
 var allButtons map[string][][]gotgbot.InlineKeyboardButton = map[string][][]gotgbot.InlineKeyboardButton{
 	"ABOUT": {{homeButton, helpButton}, {{Text: "Source 🔗", Url: "https://github.com/Jisin0/filmigobot"}}},
-	"HELP":  append(inlineSearchButtons, []gotgbot.InlineKeyboardButton{aboutButton, homeButton}),
+}
+
+// commandButtons returns the buttons for a static command, menus with search buttons are built on demand.
+func commandButtons(cmd string) [][]gotgbot.InlineKeyboardButton {
+	switch cmd {
+	case "HELP":
+		return append(inlineSearchButtons(), []gotgbot.InlineKeyboardButton{aboutButton, homeButton})
+	default:
+		return allButtons[cmd]
+	}
 }
 
 // Single buttons used to build composite markups.
@@ -0,0 +1,87 @@
+// (c) Jisin0
+// Request contexts with deadlines derived from when an update arrived.
+
+package plugins
+
+import (
+	"context"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// ReceivedAtKey is the key in ext.Context.Data holding the time.Time at which an update was received.
+const ReceivedAtKey = "received_at"
+
+const (
+	// Time telegram waits for an inline query to be answered.
+	inlineQueryBudget = 8 * time.Second
+	// Time kept aside to send the answer to an inline query.
+	inlineAnswerMargin = 1500 * time.Millisecond
+	// Time allowed to handle commands, callbacks and chosen results.
+	updateBudget = time.Minute
+)
+
+// receivedAtProcessor records when an update arrived if the caller didn't, updates from polling are stamped as soon as they're dispatched.
+type receivedAtProcessor struct{}
+
+func (receivedAtProcessor) ProcessUpdate(d *ext.Dispatcher, b *gotgbot.Bot, ctx *ext.Context) error {
+	if _, ok := ctx.Data[ReceivedAtKey].(time.Time); !ok {
+		ctx.Data[ReceivedAtKey] = time.Now()
+	}
+
+	return ext.BaseProcessor{}.ProcessUpdate(d, b, ctx)
+}
+
+// receivedAt returns the time at which an update arrived or the current time if it's unknown.
+func receivedAt(ctx *ext.Context) time.Time {
+	if ctx != nil {
+		if t, ok := ctx.Data[ReceivedAtKey].(time.Time); ok {
+			return t
+		}
+	}
+
+	return time.Now()
+}
+
+// requestContext returns a context carrying the preferences of the user that ends budget after the update arrived.
+func requestContext(ctx *ext.Context, budget time.Duration) (context.Context, context.CancelFunc) {
+	var userID int64
+	if ctx != nil && ctx.EffectiveUser != nil {
+		userID = ctx.EffectiveUser.Id
+	}
+
+	reqCtx := withUser(withPrefs(context.Background(), getUserPrefs(userID)), userID)
+
+	if ctx != nil && ctx.EffectiveChat != nil && ctx.EffectiveChat.Type == gotgbot.ChatTypePrivate {
+		reqCtx = context.WithValue(reqCtx, privateChatKeyType{}, true)
+	}
+
+	return context.WithDeadline(reqCtx, receivedAt(ctx).Add(budget))
+}
+
+type privateChatKeyType struct{}
+
+// viewerFrom returns the id of the user a message is rendered for if it's only seen by them in a private chat, 0 otherwise.
+// Messages in groups and inline messages are seen by everyone so they don't show the state of a single user.
+func viewerFrom(ctx context.Context) int64 {
+	if private, _ := ctx.Value(privateChatKeyType{}).(bool); !private {
+		return 0
+	}
+
+	return userFrom(ctx)
+}
+
+type userKeyType struct{}
+
+// withUser returns a context carrying the id of the user an update is from.
+func withUser(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userKeyType{}, userID)
+}
+
+// userFrom returns the id of the user carried by a context or 0 if it's unknown.
+func userFrom(ctx context.Context) int64 {
+	id, _ := ctx.Value(userKeyType{}).(int64)
+	return id
+}
@@ -22,6 +22,8 @@ var Dispatcher *ext.Dispatcher = ext.NewDispatcher(&ext.DispatcherOpts{
 		return ext.DispatcherActionNoop
 	},
 	MaxRoutines: ext.DefaultMaxRoutines,
+	// Webhooks pass the time an update was received, polled updates are stamped when they're dispatched.
+	Processor: receivedAtProcessor{},
 })
 
 const (
@@ -32,17 +34,44 @@ const (
 func init() {
 	Dispatcher.AddHandlerToGroup(handlers.NewInlineQuery(inlinequery.All, InlineQueryHandler), 0)
 	Dispatcher.AddHandlerToGroup(handlers.NewChosenInlineResult(choseninlineresult.All, InlineResultHandler), 0)
+	Dispatcher.AddHandlerToGroup(handlers.NewPoll(closedPoll, MovieNightPoll), 0)
 
 	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("open_"), CbOpen), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("set_"), CbSettings), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("jwc_"), CbJWCountry), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("ssn_"), CbSeasons), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("eps_"), CbEpisodes), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("pfm_"), CbFilmography), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("sim_"), CbSimilar), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("trl_"), CbTrailer), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("cst_"), CbCast), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("wla_"), CbWatchlistToggle), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("wlp_"), CbWatchlist), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("wlr_"), CbWatchlist), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("fgt_"), CbForgetMe), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Prefix("rmd_"), CbReminder), callbackHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.Equal("mnc"), CbMovieNight), callbackHandlerGroup)
 	Dispatcher.AddHandlerToGroup(handlers.NewCallback(callbackquery.All, CbCommand), callbackHandlerGroup)
 
 	Dispatcher.AddHandlerToGroup(handlers.NewCommand("start", Start), commandHandlerGroup)
-	Dispatcher.AddHandlerToGroup(handlers.NewCommand("imdb", IMDbCommand), commandHandlerGroup)
-	Dispatcher.AddHandlerToGroup(handlers.NewCommand("justwatch", JWCommand), commandHandlerGroup)
-	Dispatcher.AddHandlerToGroup(handlers.NewCommand("jw", JWCommand), commandHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCommand("settings", Settings), commandHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCommand("watchlist", Watchlist), commandHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCommand("forgetme", ForgetMe), commandHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCommand("autocard", AutoCardCommand), commandHandlerGroup)
+	Dispatcher.AddHandlerToGroup(handlers.NewCommand("movienight", MovieNight), commandHandlerGroup)
+
+	// Search commands of each provider.
+	for _, method := range allSearchMethods {
+		for _, cmd := range providerRegistry[method].Info().Commands {
+			Dispatcher.AddHandlerToGroup(handlers.NewCommand(cmd, TitleCommand(method)), commandHandlerGroup)
+		}
+	}
 
 	// Static Commands.
 	Dispatcher.AddHandlerToGroup(handlers.NewMessage(allCommand, CommandHandler), commandHandlerGroup)
+
+	// Title links in groups, after commands so they're never answered with cards.
+	Dispatcher.AddHandlerToGroup(handlers.NewMessage(autoCardFilter, AutoCard), commandHandlerGroup)
 }
 
 func allCommand(msg *gotgbot.Message) bool {
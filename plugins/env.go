@@ -10,10 +10,15 @@ import (
 )
 
 var (
-	BotToken      string // bot token from @botfather
-	Port          string // port to run webapp
-	DefaultMethod string // default search method
-	OmdbApiKey    string // omdb api key
+	BotToken        string // bot token from @botfather
+	Port            string // port to run webapp
+	DefaultMethod   string // default search method
+	OmdbApiKey      string // omdb api key
+	CacheDir        string // directory to save cached responses in, cached in memory if empty
+	RedisURL        string // url of a redis compatible server to cache responses and save user data in
+	PosterCachePath string // json file to save uploaded posters in, kept in memory if empty
+	StorePath       string // json file to save user data like preferences in if redis isn't used, defaults to data/store.json
+	CronSecret      string // secret required to call the cron endpoint that runs background jobs
 )
 
 const stringTrue = true
@@ -28,4 +33,15 @@ func init() {
 	Port = os.Getenv("PORT")
 	DefaultMethod = os.Getenv("DEFAULT_SEARCH_METHOD")
 	OmdbApiKey = os.Getenv("OMDB_API_KEY")
+	CacheDir = os.Getenv("CACHE_DIR")
+	RedisURL = os.Getenv("REDIS_URL")
+	PosterCachePath = os.Getenv("POSTER_CACHE_PATH")
+	StorePath = os.Getenv("STORE_PATH")
+	CronSecret = os.Getenv("CRON_SECRET")
+
+	// Stores that depend on the environment are set up after it's loaded.
+	initHostTimeouts()
+	initCache()
+	initPosterStore()
+	initStore()
 }
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -10,7 +11,7 @@ import (
 
 // UploadEnvssh uploads photo/video to envs.sh.
 // Media type should either be "video" or "photo". "Animation" is considered "video" here.
-func UploadEnvssh(data *bytes.Buffer, id string) (string, error) {
+func UploadEnvssh(ctx context.Context, data *bytes.Buffer, id string) (string, error) {
 	// Create a buffer and a multipart writer
 	var requestBody bytes.Buffer
 	writer := multipart.NewWriter(&requestBody)
@@ -34,15 +35,14 @@ func UploadEnvssh(data *bytes.Buffer, id string) (string, error) {
 	}
 
 	// Send the POST request
-	req, err := http.NewRequest("POST", "https://envs.sh", &requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://envs.sh", &requestBody)
 	if err != nil {
 		return "", fmt.Errorf("error creating request: %v", err)
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
 	// Make the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := upstream.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("error sending request: %v", err)
 	}
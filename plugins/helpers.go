@@ -4,7 +4,10 @@
 package plugins
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"unicode"
@@ -72,13 +75,13 @@ func htmlLinkList(elems types.Links, sep string) string {
 			return ""
 		}
 
-		return fmt.Sprintf("<a href='%s'>%s</a>", elems[0].Href, elems[0].Text)
+		return htmlLink(elems[0].Href, elems[0].Text)
 	}
 
 	var b strings.Builder
 
 	if elems[0].Text != "" {
-		b.WriteString(fmt.Sprintf("<a href='%s'>%s</a>", elems[0].Href, elems[0].Text))
+		b.WriteString(htmlLink(elems[0].Href, elems[0].Text))
 	}
 
 	for _, e := range elems[1:] {
@@ -87,7 +90,7 @@ func htmlLinkList(elems types.Links, sep string) string {
 		}
 
 		b.WriteString(sep)
-		b.WriteString(fmt.Sprintf("<a href='%s'>%s</a>", e.Href, e.Text))
+		b.WriteString(htmlLink(e.Href, e.Text))
 	}
 
 	return b.String()
@@ -112,7 +115,7 @@ func mention(u *gotgbot.User) string {
 		name = name + " " + u.LastName
 	}
 
-	return fmt.Sprintf("<a href='tg://user?id=%v'>%v</a>", u.Id, name)
+	return fmt.Sprintf("<a href='tg://user?id=%v'>%v</a>", u.Id, escapeHTML(name))
 }
 
 // Checks if a string slice Contains an item.
@@ -125,3 +128,35 @@ func Contains(l []string, v string) bool {
 
 	return false
 }
+
+// loadJSONFile reads and decodes a json file into v.
+func loadJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// saveJSONFile encodes v and writes it to a file, a temporary file is renamed so the file is never left half written.
+func saveJSONFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:mnd // standard directory permissions.
+			return err
+		}
+	}
+
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o600); err != nil { //nolint:mnd // owner only permissions.
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
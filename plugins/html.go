@@ -0,0 +1,317 @@
+// (c) Jisin0
+// Helpers for the html used in telegram messages.
+
+package plugins
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Maximum length of visible text in telegram messages.
+const (
+	captionLimit = 1024
+	messageLimit = 4096
+)
+
+// Longest html entity that's treated as a single character while truncating like &#128512;.
+const maxEntityLength = 10
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	htmlEntityPattern = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|lt|gt|amp|quot);`)
+)
+
+// telegramTags are the html tags supported by telegram.
+var telegramTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true, "ins": true,
+	"s": true, "strike": true, "del": true, "span": true, "tg-spoiler": true,
+	"a": true, "tg-emoji": true, "code": true, "pre": true, "blockquote": true,
+}
+
+// escapeHTML escapes text from users or apis so that it can be placed inside html.
+func escapeHTML(s string) string {
+	return html.EscapeString(s)
+}
+
+// htmlLink returns a html link with escaped text.
+func htmlLink(href, text string) string {
+	return fmt.Sprintf("<a href='%s'>%s</a>", escapeHTML(href), escapeHTML(text))
+}
+
+// htmlToText removes the tags from html and escapes it again so that only the text is left.
+func htmlToText(s string) string {
+	return escapeHTML(html.UnescapeString(htmlTagPattern.ReplaceAllString(s, "")))
+}
+
+// validateHTML checks that s only uses tags supported by telegram that are nested properly and that every & starts an entity.
+func validateHTML(s string) error {
+	var open []string
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '<':
+			end := strings.IndexByte(s[i:], '>')
+			if end == -1 {
+				return fmt.Errorf("unclosed tag at %d", i)
+			}
+
+			tag := s[i : i+end+1]
+
+			name := tagName(tag)
+			if !telegramTags[name] {
+				return fmt.Errorf("unsupported tag %s", tag)
+			}
+
+			if strings.HasPrefix(tag, "</") {
+				if len(open) == 0 || open[len(open)-1] != name {
+					return fmt.Errorf("unexpected end tag %s", tag)
+				}
+
+				open = open[:len(open)-1]
+			} else {
+				open = append(open, name)
+			}
+
+			i += end
+		case '>':
+			return fmt.Errorf("unescaped > at %d", i)
+		case '&':
+			if !htmlEntityPattern.MatchString(s[i:]) {
+				return fmt.Errorf("unescaped & at %d", i)
+			}
+		}
+	}
+
+	if len(open) > 0 {
+		return fmt.Errorf("unclosed tag <%s>", open[len(open)-1])
+	}
+
+	return nil
+}
+
+// safeHTML returns s if it's valid telegram html or it's text without any formatting since telegram would reject it.
+func safeHTML(s string) string {
+	if err := validateHTML(s); err != nil {
+		fmt.Printf("invalid html: %v\n", err)
+		return htmlToText(s)
+	}
+
+	return s
+}
+
+// visibleLength returns the length of html as counted by telegram, tags are skipped and entities count as a single character.
+func visibleLength(s string) int {
+	return len(utf16.Encode([]rune(html.UnescapeString(htmlTagPattern.ReplaceAllString(s, "")))))
+}
+
+// truncateHTML shortens html to at most limit visible characters ending with an ellipsis.
+// Tags and entities are never cut and tags left open are closed at the end.
+func truncateHTML(s string, limit int) string {
+	if visibleLength(s) <= limit {
+		return s
+	}
+
+	var (
+		sb    strings.Builder
+		open  []string
+		count int
+		// Room kept for the ellipsis.
+		room = limit - 1
+	)
+
+	for i := 0; i < len(s) && count < room; {
+		switch s[i] {
+		case '<':
+			end := strings.IndexByte(s[i:], '>')
+			if end == -1 {
+				i = len(s)
+				continue
+			}
+
+			tag := s[i : i+end+1]
+			sb.WriteString(tag)
+
+			i += end + 1
+
+			name := tagName(tag)
+
+			switch {
+			case name == "":
+			case strings.HasPrefix(tag, "</"):
+				for j := len(open) - 1; j >= 0; j-- {
+					if open[j] == name {
+						open = open[:j]
+						break
+					}
+				}
+			case !strings.HasSuffix(tag, "/>"):
+				open = append(open, name)
+			}
+
+			continue
+		case '&':
+			if end := strings.IndexByte(s[i:], ';'); end > 0 && end < maxEntityLength {
+				entity := s[i : i+end+1]
+				if decoded := html.UnescapeString(entity); decoded != entity {
+					sb.WriteString(entity)
+
+					count += len(utf16.Encode([]rune(decoded)))
+					i += end + 1
+
+					continue
+				}
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if count+utf16Len(r) > room {
+			break
+		}
+
+		sb.WriteString(s[i : i+size])
+
+		count += utf16Len(r)
+		i += size
+	}
+
+	sb.WriteString("…")
+
+	for j := len(open) - 1; j >= 0; j-- {
+		sb.WriteString("</" + open[j] + ">")
+	}
+
+	return sb.String()
+}
+
+// utf16Len returns the number of utf-16 code units needed for r.
+func utf16Len(r rune) int {
+	if r >= 0x10000 { //nolint:mnd // runes outside the basic plane need a surrogate pair.
+		return 2
+	}
+
+	return 1
+}
+
+// tagName returns the lower case name of a tag like <a href='…'> or </a>.
+func tagName(tag string) string {
+	name := strings.TrimLeft(strings.Trim(tag, "<>/"), "/")
+	if i := strings.IndexAny(name, " \t\n/"); i != -1 {
+		name = name[:i]
+	}
+
+	return strings.ToLower(name)
+}
+
+// captionBuilder builds a caption from parts that are dropped in order of priority when it's too long.
+type captionBuilder struct {
+	parts []captionPart
+	// Id of the quote that parts are currently added to, 0 if there's none.
+	quote  int
+	quotes int
+}
+
+type captionPart struct {
+	html     string
+	priority int
+	quote    int
+}
+
+// Add adds a part to the caption, parts with a higher priority are dropped first and those with 0 are always kept.
+func (b *captionBuilder) Add(priority int, part string) {
+	b.parts = append(b.parts, captionPart{html: part, priority: priority, quote: b.quote})
+}
+
+// StartQuote starts a blockquote that holds the parts added until EndQuote, it's left out if all of them are dropped.
+func (b *captionBuilder) StartQuote() {
+	b.quotes++
+	b.quote = b.quotes
+}
+
+// EndQuote ends the current blockquote and leaves a blank line after it.
+func (b *captionBuilder) EndQuote() {
+	b.quote = 0
+}
+
+// Length returns the visible length of the caption with all of it's parts.
+func (b *captionBuilder) Length() int {
+	return visibleLength(renderCaptionParts(b.parts))
+}
+
+// Fit renders the caption dropping parts until it's within limit.
+// The longest part is truncated if the ones that are always kept are still too long.
+func (b *captionBuilder) Fit(limit int) string {
+	parts := append([]captionPart(nil), b.parts...)
+
+	for {
+		s := renderCaptionParts(parts)
+		if visibleLength(s) <= limit {
+			return s
+		}
+
+		drop := -1
+
+		for i, p := range parts {
+			if p.priority > 0 && (drop == -1 || p.priority >= parts[drop].priority) {
+				drop = i
+			}
+		}
+
+		if drop == -1 {
+			return truncateLongestPart(parts, visibleLength(s)-limit)
+		}
+
+		parts = append(parts[:drop], parts[drop+1:]...)
+	}
+}
+
+// truncateLongestPart shortens the longest part by excess visible characters or the whole caption if it's not long enough.
+func truncateLongestPart(parts []captionPart, excess int) string {
+	longest, length := 0, 0
+
+	for i, p := range parts {
+		if l := visibleLength(strings.TrimRight(p.html, "\n")); l > length {
+			longest, length = i, l
+		}
+	}
+
+	// Extra room for the ellipsis.
+	if length <= excess+1 {
+		s := renderCaptionParts(parts)
+		return truncateHTML(s, visibleLength(s)-excess)
+	}
+
+	// Line breaks after the part are kept.
+	text := strings.TrimRight(parts[longest].html, "\n")
+	parts[longest].html = truncateHTML(text, length-excess) + parts[longest].html[len(text):]
+
+	return renderCaptionParts(parts)
+}
+
+func renderCaptionParts(parts []captionPart) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(parts); {
+		quote := parts[i].quote
+		if quote == 0 {
+			sb.WriteString(parts[i].html)
+			i++
+
+			continue
+		}
+
+		var inner strings.Builder
+
+		for ; i < len(parts) && parts[i].quote == quote; i++ {
+			inner.WriteString(parts[i].html)
+		}
+
+		sb.WriteString("<blockquote>" + strings.TrimRight(inner.String(), "\n") + "</blockquote>\n\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,121 @@
+// (c) Jisin0
+// Tests for validating, fitting and truncating html captions.
+
+package plugins
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCaptionBuilderFit(t *testing.T) {
+	var cb captionBuilder
+
+	cb.Add(0, "Title\n")
+	cb.Add(1, "Plot\n")
+	cb.StartQuote()
+	cb.Add(3, "Themes\n")
+	cb.Add(3, "Writers\n")
+	cb.EndQuote()
+	cb.Add(2, "Stars\n")
+
+	if got, want := cb.Fit(1000), "Title\nPlot\n<blockquote>Themes\nWriters</blockquote>\n\nStars\n"; got != want {
+		t.Errorf("Fit() = %q; want %q", got, want)
+	}
+
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		// The later of two parts with the same priority is dropped first.
+		{cb.Length() - 1, "Title\nPlot\n<blockquote>Themes</blockquote>\n\nStars\n"},
+		// The quote is left out once all of it's parts are dropped.
+		{len("Title\nPlot\nStars\n"), "Title\nPlot\nStars\n"},
+		{len("Title\nPlot\n"), "Title\nPlot\n"},
+		{len("Title\n"), "Title\n"},
+		// Parts with priority 0 are truncated since they can't be dropped, the line break is kept.
+		{4, "Ti…\n"},
+	}
+
+	for _, tt := range tests {
+		if got := cb.Fit(tt.limit); got != tt.want {
+			t.Errorf("Fit(%d) = %q; want %q", tt.limit, got, tt.want)
+		}
+	}
+
+	// Fit doesn't change the parts so it can be called again with a larger limit.
+	if got := cb.Fit(1000); !strings.Contains(got, "Writers") {
+		t.Error("Fit() removed parts from the builder")
+	}
+}
+
+func TestTruncateHTML(t *testing.T) {
+	tests := []struct {
+		in    string
+		limit int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"hello world", 6, "hello…"},
+		{"<b>hello world</b>", 6, "<b>hello…</b>"},
+		{"<b><i>hello</i> world</b>", 8, "<b><i>hello</i> w…</b>"},
+		{"<a href='https://example.com/a&b'>link text</a>", 5, "<a href='https://example.com/a&b'>link…</a>"},
+		// Entities are counted as one character and never cut.
+		{"a &amp; b &amp; c", 4, "a &amp;…"},
+		// Emoji take two utf-16 units.
+		{"🎬🎬🎬", 4, "🎬…"},
+		{"<blockquote>quote</blockquote>", 3, "<blockquote>qu…</blockquote>"},
+	}
+
+	for _, tt := range tests {
+		got := truncateHTML(tt.in, tt.limit)
+		if got != tt.want {
+			t.Errorf("truncateHTML(%q, %d) = %q; want %q", tt.in, tt.limit, got, tt.want)
+		}
+
+		if l := visibleLength(got); l > tt.limit {
+			t.Errorf("truncateHTML(%q, %d) has %d characters", tt.in, tt.limit, l)
+		}
+
+		if err := validateHTML(got); err != nil && validateHTML(tt.in) == nil {
+			t.Errorf("truncateHTML(%q, %d) made invalid html: %v", tt.in, tt.limit, err)
+		}
+	}
+}
+
+func TestValidateHTML(t *testing.T) {
+	valid := []string{
+		"plain text",
+		"<b>bold</b> <i>italic</i>",
+		"<a href='https://example.com/?a=1&amp;b=2'>link</a>",
+		"<blockquote><b>nested</b></blockquote>",
+		"&lt;tag&gt; &amp; &quot; &#39; &#x1F600;",
+		"<tg-spoiler>spoiler</tg-spoiler>",
+	}
+
+	for _, s := range valid {
+		if err := validateHTML(s); err != nil {
+			t.Errorf("validateHTML(%q) = %v; want nil", s, err)
+		}
+	}
+
+	invalid := []string{
+		"<div>unsupported</div>",
+		"<b><i>misnested</b></i>",
+		"<b>unclosed",
+		"</b>",
+		"fish & chips",
+		"1 > 0",
+		"<b",
+	}
+
+	for _, s := range invalid {
+		if err := validateHTML(s); err == nil {
+			t.Errorf("validateHTML(%q) = nil; want an error", s)
+		}
+	}
+
+	if got := safeHTML("fish & <div>chips</div>"); got != "fish &amp; chips" {
+		t.Errorf("safeHTML() = %q; want the escaped text", got)
+	}
+}
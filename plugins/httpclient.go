@@ -0,0 +1,361 @@
+// (c) Jisin0
+// Shared http client with timeouts, retries and per-host rate limiting.
+
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Number of times a request is retried after a 5xx or 429 response.
+	httpMaxRetries = 3
+	// Delay before the first retry, doubled after each attempt.
+	httpBaseBackoff = 500 * time.Millisecond
+	// Longest delay allowed between two attempts.
+	httpMaxBackoff = 5 * time.Second
+)
+
+// hostLimits are the timeout and rate limit used for requests to a host.
+type hostLimits struct {
+	// Timeout of a single attempt.
+	Timeout time.Duration
+	// Requests allowed per second.
+	Rate float64
+	// Requests allowed in a burst.
+	Burst int
+}
+
+var (
+	defaultHostLimits = hostLimits{Timeout: 8 * time.Second, Rate: 5, Burst: 10}
+
+	// Limits for upstream hosts that need different values from the defaults.
+	hostLimitsMap = map[string]hostLimits{
+		"imdb.iamidiotareyoutoo.com": {Timeout: 6 * time.Second, Rate: 5, Burst: 10},
+		"api.imdbapi.dev":            {Timeout: 6 * time.Second, Rate: 5, Burst: 10},
+		"api.themoviedb.org":         {Timeout: 5 * time.Second, Rate: 20, Burst: 40},
+		"www.omdbapi.com":            {Timeout: 5 * time.Second, Rate: 5, Burst: 10},
+		"apis.justwatch.com":         {Timeout: 8 * time.Second, Rate: 5, Burst: 10},
+		"api.telegra.ph":             {Timeout: 10 * time.Second, Rate: 2, Burst: 5},
+		"te.legra.ph":                {Timeout: 20 * time.Second, Rate: 2, Burst: 5},
+		"envs.sh":                    {Timeout: 20 * time.Second, Rate: 2, Burst: 5},
+	}
+)
+
+// upstream is the client used for all requests to external apis.
+var upstream = newHTTPClient()
+
+// httpClient sends requests with a timeout per attempt, retries failed requests with exponential backoff and rate limits each host.
+type httpClient struct {
+	client *http.Client
+
+	mu       sync.Mutex
+	limiters map[string]*tokenBucket
+}
+
+func newHTTPClient() *httpClient {
+	return &httpClient{
+		client:   &http.Client{},
+		limiters: make(map[string]*tokenBucket),
+	}
+}
+
+// initHostTimeouts reads timeouts overridden with HTTP_TIMEOUTS for ex: envs.sh=30s,api.imdbapi.dev=3s.
+func initHostTimeouts() {
+	for _, item := range strings.Split(os.Getenv("HTTP_TIMEOUTS"), ",") {
+		host, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			fmt.Printf("bad timeout for %s in HTTP_TIMEOUTS: %v\n", host, err)
+			continue
+		}
+
+		limits, ok := hostLimitsMap[host]
+		if !ok {
+			limits = defaultHostLimits
+		}
+
+		limits.Timeout = timeout
+		hostLimitsMap[host] = limits
+	}
+}
+
+func limitsFor(host string) hostLimits {
+	if l, ok := hostLimitsMap[host]; ok {
+		return l
+	}
+
+	return defaultHostLimits
+}
+
+// limiter returns the token bucket of a host.
+func (c *httpClient) limiter(host string) *tokenBucket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.limiters[host]
+	if !ok {
+		l := limitsFor(host)
+		b = newTokenBucket(l.Rate, l.Burst)
+		c.limiters[host] = b
+	}
+
+	return b
+}
+
+// Get sends a GET request to url.
+func (c *httpClient) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req)
+}
+
+// PostForm sends a POST request with url encoded form data.
+func (c *httpClient) PostForm(ctx context.Context, url string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.Do(req)
+}
+
+// Do sends a request using it's context, the request body must be rewindable using GetBody for it to be retried.
+func (c *httpClient) Do(req *http.Request) (*http.Response, error) {
+	var (
+		ctx     = req.Context()
+		host    = req.URL.Hostname()
+		limits  = limitsFor(host)
+		limiter = c.limiter(host)
+	)
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, limits.Timeout)
+
+		r := req.Clone(attemptCtx)
+
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				cancel()
+				return nil, fmt.Errorf("request to %s can't be retried", host)
+			}
+
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+
+			r.Body = body
+		}
+
+		resp, err := c.client.Do(r)
+
+		retry := attempt < httpMaxRetries && ctx.Err() == nil &&
+			(err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError)
+		if !retry {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+
+			// The attempt is only cancelled once the body is closed so it can still be read.
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+			return resp, nil
+		}
+
+		wait := backoff(attempt)
+
+		if resp != nil {
+			if d, ok := retryAfter(resp); ok {
+				wait = d
+			}
+
+			io.Copy(io.Discard, resp.Body) //nolint:errcheck // draining so the connection is reused.
+			resp.Body.Close()
+		}
+
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// backoff returns the delay before retrying the given attempt with some jitter.
+func backoff(attempt int) time.Duration {
+	d := httpBaseBackoff << attempt
+	if d > httpMaxBackoff {
+		d = httpMaxBackoff
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2))) //nolint:gosec // jitter doesn't need to be secure.
+}
+
+// retryAfter reads the delay from the Retry-After header of a response.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+
+	d := time.Duration(secs) * time.Second
+	if d > httpMaxBackoff {
+		d = httpMaxBackoff
+	}
+
+	return d, true
+}
+
+// cancelBody cancels the context of a request when it's body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// tokenBucket allows rate requests per second with bursts of up to burst requests.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// errRateLimited is returned when a token won't be available before the context's deadline.
+var errRateLimited = errors.New("rate limited")
+
+// refill adds the tokens earned since the last call, b.mu must be held.
+func (b *tokenBucket) refill() {
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	b.last = now
+
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// Allow takes a token if one is available without waiting.
+func (b *tokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+// Full reports whether the bucket has refilled to it's burst, it behaves like a new bucket then.
+func (b *tokenBucket) Full() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+
+	return b.tokens >= b.burst
+}
+
+// Wait blocks until a token is available or the context is done.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.refill()
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+
+			return nil
+		}
+
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return errRateLimited
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// withLimits runs a call made by a library that doesn't accept a context, it's rate limited as host and abandoned once the context or host timeout ends.
+func withLimits[T any](ctx context.Context, host string, call func() (T, error)) (T, error) {
+	var zero T
+
+	if err := upstream.limiter(host).Wait(ctx); err != nil {
+		return zero, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, limitsFor(host).Timeout)
+	defer cancel()
+
+	type result struct {
+		v   T
+		err error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		v, err := call()
+		done <- result{v, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case r := <-done:
+		return r.v, r.err
+	}
+}
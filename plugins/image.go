@@ -5,12 +5,12 @@ package plugins
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image"
 	"image/draw"
 	"image/jpeg"
 	"image/png"
-	"net/http"
 	"strings"
 
 	"github.com/fogleman/gg"
@@ -23,16 +23,16 @@ const (
 )
 
 // Creates a poster image with a backdrop and poster as overlay.
-func CreateJWPoster(backdropURL, posterURL, id string) *bytes.Buffer {
+func CreateJWPoster(ctx context.Context, backdropURL, posterURL, id string) *bytes.Buffer {
 	// Load the backdrop image
-	backdrop, err := loadImage(backdropURL)
+	backdrop, err := loadImage(ctx, backdropURL)
 	if err != nil {
 		fmt.Println("Error loading backdrop:", err)
 		return nil
 	}
 
 	// Load the poster image
-	poster, err := loadImage(posterURL)
+	poster, err := loadImage(ctx, posterURL)
 	if err != nil {
 		fmt.Println("Error loading poster:", err)
 		return nil
@@ -77,8 +77,8 @@ func CreateJWPoster(backdropURL, posterURL, id string) *bytes.Buffer {
 }
 
 // loadImage loads an image from a URL
-func loadImage(url string) (image.Image, error) {
-	resp, err := http.Get(url) //nolint:gosec // can't make this a constant
+func loadImage(ctx context.Context, url string) (image.Image, error) {
+	resp, err := upstream.Get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
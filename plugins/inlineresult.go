@@ -4,6 +4,7 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -32,35 +33,18 @@ func InlineResultHandler(bot *gotgbot.Bot, ctx *ext.Context) error {
 		id     = args[1]
 	)
 
-	// --- FIX: Pass status updater ---
-	statusUpdater := func(msg string) {
-		bot.EditMessageText(msg, &gotgbot.EditMessageTextOpts{
-			InlineMessageId: update.InlineMessageId,
-			ParseMode:       gotgbot.ParseModeHTML,
-		})
-	}
-	
-	previewURL, caption, buttons, err := getChosenResult(method, id, statusUpdater)
+	target := messageTarget{InlineMessageID: update.InlineMessageId}
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	card, err := getChosenResult(reqCtx, method, id, progressUpdater(bot, target))
 	if err != nil {
 		fmt.Println(err)
 		return nil
 	}
 
-	messageText := fmt.Sprintf("<a href=\"%s\">&#8203;</a>%s", previewURL, caption)
-
-	_, _, err = bot.EditMessageText(
-		messageText,
-		&gotgbot.EditMessageTextOpts{
-			InlineMessageId: update.InlineMessageId,
-			ParseMode:       gotgbot.ParseModeHTML,
-			ReplyMarkup:     gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons},
-			LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
-				IsDisabled:      false,
-				ShowAboveText: true,
-				Url:             previewURL,
-			},
-		},
-	)
+	err = editCard(bot, target, card)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -68,17 +52,14 @@ func InlineResultHandler(bot *gotgbot.Bot, ctx *ext.Context) error {
 	return nil
 }
 
-// --- FIX: Signature updated ---
-func getChosenResult(method, id string, progress func(string)) (string, string, [][]gotgbot.InlineKeyboardButton, error) {
-	switch method {
-	case searchMethodIMDb:
-		return GetOMDbTitle(id, progress)
-	case searchMethodOMDb:
-		return GetOMDbTitle(id, progress)
-	default:
-		fmt.Println("unknown method on choseninlineresult : " + method)
-		return GetOMDbTitle(id, progress)
+// getChosenResult gets the full title from the provider of the given method.
+func getChosenResult(ctx context.Context, method, id string, progress func(string)) (*TitleCard, error) {
+	p, ok := getProvider(method)
+	if !ok {
+		return nil, fmt.Errorf("unknown method on choseninlineresult : %s", method)
 	}
+
+	return p.GetTitle(ctx, id, progress)
 }
 
 func CbOpen(bot *gotgbot.Bot, ctx *ext.Context) error {
@@ -93,47 +74,36 @@ func CbOpen(bot *gotgbot.Bot, ctx *ext.Context) error {
 	var (
 		method = split[1]
 		id     = split[2]
-		
-		previewURL string
-		caption   string
-		buttons   [][]gotgbot.InlineKeyboardButton
-		err       error
 	)
 
-	// --- FIX: Pass status updater ---
-	statusUpdater := func(msg string) {
-		update.Message.EditText(bot, msg, &gotgbot.EditMessageTextOpts{ParseMode: gotgbot.ParseModeHTML})
+	p, ok := getProvider(method)
+	if !ok {
+		fmt.Println("unknown method on cbopen: " + method)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+
+		return ext.EndGroups
 	}
 
-	switch method {
-	case searchMethodIMDb:
-		previewURL, caption, buttons, err = GetOMDbTitle(id, statusUpdater)
-	case searchMethodOMDb:
-		previewURL, caption, buttons, err = GetOMDbTitle(id, statusUpdater)
-	default:
-		fmt.Println("unknown method on cbopen: " + method)
-		previewURL, caption, buttons, err = GetOMDbTitle(id, statusUpdater)
+	target := callbackTarget(update)
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	// Regional titles may be opened in a country other than the user's.
+	if len(split) > 3 && p.Info().Regional && isCountryCode(split[3]) {
+		reqCtx = withCountry(reqCtx, split[3])
 	}
 
+	card, err := p.GetTitle(reqCtx, id, progressUpdater(bot, target))
 	if err != nil {
-		fmt.Printf("cbopen: %v", err)
+		fmt.Printf("cbopen: %v\n", err)
 		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Fetch Data on That Movie 🤧\nPlease Try Again Later or Contact Admins !", ShowAlert: true})
 		return nil
 	}
 
-	messageText := fmt.Sprintf("<a href=\"%s\">&#8203;</a>%s", previewURL, caption)
-
-	_, _, err = update.Message.EditText(bot, messageText, &gotgbot.EditMessageTextOpts{
-		ParseMode:   gotgbot.ParseModeHTML,
-		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons},
-		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
-			IsDisabled:      false,
-			ShowAboveText: true,
-			Url:             previewURL,
-		},
-	})
+	err = editCard(bot, target, card)
 	if err != nil {
-		fmt.Printf("cbopen: %v", err)
+		fmt.Printf("cbopen: %v\n", err)
 	}
 
 	return nil
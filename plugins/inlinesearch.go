@@ -4,60 +4,65 @@
 package plugins
 
 import (
+	"context"
+	"crypto/sha1" //nolint:gosec // only used to shorten cursors.
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 )
 
-var (
-	defaultSearchMethod = searchMethodJW
-	allSearchMethods    = []string{searchMethodIMDb, searchMethodOMDb, searchMethodJW}
-)
-
-// Search methods with a whitespace added after for a seamless search.
-var (
-	inlineIMDbSwitch = searchMethodIMDb + " "
-	inlineJWSwitch   = searchMethodJW + " "
-	inlineOMDbSwitch = searchMethodOMDb + " "
-)
+var defaultSearchMethod = searchMethodJW
 
 var (
 	startSearchingButton = &gotgbot.InlineQueryResultsButton{Text: "Start typing the name of your movie to search ...", StartParameter: "nvm"}
 	searchResultsButton  = &gotgbot.InlineQueryResultsButton{Text: "Here Are Your Results 👇", StartParameter: "nvm2"}
 
 	notFoundImage = "https://telegra.ph/file/24788bfd2b087c292fbe2.jpg"
+)
 
-	inlineSearchButtons = [][]gotgbot.InlineKeyboardButton{
-		{{Text: "📺 Search IMDb", SwitchInlineQueryCurrentChat: &inlineIMDbSwitch}},
-		{{Text: "💻 Search OTT", SwitchInlineQueryCurrentChat: &inlineJWSwitch}},
-	}
-
-	noResultsArticle = gotgbot.InlineQueryResultArticle{
+// noResultsArticle returns the article shown when a query has no results.
+func noResultsArticle() gotgbot.InlineQueryResultArticle {
+	return gotgbot.InlineQueryResultArticle{
 		Id:    notAvailable,
 		Title: "No Results Were Found for Your Query !",
 		InputMessageContent: gotgbot.InputTextMessageContent{
 			MessageText: "<i>👋 Sorry I didn't find anything for that !\nUse the buttons below to Search Again 👇</i>",
 			ParseMode:   gotgbot.ParseModeHTML,
 		},
-		ReplyMarkup:  &gotgbot.InlineKeyboardMarkup{InlineKeyboard: inlineSearchButtons},
+		ReplyMarkup:  &gotgbot.InlineKeyboardMarkup{InlineKeyboard: inlineSearchButtons()},
 		ThumbnailUrl: notFoundImage,
 	}
-)
+}
 
 const (
 	// The time in seconds that results for a query can be cached by a client.
 	defaultCacheTime = 2000
+	// The time in seconds that partial results can be cached by a client.
+	partialCacheTime = 5
+
+	// Number of results in each page of an inline query.
+	inlinePageSize = 10
+	// Maximum number of results shown for a query across all pages.
+	maxInlineResults = 50
+
+	// Time a search may keep running after the inline query was answered.
+	lateSearchTimeout = 20 * time.Second
+	// Time for which the last results of a search are kept to answer slow queries.
+	lastSearchTTL = 7 * 24 * time.Hour
 )
 
 func init() {
-	switch DefaultMethod {
-	case "":
+	if DefaultMethod == "" {
 		DefaultMethod = defaultSearchMethod
-	case searchMethodIMDb, searchMethodJW, searchMethodOMDb:
-	default:
+	} else if !isDefaultMethodChoice(DefaultMethod) {
 		fmt.Printf("error: unknown search method \"%s\", using default method \"%s\"\n", DefaultMethod, defaultSearchMethod)
+		DefaultMethod = defaultSearchMethod
 	}
 }
 
@@ -83,42 +88,296 @@ func InlineQueryHandler(bot *gotgbot.Bot, ctx *ext.Context) error {
 		query = args[1]
 	}
 
+	if method == watchlistInlinePrefix {
+		return answerWatchlistQuery(bot, update, query)
+	}
+
 	if Contains(allSearchMethods, method) && len(query) < 1 {
 		_, err := update.Answer(bot, []gotgbot.InlineQueryResult{}, &gotgbot.AnswerInlineQueryOpts{CacheTime: defaultCacheTime, Button: startSearchingButton})
 
 		return err
 	}
 
-	results := getInlineResults(method, query, fullQuery)
-	if len(results) < 1 {
-		_, err := update.Answer(bot, []gotgbot.InlineQueryResult{noResultsArticle}, &gotgbot.AnswerInlineQueryOpts{
-			CacheTime: defaultCacheTime,
-			Button:    searchResultsButton,
+	reqCtx, cancel := requestContext(ctx, inlineQueryBudget-inlineAnswerMargin)
+	defer cancel()
+
+	offset, ok := parseInlineOffset(update.Offset)
+	if !ok {
+		// The cursor of an old search expired, there's nothing left to show.
+		_, err := update.Answer(bot, []gotgbot.InlineQueryResult{}, &gotgbot.AnswerInlineQueryOpts{CacheTime: partialCacheTime, IsPersonal: true})
+
+		return err
+	}
+
+	results, nextOffset, complete := getInlineResults(reqCtx, method, query, fullQuery, offset)
+
+	// Partial answers are cached briefly so the client asks again once slower providers have finished.
+	cacheTime := int64(defaultCacheTime)
+	if !complete {
+		cacheTime = partialCacheTime
+	}
+
+	if len(results) < 1 && offset.Shown < 1 {
+		_, err := update.Answer(bot, []gotgbot.InlineQueryResult{noResultsArticle()}, &gotgbot.AnswerInlineQueryOpts{
+			CacheTime:  cacheTime,
+			IsPersonal: true,
+			Button:     searchResultsButton,
 		})
 
 		return err
 	}
 
 	_, err := update.Answer(bot, results, &gotgbot.AnswerInlineQueryOpts{
-		CacheTime: defaultCacheTime,
-		Button:    searchResultsButton,
+		CacheTime:  cacheTime,
+		IsPersonal: true, // results depend on the user's preferences.
+		Button:     searchResultsButton,
+		NextOffset: nextOffset,
 	})
 
 	return err
 }
 
-// Returns inline results based on given method.
-//
-//nolint:unparam // linter claims I don't use fullQuery.
-func getInlineResults(method, query, fullQuery string) []gotgbot.InlineQueryResult {
-	switch method {
-	case searchMethodJW:
-		return JWInlineSearch(query)
-	case searchMethodIMDb:
-		return IMDbInlineSearch(query)
-	case searchMethodOMDb:
-		return OMDbInlineSearch(query)
-	default:
-		return getInlineResults(DefaultMethod, fullQuery, fullQuery)
+// Returns a page of inline results from the provider of the given method or the default provider.
+// The offset of the next page is empty if there are no more results, complete reports whether every provider answered before the deadline.
+func getInlineResults(ctx context.Context, method, query, fullQuery string, offset inlineOffset) (results []gotgbot.InlineQueryResult, nextOffset string, complete bool) {
+	p, ok := getProvider(method)
+	if !ok {
+		p, _ = getProvider(prefsFrom(ctx).Method)
+		query = fullQuery
+	}
+
+	items, next, complete := searchInline(ctx, p, query, offset)
+
+	for _, item := range items {
+		results = append(results, p.InlineResult(item))
+	}
+
+	return results, next.String(), complete
+}
+
+// searchInline searches a page of results for an inline query continuing from offset and returns the offset of the next page.
+// Filters are applied after searching so filtered searches read pages until one is filled or a page's worth of results were skipped.
+func searchInline(ctx context.Context, p Provider, query string, offset inlineOffset) (items []UniversalSearchResult, next inlineOffset, complete bool) {
+	if offset.Shown < 0 || offset.Shown >= maxInlineResults {
+		return nil, next, true
+	}
+
+	query, filters := parseSearchQuery(query)
+	if query == "" {
+		return nil, next, true
+	}
+
+	var (
+		cursor  = offset.Cursor
+		scanned int
+	)
+
+	ctx = withSearchFilters(ctx, filters)
+	complete = true
+
+	for {
+		searches, done := searchProviders(ctx, []Provider{p}, query, cursor, inlinePageSize)
+		s := searches[0]
+
+		complete = complete && done
+		scanned += len(s.Items)
+		cursor = s.Next
+
+		if filters.Language != "" {
+			fillLanguages(ctx, s.Items)
+		}
+
+		items = append(items, filters.Filter(s.Items)...)
+
+		if cursor == "" || !filters.Narrows() || len(items) >= inlinePageSize || scanned >= maxInlineResults || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if left := maxInlineResults - offset.Shown; len(items) >= left {
+		return items[:left], next, complete
+	}
+
+	if cursor != "" {
+		next = inlineOffset{Shown: offset.Shown + len(items), Cursor: cursor}
+	}
+
+	return items, next, complete
+}
+
+// inlineOffset is the position of the next page of an inline query.
+type inlineOffset struct {
+	// Number of results already shown.
+	Shown int
+	// Cursor of the provider's next page.
+	Cursor string
+}
+
+// Maximum length of the offset of an inline query and the prefix of cursors that were too long to fit in it.
+const (
+	maxOffsetLength = 64
+	offsetRefPrefix = "#"
+)
+
+// String encodes an offset as shown:cursor or an empty string if there's no next page.
+// Cursors that don't fit in an offset are saved in the cache and replaced by their hash.
+func (o inlineOffset) String() string {
+	if o.Cursor == "" {
+		return ""
 	}
+
+	s := fmt.Sprintf("%d:%s", o.Shown, o.Cursor)
+	if len(s) <= maxOffsetLength && !strings.HasPrefix(o.Cursor, offsetRefPrefix) {
+		return s
+	}
+
+	sum := sha1.Sum([]byte(o.Cursor)) //nolint:gosec // only used to shorten cursors.
+	ref := offsetRefPrefix + hex.EncodeToString(sum[:])
+
+	responseCache.Set(cacheKey("offset", ref), []byte(o.Cursor), searchCacheTTL)
+
+	return fmt.Sprintf("%d:%s", o.Shown, ref)
+}
+
+// parseInlineOffset decodes the offset of an inline query, it reports false if the offset is invalid or it's cursor expired.
+func parseInlineOffset(s string) (inlineOffset, bool) {
+	if s == "" {
+		return inlineOffset{}, true
+	}
+
+	shown, cursor, ok := strings.Cut(s, ":")
+	if !ok || cursor == "" {
+		return inlineOffset{}, false
+	}
+
+	n, err := strconv.Atoi(shown)
+	if err != nil {
+		return inlineOffset{}, false
+	}
+
+	if strings.HasPrefix(cursor, offsetRefPrefix) {
+		data, ok := responseCache.Get(cacheKey("offset", cursor))
+		if !ok {
+			return inlineOffset{}, false
+		}
+
+		cursor = string(data)
+	}
+
+	return inlineOffset{Shown: n, Cursor: cursor}, true
+}
+
+// providerSearch is the outcome of searching a single provider.
+type providerSearch struct {
+	Provider Provider
+	Items    []UniversalSearchResult
+	// Cursor of the next page, empty on the last page.
+	Next string
+	Err  error
+}
+
+// searchProvider searches a page of up to limit results after cursor.
+// Providers that can't continue a search are searched up to the end of the page and their cursor is the number of results before it.
+func searchProvider(ctx context.Context, p Provider, query, cursor string, limit int) ([]UniversalSearchResult, string, error) {
+	if pp, ok := p.(pagedProvider); ok {
+		return pp.SearchPage(ctx, query, cursor, limit)
+	}
+
+	start, _ := strconv.Atoi(cursor)
+
+	items, err := p.Search(ctx, query, start+limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(items) >= start+limit {
+		next = strconv.Itoa(start + limit)
+	}
+
+	return items[min(start, len(items)):], next, nil
+}
+
+// searchProviders searches a page of each provider concurrently and returns what has arrived when ctx is done.
+// Providers that didn't answer in time use their last saved results and keep searching in the background to warm the cache.
+func searchProviders(ctx context.Context, providers []Provider, query, cursor string, limit int) ([]providerSearch, bool) {
+	done := make(chan int, len(providers))
+	searches := make([]providerSearch, len(providers))
+
+	// Searches outlive the deadline so late results are cached for the next query.
+	searchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lateSearchTimeout)
+
+	for i, p := range providers {
+		go func(i int, p Provider) {
+			items, next, err := searchProvider(searchCtx, p, query, cursor, limit)
+			if err == nil {
+				saveLastSearch(p, query, cursor, limit, searchPage{Items: items, Next: next})
+			}
+
+			searches[i] = providerSearch{Provider: p, Items: items, Next: next, Err: err}
+			done <- i
+		}(i, p)
+	}
+
+	var (
+		finished = make([]bool, len(providers))
+		pending  = len(providers)
+	)
+
+wait:
+	for pending > 0 {
+		select {
+		case i := <-done:
+			finished[i] = true
+			pending--
+		case <-ctx.Done():
+			break wait
+		}
+	}
+
+	if pending == 0 {
+		cancel()
+		return searches, true
+	}
+
+	// Release the context once the remaining searches are done.
+	go func() {
+		for ; pending > 0; pending-- {
+			<-done
+		}
+
+		cancel()
+	}()
+
+	results := make([]providerSearch, len(providers))
+
+	for i, p := range providers {
+		if finished[i] {
+			results[i] = searches[i]
+		} else {
+			page, _ := lastSearch(p, query, cursor, limit)
+			results[i] = providerSearch{Provider: p, Items: page.Items, Next: page.Next, Err: ctx.Err()}
+		}
+	}
+
+	return results, false
+}
+
+// saveLastSearch saves a page of results for longer than the search cache so it can be served when a provider is slow.
+func saveLastSearch(p Provider, query, cursor string, limit int, page searchPage) {
+	if data, err := json.Marshal(page); err == nil {
+		responseCache.Set(cacheKey("lastsearch", p.Info().Name, strconv.Itoa(limit), cursor, query), data, lastSearchTTL)
+	}
+}
+
+// lastSearch returns the last saved page of a search.
+func lastSearch(p Provider, query, cursor string, limit int) (searchPage, bool) {
+	var page searchPage
+
+	data, ok := responseCache.Get(cacheKey("lastsearch", p.Info().Name, strconv.Itoa(limit), cursor, query))
+	if !ok {
+		return page, false
+	}
+
+	return page, json.Unmarshal(data, &page) == nil
 }
@@ -0,0 +1,347 @@
+// (c) Jisin0
+// Tests for paging inline search results.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// fakeProvider searches a fixed list of titles, it's cursor is the index of the next title.
+type fakeProvider struct {
+	info  ProviderInfo
+	items []UniversalSearchResult
+	// Number of results requested from each search.
+	calls []int
+}
+
+func newFakeProvider(name string, n int) *fakeProvider {
+	p := &fakeProvider{info: ProviderInfo{Name: name}}
+
+	for i := 0; i < n; i++ {
+		p.items = append(p.items, UniversalSearchResult{ID: fmt.Sprintf("%s%d", name, i), Title: fmt.Sprintf("Title %d", i), Year: 2000 + i%3})
+	}
+
+	return p
+}
+
+func (p *fakeProvider) Info() *ProviderInfo {
+	return &p.info
+}
+
+func (p *fakeProvider) Search(_ context.Context, _ string, limit int) ([]UniversalSearchResult, error) {
+	p.calls = append(p.calls, limit)
+	return p.items[:min(limit, len(p.items))], nil
+}
+
+func (p *fakeProvider) InlineResult(item UniversalSearchResult) gotgbot.InlineQueryResult {
+	return gotgbot.InlineQueryResultArticle{Id: item.ID, Title: item.Title}
+}
+
+func (p *fakeProvider) GetTitle(context.Context, string, func(string)) (*TitleCard, error) {
+	return nil, nil
+}
+
+// fakePagedProvider is a fakeProvider that can continue a search.
+type fakePagedProvider struct {
+	*fakeProvider
+}
+
+func (p fakePagedProvider) SearchPage(_ context.Context, _ string, cursor string, limit int) ([]UniversalSearchResult, string, error) {
+	p.calls = append(p.calls, limit)
+
+	start, _ := strconv.Atoi(cursor)
+	end := min(start+limit, len(p.items))
+
+	var next string
+	if end < len(p.items) {
+		next = strconv.Itoa(end)
+	}
+
+	return p.items[start:end], next, nil
+}
+
+// scrollInline reads every page of an inline query like a client would and returns the ids of the results.
+func scrollInline(t *testing.T, p Provider, query string) []string {
+	t.Helper()
+
+	var (
+		ids    []string
+		offset inlineOffset
+	)
+
+	for pages := 0; ; pages++ {
+		if pages > maxInlineResults {
+			t.Fatal("scrolling didn't end")
+		}
+
+		items, next, complete := searchInline(context.Background(), p, query, offset)
+		if !complete {
+			t.Errorf("page %d wasn't complete", pages)
+		}
+
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+
+		s := next.String()
+		if s == "" {
+			return ids
+		}
+
+		if len(s) > maxOffsetLength {
+			t.Fatalf("offset %q is longer than %d bytes", s, maxOffsetLength)
+		}
+
+		var ok bool
+
+		if offset, ok = parseInlineOffset(s); !ok {
+			t.Fatalf("offset %q couldn't be parsed", s)
+		}
+	}
+}
+
+// checkUnique fails if an id is repeated.
+func checkUnique(t *testing.T, ids []string) {
+	t.Helper()
+
+	seen := make(map[string]bool, len(ids))
+
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("%s was shown twice", id)
+		}
+
+		seen[id] = true
+	}
+}
+
+func TestInlineOffset(t *testing.T) {
+	if o, ok := parseInlineOffset(""); !ok || o != (inlineOffset{}) {
+		t.Errorf("empty offset = %+v, %v; want the first page", o, ok)
+	}
+
+	if s := (inlineOffset{Shown: 10}).String(); s != "" {
+		t.Errorf("offset without a cursor = %q; want none", s)
+	}
+
+	short := inlineOffset{Shown: 10, Cursor: "YXJyYXljb25uZWN0aW9uOjk="}
+	if s := short.String(); s != "10:YXJyYXljb25uZWN0aW9uOjk=" {
+		t.Errorf("short offset = %q", s)
+	}
+
+	// Long cursors and ones that look like a reference are saved in the cache.
+	for _, cursor := range []string{strings.Repeat("Ab", 40), "#abc"} {
+		o := inlineOffset{Shown: 20, Cursor: cursor}
+
+		s := o.String()
+		if len(s) > maxOffsetLength || strings.Contains(s, cursor) {
+			t.Errorf("offset of cursor %q = %q; want a reference", cursor, s)
+		}
+
+		if got, ok := parseInlineOffset(s); !ok || got != o {
+			t.Errorf("parseInlineOffset(%q) = %+v, %v; want %+v", s, got, ok, o)
+		}
+	}
+
+	for _, bad := range []string{"1", "x:cursor", "10:", "10:#0000"} {
+		if o, ok := parseInlineOffset(bad); ok {
+			t.Errorf("parseInlineOffset(%q) = %+v; want it to fail", bad, o)
+		}
+	}
+}
+
+func TestSearchInlinePages(t *testing.T) {
+	p := fakePagedProvider{newFakeProvider("pages", 35)}
+
+	ids := scrollInline(t, p, "pages")
+
+	if len(ids) != 35 || ids[0] != "pages0" || ids[34] != "pages34" {
+		t.Errorf("got %d results from %v to %v; want all 35 in order", len(ids), ids[0], ids[len(ids)-1])
+	}
+
+	checkUnique(t, ids)
+
+	// Each page is a single search of one page.
+	if len(p.calls) != 4 {
+		t.Errorf("searched %d times; want 4", len(p.calls))
+	}
+
+	for _, limit := range p.calls {
+		if limit != inlinePageSize {
+			t.Errorf("searched %d results; want %d", limit, inlinePageSize)
+		}
+	}
+}
+
+func TestSearchInlineMaxResults(t *testing.T) {
+	p := fakePagedProvider{newFakeProvider("max", 80)}
+
+	if ids := scrollInline(t, p, "max"); len(ids) != maxInlineResults {
+		t.Errorf("got %d results; want %d", len(ids), maxInlineResults)
+	}
+}
+
+func TestSearchInlineFiltered(t *testing.T) {
+	p := fakePagedProvider{newFakeProvider("filtered", 40)}
+
+	var want []string
+
+	for _, item := range p.items {
+		if item.Year == 2001 {
+			want = append(want, item.ID)
+		}
+	}
+
+	// Pages are read until one is filled so nothing that matches is skipped.
+	ids := scrollInline(t, p, "filtered y:2001")
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("results = %v; want %v", ids, want)
+	}
+
+	if len(p.calls) != 4 {
+		t.Errorf("searched %d times; want each of the 4 pages once", len(p.calls))
+	}
+}
+
+func TestSearchInlineUnpagedProvider(t *testing.T) {
+	p := newFakeProvider("unpaged", 25)
+
+	ids := scrollInline(t, p, "unpaged")
+	if len(ids) != 25 || ids[24] != "unpaged24" {
+		t.Errorf("got %d results; want all 25 in order", len(ids))
+	}
+
+	checkUnique(t, ids)
+}
+
+func TestFederatedSearchPage(t *testing.T) {
+	imdb := fakePagedProvider{newFakeProvider("allimdb", 30)}
+	jw := newFakeProvider("alljw", 30)
+
+	// Half of the justwatch titles are also on imdb.
+	for i := range jw.items {
+		if i%2 == 0 {
+			jw.items[i].Title = imdb.items[i].Title
+			jw.items[i].Year = imdb.items[i].Year
+		}
+	}
+
+	defer func(sources []Provider) { allSources = sources }(allSources)
+
+	allSources = []Provider{imdb, jw}
+
+	p := &federatedProvider{info: ProviderInfo{Name: "allfake"}}
+	ctx := context.Background()
+
+	merged, err := p.Search(ctx, "stable", maxInlineResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, next, err := p.SearchPage(ctx, "stable", "", inlinePageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The sources change while the user scrolls.
+	for i, j := 0, len(imdb.items)-1; i < j; i, j = i+1, j-1 {
+		imdb.items[i], imdb.items[j] = imdb.items[j], imdb.items[i]
+	}
+
+	jw.items = jw.items[:5]
+
+	ids := make([]string, 0, len(merged))
+	for _, item := range first {
+		ids = append(ids, item.ID)
+	}
+
+	for next != "" {
+		var page []UniversalSearchResult
+
+		if page, next, err = p.SearchPage(ctx, "stable", next, inlinePageSize); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, item := range page {
+			ids = append(ids, item.ID)
+		}
+	}
+
+	checkUnique(t, ids)
+
+	if len(ids) != len(merged) {
+		t.Fatalf("got %d results; want %d", len(ids), len(merged))
+	}
+
+	for i, item := range merged {
+		if ids[i] != item.ID {
+			t.Errorf("result %d = %s; want %s", i, ids[i], item.ID)
+		}
+	}
+
+	// A new search merges the sources again.
+	fresh, err := p.Search(ctx, "stable", maxInlineResults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, _, err := p.SearchPage(ctx, "stable", "", inlinePageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, item := range fresh[:len(page)] {
+		if page[i].ID != item.ID {
+			t.Errorf("new search result %d = %s; want %s", i, page[i].ID, item.ID)
+		}
+	}
+}
+
+func TestJWSearchPage(t *testing.T) {
+	var res jWSearchPageRes
+
+	loadFixture(t, "jw_search_page.json", &res)
+
+	page := res.Page()
+
+	// The title without content is skipped.
+	if len(page.Items) != 2 || page.Items[0].ID != "tm1139441" || page.Items[1].Type != "TV Series" {
+		t.Errorf("items = %+v; want dune and dune prophecy", page.Items)
+	}
+
+	if page.Items[0].URL != jWHomepage+"/us/movie/dune-2021" || page.Items[0].Year != 2021 || page.Items[0].Language != "en" {
+		t.Errorf("fields weren't decoded: %+v", page.Items[0])
+	}
+
+	if page.Next != "YXJyYXljb25uZWN0aW9uOjI=" {
+		t.Errorf("next = %q; want the end cursor", page.Next)
+	}
+
+	res.PopularTitles.PageInfo.HasNextPage = false
+
+	if next := res.Page().Next; next != "" {
+		t.Errorf("next of the last page = %q; want none", next)
+	}
+}
+
+func TestIMDbAPISearchPage(t *testing.T) {
+	var res fallbackSearchRes
+
+	loadFixture(t, "imdbapi_search.json", &res)
+
+	items := res.Items()
+	if len(items) != 2 || items[0].ID != "tt1160419" || items[0].Rating != 8 || items[0].Type != "Movie" || items[1].Poster != "" {
+		t.Errorf("items = %+v", items)
+	}
+
+	if res.NextPageToken != "eyJvZmZzZXQiOjJ9" {
+		t.Errorf("next page token = %q", res.NextPageToken)
+	}
+}
@@ -0,0 +1,92 @@
+// (c) Jisin0
+// Periodic background jobs run by the polling bot or a cron endpoint.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const (
+	// Time between checks for due jobs in polling mode.
+	jobTickInterval = time.Minute
+	// Maximum time all due jobs can run for in polling mode.
+	jobRunTimeout = 30 * time.Minute
+)
+
+// backgroundJob is a task that runs once every interval.
+type backgroundJob struct {
+	Name     string
+	Interval time.Duration
+	// Run does the work of the job, it should save it's progress and return ctx.Err() if it's stopped early.
+	Run func(ctx context.Context, bot *gotgbot.Bot) error
+}
+
+var (
+	backgroundJobs []*backgroundJob
+	// jobsMu stops due jobs from running again before the previous run has finished.
+	jobsMu sync.Mutex
+)
+
+// registerJob adds a job to the list of background jobs.
+func registerJob(job *backgroundJob) *backgroundJob {
+	backgroundJobs = append(backgroundJobs, job)
+	return job
+}
+
+func jobKey(name string) string {
+	return "job:" + name
+}
+
+// RunDueJobs runs every job whose last successful run is older than it's interval.
+// The time of each run is saved in the store so runs missed while the bot was offline are caught up on the next call.
+func RunDueJobs(ctx context.Context, bot *gotgbot.Bot) {
+	if !jobsMu.TryLock() {
+		return
+	}
+	defer jobsMu.Unlock()
+
+	for _, job := range backgroundJobs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		var lastRun time.Time
+
+		loadRecord(jobKey(job.Name), &lastRun)
+
+		if time.Since(lastRun) < job.Interval {
+			continue
+		}
+
+		if err := job.Run(ctx, bot); err != nil {
+			fmt.Printf("job %s: %v\n", job.Name, err)
+			continue
+		}
+
+		if err := saveRecord(jobKey(job.Name), time.Now()); err != nil {
+			fmt.Printf("job %s: %v\n", job.Name, err)
+		}
+	}
+}
+
+// StartJobs runs due jobs in the background every minute.
+func StartJobs(bot *gotgbot.Bot) {
+	go func() {
+		ticker := time.NewTicker(jobTickInterval)
+		defer ticker.Stop()
+
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), jobRunTimeout)
+			RunDueJobs(ctx, bot)
+			cancel()
+
+			<-ticker.C
+		}
+	}()
+}
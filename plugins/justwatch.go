@@ -4,11 +4,16 @@
 package plugins
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"net/url"
+	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Jisin0/filmigo/justwatch"
 	"github.com/PaulSonOfLars/gotgbot/v2"
@@ -19,176 +24,491 @@ const (
 	jWBanner   = "https://telegra.ph/file/23a8bea137de034392f29.jpg"
 	jWLogo     = "https://upload.wikimedia.org/wikipedia/commons/e/e1/JustWatch.png"
 	jWHomepage = "https://justwatch.com"
+	// Host of the justwatch api used to rate limit requests.
+	jWHost       = "apis.justwatch.com"
+	jWGraphqlURL = "https://apis.justwatch.com/graphql"
 
 	decriptionMaxLength = 200
 	jWCountryCode       = "US"
 )
 
+var searchMethodJW = "jw"
+
+// Justwatch clients mapped by country code, built when a country is first used.
 var (
-	searchMethodJW = "jw"
-	// map to cache tgraph url of images.
-	jWPosterCache = make(map[string]string)
+	jWClients   = make(map[string]*justwatch.JustwatchClient)
+	jWClientsMu sync.Mutex
 )
 
-var jWClient = justwatch.NewClient(&justwatch.JustwatchClientOpts{Country: jWCountryCode})
+// jWClientFor returns the justwatch client of a country.
+func jWClientFor(country string) *justwatch.JustwatchClient {
+	country = strings.ToUpper(country)
+	if country == "" {
+		country = jWCountryCode
+	}
+
+	jWClientsMu.Lock()
+	defer jWClientsMu.Unlock()
+
+	c, ok := jWClients[country]
+	if !ok {
+		c = justwatch.NewClient(&justwatch.JustwatchClientOpts{Country: country})
+		jWClients[country] = c
+	}
+
+	return c
+}
 
-// JWInlineSearch searches for query on justwatch and returns results to be used in inline queries.
-func JWInlineSearch(query string) []gotgbot.InlineQueryResult {
-	rawResults, err := jWClient.SearchTitle(query)
+// jWIDPattern matches a justwatch title id.
+var jWIDPattern = regexp.MustCompile(`tm\d+|ts\d+`)
+
+var jWProvider = registerProvider(&justwatchProvider{info: ProviderInfo{
+	Name:         searchMethodJW,
+	Label:        "JustWatch",
+	Banner:       jWBanner,
+	SearchButton: "💻 Search OTT",
+	Commands:     []string{"justwatch", "jw"},
+	ExampleID:    "tm92641",
+	IDPattern:    jWIDPattern,
+	PhotoCards:   true,
+	Regional:     true,
+}})
+
+// justwatchProvider searches and gets titles from justwatch.
+type justwatchProvider struct {
+	info ProviderInfo
+}
+
+func (p *justwatchProvider) Info() *ProviderInfo {
+	return &p.info
+}
+
+func (p *justwatchProvider) Search(ctx context.Context, query string, limit int) ([]UniversalSearchResult, error) {
+	prefs := prefsFrom(ctx)
+	language := jWSearchLanguage(ctx)
+
+	return cached(cacheKey("search", searchMethodJW, prefs.Country, language, strconv.Itoa(limit), query), searchCacheTTL, func() ([]UniversalSearchResult, error) {
+		return jWSearch(ctx, query, limit, prefs.Country, language)
+	})
+}
+
+func (p *justwatchProvider) SearchPage(ctx context.Context, query, cursor string, limit int) ([]UniversalSearchResult, string, error) {
+	var (
+		prefs    = prefsFrom(ctx)
+		language = jWSearchLanguage(ctx)
+	)
+
+	page, err := cached(cacheKey("searchpage", searchMethodJW, prefs.Country, language, strconv.Itoa(limit), cursor, query), searchCacheTTL, func() (searchPage, error) {
+		return jWSearchPage(ctx, query, cursor, limit, prefs.Country, language)
+	})
+
+	return page.Items, page.Next, err
+}
+
+// jWSearchLanguage returns the language search results are localized in.
+func jWSearchLanguage(ctx context.Context) string {
+	return prefsFrom(ctx).Language
+}
+
+// jWSearch searches justwatch in a country for up to limit titles matching the query, results are localized in language if it's set.
+func jWSearch(ctx context.Context, query string, limit int, country, language string) ([]UniversalSearchResult, error) {
+	rawResults, err := withLimits(ctx, jWHost, func() (*justwatch.SearchResults, error) {
+		return jWClientFor(country).SearchTitle(query, &justwatch.SearchOptions{Limit: limit, Language: language})
+	})
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	results := make([]gotgbot.InlineQueryResult, 0, len(rawResults.Results))
+	results := make([]UniversalSearchResult, 0, len(rawResults.Results))
 
 	for _, item := range rawResults.Results {
-		posterURL := item.Poster.FullURL()
-		if posterURL == "" {
-			posterURL = jWBanner
+		if item.TitlePreview == nil || item.TitlePreviewContent == nil {
+			continue
 		}
 
-		results = append(results, gotgbot.InlineQueryResultPhoto{
-			Id:           searchMethodJW + "_" + item.ID,
-			PhotoUrl:     posterURL,
-			ThumbnailUrl: posterURL,
-			Title:        fmt.Sprintf("%s (%v)", item.Title, item.OriginalReleaseYear),
-			Description:  item.ShortDescription,
-			Caption:      buildSearchCaption(item.TitlePreview),
-			ParseMode:    gotgbot.ParseModeHTML,
-			ReplyMarkup: &gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
-				{{Text: "Open JustWatch", CallbackData: fmt.Sprintf("open_%s_%s", searchMethodJW, item.ID)}},
-			}},
-		})
+		results = append(results, jWSearchResult(item.TitlePreview))
 	}
 
-	return results
+	return results, nil
+}
+
+// jWSearchPageQuery searches justwatch for a page of titles after a cursor.
+const jWSearchPageQuery = `query SearchTitles($country: Country!, $language: Language!, $first: Int!, $after: String, $filter: TitleFilter) {
+  popularTitles(country: $country, first: $first, after: $after, filter: $filter) {
+    edges {
+      node {
+        id
+        objectType
+        objectId
+        content(country: $country, language: $language) {
+          fullPath
+          title
+          originalReleaseYear
+          posterUrl
+          shortDescription
+          originalLanguage
+          genres {
+            shortName
+          }
+        }
+      }
+    }
+    pageInfo {
+      endCursor
+      hasNextPage
+    }
+  }
+}`
+
+// jWSearchPageRes is the response of jWSearchPageQuery.
+type jWSearchPageRes struct {
+	PopularTitles struct {
+		justwatch.SearchResults
+		PageInfo struct {
+			EndCursor   string `json:"endCursor"`
+			HasNextPage bool   `json:"hasNextPage"`
+		} `json:"pageInfo"`
+	} `json:"popularTitles"`
+
+	// Original language of each title by it's id, filmigo doesn't decode it.
+	languages map[string]string
+}
+
+func (r *jWSearchPageRes) UnmarshalJSON(data []byte) error {
+	type plain jWSearchPageRes
+
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+
+	var res struct {
+		PopularTitles struct {
+			Edges []struct {
+				Node struct {
+					ID      string `json:"id"`
+					Content *struct {
+						OriginalLanguage string `json:"originalLanguage"`
+					} `json:"content"`
+				} `json:"node"`
+			} `json:"edges"`
+		} `json:"popularTitles"`
+	}
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+
+	r.languages = make(map[string]string, len(res.PopularTitles.Edges))
+
+	for _, e := range res.PopularTitles.Edges {
+		if e.Node.Content != nil {
+			r.languages[e.Node.ID] = strings.ToLower(e.Node.Content.OriginalLanguage)
+		}
+	}
+
+	return nil
+}
+
+// Page returns the results of a search and the cursor of the next page.
+func (r *jWSearchPageRes) Page() searchPage {
+	var page searchPage
+
+	for _, item := range r.PopularTitles.Results {
+		if item.TitlePreview == nil || item.TitlePreviewContent == nil {
+			continue
+		}
+
+		result := jWSearchResult(item.TitlePreview)
+		result.Language = r.languages[result.ID]
+
+		page.Items = append(page.Items, result)
+	}
+
+	if r.PopularTitles.PageInfo.HasNextPage {
+		page.Next = r.PopularTitles.PageInfo.EndCursor
+	}
+
+	return page
+}
+
+// jWSearchPage searches justwatch for a page of up to limit titles after cursor.
+func jWSearchPage(ctx context.Context, query, cursor string, limit int, country, language string) (searchPage, error) {
+	country = strings.ToUpper(country)
+	if country == "" {
+		country = jWCountryCode
+	}
+
+	if language == "" {
+		language = defaultLanguage
+	}
+
+	vars := map[string]any{
+		"country":  country,
+		"language": language,
+		"first":    limit,
+		"filter":   map[string]any{"searchQuery": query, "includeTitlesWithoutUrl": true},
+	}
+
+	if cursor != "" {
+		vars["after"] = cursor
+	}
+
+	var res jWSearchPageRes
+
+	if err := jWGraphQL(ctx, jWSearchPageQuery, vars, &res); err != nil {
+		return searchPage{}, err
+	}
+
+	page := res.Page()
+	if len(page.Items) < 1 && cursor == "" {
+		return page, fmt.Errorf("no results found for %s", query)
+	}
+
+	return page, nil
+}
+
+// jWGraphQL runs a query on the justwatch graphql api and decodes it's data into v.
+func jWGraphQL(ctx context.Context, query string, vars map[string]any, v any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, jWGraphqlURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	r, err := upstream.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	var res struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		return err
+	}
+
+	if len(res.Errors) > 0 {
+		return errors.New("justwatch: " + res.Errors[0].Message)
+	}
+
+	return json.Unmarshal(res.Data, v)
+}
+
+// jWSearchResult converts a justwatch search result into a UniversalSearchResult.
+func jWSearchResult(item *justwatch.TitlePreview) UniversalSearchResult {
+	var genres []string
+	if item.Genres != nil {
+		genres = item.Genres.ToList()
+	}
+
+	typeTag := "Movie"
+	if item.Type == "SHOW" {
+		typeTag = "TV Series"
+	}
+
+	return UniversalSearchResult{
+		ID:          item.ID,
+		Title:       item.Title,
+		Year:        item.OriginalReleaseYear,
+		Poster:      item.Poster.FullURL(),
+		Type:        typeTag,
+		Description: item.ShortDescription,
+		Genres:      genres,
+		URL:         jWHomepage + item.Path,
+	}
+}
+
+func (p *justwatchProvider) InlineResult(item UniversalSearchResult) gotgbot.InlineQueryResult {
+	posterURL := item.Poster
+	if posterURL == "" {
+		posterURL = jWBanner
+	}
+
+	return gotgbot.InlineQueryResultPhoto{
+		Id:           searchMethodJW + "_" + item.ID,
+		PhotoUrl:     posterURL,
+		ThumbnailUrl: posterURL,
+		Title:        fmt.Sprintf("%s (%v)", item.Title, item.Year),
+		Description:  item.Description,
+		Caption:      buildSearchCaption(item),
+		ParseMode:    gotgbot.ParseModeHTML,
+		ReplyMarkup: &gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: "Open JustWatch", CallbackData: fmt.Sprintf("open_%s_%s", searchMethodJW, item.ID)}},
+		}},
+	}
+}
+
+func (p *justwatchProvider) GetTitle(ctx context.Context, id string, _ func(string)) (*TitleCard, error) {
+	return GetJWTitle(ctx, id)
 }
 
 // buildSearchCaption creates the caption for a search item.
-func buildSearchCaption(item *justwatch.TitlePreview) string {
+func buildSearchCaption(item UniversalSearchResult) string {
 	var (
 		builder     strings.Builder
-		description = item.ShortDescription
+		description = item.Description
 	)
 
 	if len(description) > decriptionMaxLength {
 		description = description[0:decriptionMaxLength]
 	}
 
-	builder.WriteString(fmt.Sprintf("🎯 <b><a href='%s'>%s (%v)</a></b>\n", jWHomepage+item.Path, item.OriginalTitle, item.OriginalReleaseYear))
-	builder.WriteString(fmt.Sprintf("<i>%s</i>\n\n", item.Genres.ToString(" | ")))
-	builder.WriteString(fmt.Sprintf("<tg-spoiler><i>%s</i></tg-spoiler>", description))
+	builder.WriteString(fmt.Sprintf("🎯 <b><a href='%s'>%s (%v)</a></b>\n", escapeHTML(item.URL), escapeHTML(item.Title), item.Year))
+	builder.WriteString(fmt.Sprintf("<i>%s</i>\n\n", escapeHTML(strings.Join(item.Genres, " | "))))
+	builder.WriteString(fmt.Sprintf("<tg-spoiler><i>%s</i></tg-spoiler>", escapeHTML(description)))
 
 	return builder.String()
 }
 
+// getJWTitleData gets the raw data of a justwatch title by id in the country and language of the user's preferences.
+func getJWTitleData(ctx context.Context, id string) (*justwatch.Title, error) {
+	prefs := prefsFrom(ctx)
+
+	return cached(cacheKey("jwtitle", prefs.Country, prefs.Language, id), titleCacheTTL, func() (*justwatch.Title, error) {
+		return withLimits(ctx, jWHost, func() (*justwatch.Title, error) {
+			return jWClientFor(prefs.Country).GetTitle(id, &justwatch.GetTitleOptions{Language: prefs.Language})
+		})
+	})
+}
+
+// jWIMDbID returns the imdb id of a justwatch title or an empty string if it has none.
+func jWIMDbID(ctx context.Context, id string) (string, error) {
+	title, err := getJWTitleData(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	if title.Content == nil || title.Content.ExteranlIDs == nil {
+		return "", nil
+	}
+
+	return title.Content.ExteranlIDs.ImdbID, nil
+}
+
 // Gets a justwatch title by id and build the message that should be sent or edited.
-func GetJWTitle(id string) (gotgbot.InputMediaPhoto, [][]gotgbot.InlineKeyboardButton, error) {
-	var (
-		photo   gotgbot.InputMediaPhoto
-		buttons [][]gotgbot.InlineKeyboardButton
-	)
+func GetJWTitle(ctx context.Context, id string) (*TitleCard, error) {
+	var buttons [][]gotgbot.InlineKeyboardButton
 
-	title, err := jWClient.GetTitle(id)
+	title, err := getJWTitleData(ctx, id)
 	if err != nil {
-		return photo, buttons, err
+		return nil, err
 	}
 
-	var captionBuilder strings.Builder
+	var (
+		cb      captionBuilder
+		header  strings.Builder
+		prefs   = prefsFrom(ctx)
+		compact = prefs.Verbosity == verbosityCompact
+	)
 
 	content := title.Content
 
 	if content == nil {
 		fmt.Println("no content found !")
-		return photo, buttons, errors.New("title content not found : " + id)
+		return nil, errors.New("title content not found : " + id)
 	}
 
-	captionBuilder.WriteString(fmt.Sprintf("<a href='%s'><b>%s</b>", jWHomepage+content.URLPath, content.Title))
+	header.WriteString(fmt.Sprintf("<a href='%s'><b>%s</b>", escapeHTML(jWHomepage+content.URLPath), escapeHTML(content.Title)))
 
 	if content.ReleaseYear != 0 {
-		captionBuilder.WriteString(fmt.Sprintf("<b> (%v)</b>", content.ReleaseYear))
+		header.WriteString(fmt.Sprintf("<b> (%v)</b>", content.ReleaseYear))
 	}
 
-	captionBuilder.WriteString("</a>")
+	header.WriteString("</a>")
 
 	if content.AgeCertification != "" {
-		captionBuilder.WriteString(fmt.Sprintf(" [<tg-spoiler>%s Rated</tg-spoiler>]", content.AgeCertification))
+		header.WriteString(fmt.Sprintf(" [<tg-spoiler>%s Rated</tg-spoiler>]", escapeHTML(content.AgeCertification)))
 	}
 
-	captionBuilder.WriteRune('\n')
+	header.WriteRune('\n')
+	cb.Add(0, header.String())
 
-	if content.OriginalTitle != content.Title {
-		captionBuilder.WriteString(fmt.Sprintf("<i>  aka : %s\n</i>", content.OriginalTitle))
+	if !compact && content.OriginalTitle != content.Title {
+		cb.Add(3, fmt.Sprintf("<i>  aka : %s\n</i>", escapeHTML(content.OriginalTitle)))
 	}
 
-	if content.Interactions != nil {
-		captionBuilder.WriteString(fmt.Sprintf("<i>👍 %v | %v 👎</i>", content.Interactions.Likes, content.Interactions.Dislikes))
+	if !compact && content.Interactions != nil {
+		cb.Add(4, fmt.Sprintf("<i>👍 %v | %v 👎</i>", content.Interactions.Likes, content.Interactions.Dislikes))
 	}
 
 	if content.Scores != nil {
-		captionBuilder.WriteString(fmt.Sprintf("  (<i>%.1f%% ❤️</i>)", content.Scores.JustwatchRating*100))
+		cb.Add(2, fmt.Sprintf("  (<i>%.1f%% ❤️</i>)", content.Scores.JustwatchRating*100))
 	}
 
-	captionBuilder.WriteRune('\n')
+	cb.Add(0, "\n")
 
 	if content.ExteranlIDs != nil && content.ExteranlIDs.ImdbID != "" {
-		captionBuilder.WriteString(fmt.Sprintf("<b>🚦𝙸ᴍᴅʙ:</b> <i><a href='imdb.com/title/%s'>%s", content.ExteranlIDs.ImdbID, content.ExteranlIDs.ImdbID))
+		imdb := fmt.Sprintf("<b>🚦𝙸ᴍᴅʙ:</b> <i><a href='imdb.com/title/%s'>%s", escapeHTML(content.ExteranlIDs.ImdbID), escapeHTML(content.ExteranlIDs.ImdbID))
 
 		if content.Scores != nil && content.Scores.ImdbRating > 0 {
-			captionBuilder.WriteString(fmt.Sprintf(" | %v/10 ⭐", content.Scores.ImdbRating))
+			imdb += fmt.Sprintf(" | %v/10 ⭐", content.Scores.ImdbRating)
 		}
 
-		captionBuilder.WriteString("</a></i>\n")
+		cb.Add(1, imdb+"</a></i>\n")
 	}
 
 	if content.ReleaseDate != "" {
-		captionBuilder.WriteString(fmt.Sprintf("<b>🗓️ Rᴇʟᴇᴀsᴇᴅ:</b> %s\n", content.ReleaseDate))
+		cb.Add(1, fmt.Sprintf("<b>🗓️ Rᴇʟᴇᴀsᴇᴅ:</b> %s\n", escapeHTML(content.ReleaseDate)))
 	}
 
 	if content.Runtime != 0 {
-		captionBuilder.WriteString(fmt.Sprintf("<b>📟 Rᴜɴᴛɪᴍᴇ:</b> %vmins\n", content.Runtime))
+		cb.Add(1, fmt.Sprintf("<b>📟 Rᴜɴᴛɪᴍᴇ:</b> %vmins\n", content.Runtime))
 	}
 
-	if len(*content.Genres) > 0 {
-		captionBuilder.WriteString(fmt.Sprintf("<b>🎭 Gᴇɴʀᴇs:</b> <i>%s</i>\n", content.Genres.ToString(", ")))
+	if content.Genres != nil && len(*content.Genres) > 0 {
+		cb.Add(2, fmt.Sprintf("<b>🎭 Gᴇɴʀᴇs:</b> <i>%s</i>\n", escapeHTML(content.Genres.ToString(", "))))
 	}
 
-	if len(title.Offers) > 0 {
-		captionBuilder.WriteString("\n<blockquote expandable>")
+	if groups := groupOffers(title.Offers); len(groups) > 0 {
+		cb.Add(0, fmt.Sprintf("\n<b>Offers in %s:</b>\n", countryLabel(prefs.Country)))
 
-		var savedOffers []string
-
-		for _, offer := range title.Offers {
-			if Contains(savedOffers, offer.URL) {
-				continue
-			}
+		offers := renderOffers(groups, compact)
 
-			captionBuilder.WriteString(fmt.Sprintf("[<b><a href='%s'>%s</a></b>] ", offer.URL, offer.Package.ClearName))
+		// Long offer lists are shortened and the full table is moved to telegraph.
+		if cb.Length()+visibleLength(offers) > captionLimit {
+			cb.Add(0, "<blockquote expandable>"+renderOffers(groups, true)+"</blockquote>")
 
-			savedOffers = append(savedOffers, offer.URL)
+			if page := jWOffersPage(ctx, id, content.Title, prefs.Country, groups); page != "" {
+				cb.Add(0, fmt.Sprintf("\n<a href='%s'>All Offers &amp; Prices</a>", escapeHTML(page)))
+			}
+		} else {
+			cb.Add(0, "<blockquote expandable>"+offers+"</blockquote>")
 		}
-
-		captionBuilder.WriteString("</blockquote>")
 	} else {
-		captionBuilder.WriteString("<b>No Offers Available</b>")
+		cb.Add(0, fmt.Sprintf("\n<b>No Offers Available in %s</b>", countryLabel(prefs.Country)))
+	}
+
+	var posterURL string
+	if content.Poster != nil {
+		posterURL = content.Poster.FullURL()
 	}
 
-	posterURL := content.Poster.FullURL()
+	if len(content.FullBackdrops) > 0 && posterURL != "" {
+		backdropURL := content.FullBackdrops[0].FullURL()
 
-	if len(content.Backdrops) > 0 {
-		if s, ok := jWPosterCache[id]; ok {
-			posterURL = s
-		} else {
-			file := CreateJWPoster(content.FullBackdrops[0].FullURL(), posterURL, id)
-			if file != nil {
-				imageURL, err := UploadEnvssh(file, id)
-				if err == nil {
-					posterURL = imageURL
-					jWPosterCache[id] = imageURL
-				} else {
-					fmt.Println("failed to upload to telegraph " + err.Error())
-				}
+		imageURL, err := jWPosterCache.GetOrCreate(id, func() (string, error) {
+			file := CreateJWPoster(ctx, backdropURL, posterURL, id)
+			if file == nil {
+				return "", fmt.Errorf("failed to create poster for %s", id)
 			}
+
+			return UploadEnvssh(ctx, file, id)
+		})
+		if err == nil {
+			posterURL = imageURL
+		} else {
+			fmt.Println("failed to upload poster " + err.Error())
 		}
 	}
 
@@ -196,99 +516,89 @@ func GetJWTitle(id string) (gotgbot.InputMediaPhoto, [][]gotgbot.InlineKeyboardB
 		posterURL = jWBanner
 	}
 
-	if len(content.Clips) > 0 {
-		var row []gotgbot.InlineKeyboardButton
-
-		switch len(content.Clips) {
-		case 1:
-			row = append(row, gotgbot.InlineKeyboardButton{Text: content.Clips[0].Name, Url: content.Clips[0].URL})
-		default:
-			for n, clip := range content.Clips {
-				if clip == nil {
-					continue
-				}
+	buttons = append(buttons,
+		[]gotgbot.InlineKeyboardButton{trailerButton(searchMethodJW, id, prefs.Country), similarButton(searchMethodJW, id, prefs.Country), watchlistButton(searchMethodJW, id, inWatchlist(viewerFrom(ctx), searchMethodJW, id))},
+		[]gotgbot.InlineKeyboardButton{{Text: "🌍 Change Country", CallbackData: fmt.Sprintf("jwc_list_%s_%s", id, prefs.Country)}},
+	)
 
-				row = append(row, gotgbot.InlineKeyboardButton{Text: fmt.Sprintf("Clip %v", n+1), Url: clip.URL})
+	return &TitleCard{
+		Poster:  posterURL,
+		Caption: cb.Fit(captionLimit),
+		Buttons: buttons,
+		Photo:   true,
+		Spoiler: !prefs.NoSpoilers,
+	}, nil
+}
 
-				if n >= 2 {
-					break
-				}
-			}
+// jWOffersPage creates a telegraph page with every offer of a title in a country and returns it's url or an empty string.
+func jWOffersPage(ctx context.Context, id, name, country string, groups []offerGroup) string {
+	page, err := cached(cacheKey("jwoffers", country, id), titleCacheTTL, func() (string, error) {
+		if u := createTelegraphPage(ctx, fmt.Sprintf("%s Offers (%s)", name, country), renderOffersTelegraph(groups)); u != "" {
+			return u, nil
 		}
 
-		buttons = append(buttons, row)
-	}
-
-	photo = gotgbot.InputMediaPhoto{
-		Media:      gotgbot.InputFileByURL(posterURL),
-		Caption:    captionBuilder.String(),
-		ParseMode:  gotgbot.ParseModeHTML,
-		HasSpoiler: true,
+		return "", errors.New("failed to create telegraph page")
+	})
+	if err != nil {
+		return ""
 	}
 
-	return photo, buttons, nil
+	return page
 }
 
-// JWCommand handles the /justwatch or /jw command.
-func JWCommand(bot *gotgbot.Bot, ctx *ext.Context) error {
-	update := ctx.EffectiveMessage
+// CbJWCountry handles the country switcher of justwatch cards.
+// Data like jwc_list_<id>_<country> lists countries to pick from and jwc_set_<id>_<country> re-renders the card in that country.
+func CbJWCountry(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.Split(update.Data, "_")
+	)
 
-	split := strings.SplitN(update.GetText(), " ", 2)
-	if len(split) < 2 {
-		text := "<i>Please provide a search query or movie id along with this command !\nFor Example:</i>\n  <code>/justwatch Inception</code>\n  <code>/justwatch tm92641</code>"
-		update.Reply(bot, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML})
-		return nil
+	if len(split) < 4 || !jWIDPattern.MatchString(split[2]) || !isCountryCode(split[3]) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
 	}
 
-	input := split[1]
-
 	var (
-		photo   gotgbot.InputMediaPhoto
-		buttons [][]gotgbot.InlineKeyboardButton
-		err     error
+		action  = split[1]
+		id      = split[2]
+		country = split[3]
+		target  = callbackTarget(update)
 	)
 
-	if id := regexp.MustCompile(`tm\d+`).FindString(input); id != "" {
-		photo, buttons, err = GetJWTitle(id)
-	} else {
-		results, e := jWClient.SearchTitle(input)
-		if e != nil {
-			err = e
-		} else {
-			if len(results.Results) < 1 {
-				err = errors.New("No results found")
-			} else {
-				for _, r := range results.Results {
-					buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: fmt.Sprintf("%s (%d)", r.Title, r.OriginalReleaseYear), CallbackData: fmt.Sprintf("open_%s_%s", searchMethodJW, r.ID)}})
-				}
-
-				photo = gotgbot.InputMediaPhoto{
-					Media:     gotgbot.InputFileByURL(jWBanner),
-					Caption:   fmt.Sprintf("<i>👋 Hey <tg-spoiler>%s</tg-spoiler> I've got %d Results for you 👇</i>", mention(ctx.EffectiveUser), len(results.Results)),
-					ParseMode: gotgbot.ParseModeHTML,
-				}
-			}
+	if action == "list" {
+		labels := make([]string, 0, len(settingsCountries))
+		for _, c := range settingsCountries {
+			labels = append(labels, countryLabel(c))
 		}
-	}
 
-	if err != nil {
-		photo = gotgbot.InputMediaPhoto{
-			Caption:   fmt.Sprintf("<i>I'm Sorry %s I Couldn't find Anything for <code>%s</code> 🤧</i>", mention(ctx.EffectiveUser), input),
-			Media:     gotgbot.InputFileByURL(jWBanner),
-			ParseMode: gotgbot.ParseModeHTML,
+		back := gotgbot.InlineKeyboardButton{Text: "⬅️ Back", CallbackData: fmt.Sprintf("jwc_set_%s_%s", id, country)}
+
+		if err := editMarkup(bot, target, choiceButtons(fmt.Sprintf("jwc_set_%s_", id), settingsCountries, labels, country, back)); err != nil {
+			fmt.Printf("cbjwcountry: %v\n", err)
 		}
 
-		buttons = [][]gotgbot.InlineKeyboardButton{{{Text: "Search On Google 🔎", Url: fmt.Sprintf("https://google.com/search?q=%s", url.QueryEscape(input))}}}
+		update.Answer(bot, nil)
+
+		return ext.EndGroups
 	}
 
-	_, err = bot.SendPhoto(ctx.EffectiveChat.Id, photo.Media, &gotgbot.SendPhotoOpts{
-		Caption:     photo.Caption,
-		ParseMode:   photo.ParseMode,
-		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons},
-		HasSpoiler:  photo.HasSpoiler})
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	card, err := GetJWTitle(withCountry(reqCtx, country), id)
 	if err != nil {
-		fmt.Printf("jwcommand: %v", err)
+		fmt.Printf("cbjwcountry: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Fetch Offers for That Country 🤧\nPlease Try Again Later !", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	if err := editCard(bot, target, card); err != nil {
+		fmt.Printf("cbjwcountry: %v\n", err)
 	}
 
+	update.Answer(bot, nil)
+
 	return ext.EndGroups
 }
@@ -0,0 +1,586 @@
+// (c) Jisin0
+// Movie night polls to let a group vote on what to watch.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	// Number of titles a poll can have.
+	minMovieNightOptions = 2
+	maxMovieNightOptions = 10
+	// Maximum length of a poll option.
+	pollOptionLimit = 100
+
+	movieNightQuestion = "🍿 Movie Night ! What should we watch ?"
+
+	// Time after which polls that were never closed and drafts that weren't changed are deleted.
+	movieNightMaxAge = 7 * 24 * time.Hour
+)
+
+// titleIDPattern matches a query that is an imdb or justwatch id.
+var titleIDPattern = regexp.MustCompile(`^(?:tt|tm|ts)\d+$`)
+
+// titleButtonPattern matches the data of buttons on title cards that hold the method and id of the title.
+var titleButtonPattern = regexp.MustCompile(`^(?:wla|trl|sim)_([a-z]+)_(tt\d+|tm\d+|ts\d+)`)
+
+// movieNightOption is a title in a movie night poll.
+type movieNightOption struct {
+	Method string `json:"method"`
+	ID     string `json:"id"`
+	// Text of the option with the year and rating of the title.
+	Label string `json:"label"`
+}
+
+// movieNight is a poll that was sent, it's removed once the poll is closed or it's too old.
+type movieNight struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int64 `json:"message_id"`
+	// User who started the poll, 0 if they asked to be forgotten.
+	CreatorID int64              `json:"creator_id"`
+	Options   []movieNightOption `json:"options"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// movieNightDraft is the list of titles saved for the next poll of a chat.
+type movieNightDraft struct {
+	Options   []movieNightOption `json:"options"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// The job deletes polls and drafts older than movieNightMaxAge, twice a day so daily cron jobs that run a little early don't skip it.
+var movieNightJob = registerJob(&backgroundJob{Name: "movienight", Interval: 12 * time.Hour, Run: cleanMovieNights})
+
+// movieNightMu serializes changes to drafts and polls so a poll's winner is only announced once.
+var movieNightMu sync.Mutex
+
+func movieNightDraftKey(chatID int64) string {
+	return fmt.Sprintf("%s%d", movieNightDraftPrefix, chatID)
+}
+
+func movieNightPollKey(pollID string) string {
+	return movieNightPollPrefix + pollID
+}
+
+const (
+	movieNightDraftPrefix = "movienight:draft:"
+	movieNightPollPrefix  = "movienight:poll:"
+)
+
+// loadMovieNight returns the poll saved with an id, polls older than movieNightMaxAge are treated as closed.
+func loadMovieNight(pollID string) (movieNight, bool) {
+	var night movieNight
+	if !loadRecord(movieNightPollKey(pollID), &night) || time.Since(night.CreatedAt) > movieNightMaxAge {
+		return movieNight{}, false
+	}
+
+	return night, true
+}
+
+// loadMovieNightDraft returns the titles saved for the next poll of a chat, drafts older than movieNightMaxAge are empty.
+func loadMovieNightDraft(chatID int64) []movieNightOption {
+	var draft movieNightDraft
+	if !loadRecord(movieNightDraftKey(chatID), &draft) || time.Since(draft.UpdatedAt) > movieNightMaxAge {
+		return nil
+	}
+
+	return draft.Options
+}
+
+// cleanMovieNights deletes polls and drafts older than movieNightMaxAge.
+func cleanMovieNights(ctx context.Context, _ *gotgbot.Bot) error {
+	movieNightMu.Lock()
+	defer movieNightMu.Unlock()
+
+	for _, key := range userStore.Keys("movienight:") {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var expired bool
+
+		switch {
+		case strings.HasPrefix(key, movieNightPollPrefix):
+			_, found := loadMovieNight(strings.TrimPrefix(key, movieNightPollPrefix))
+			expired = !found
+		case strings.HasPrefix(key, movieNightDraftPrefix):
+			var draft movieNightDraft
+			expired = !loadRecord(key, &draft) || time.Since(draft.UpdatedAt) > movieNightMaxAge
+		}
+
+		if expired {
+			if err := userStore.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// forgetMovieNights removes a user from the polls they started, admins can still close them.
+func forgetMovieNights(userID int64) error {
+	movieNightMu.Lock()
+	defer movieNightMu.Unlock()
+
+	for _, key := range userStore.Keys(movieNightPollPrefix) {
+		var night movieNight
+		if !loadRecord(key, &night) || night.CreatorID != userID {
+			continue
+		}
+
+		night.CreatorID = 0
+
+		if err := saveRecord(key, night); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// movieNightLabel returns the text of a poll option for a title.
+func movieNightLabel(title string, year int, rating float64) string {
+	var suffix string
+
+	if year > 0 {
+		suffix += fmt.Sprintf(" (%d)", year)
+	}
+
+	if rating > 0 {
+		suffix += fmt.Sprintf(" ⭐ %.1f", rating)
+	}
+
+	// The title is shortened so the year and rating always fit.
+	if room := pollOptionLimit - utf8.RuneCountInString(suffix); utf8.RuneCountInString(title) > room {
+		title = string([]rune(title)[:room-1]) + "…"
+	}
+
+	return title + suffix
+}
+
+// resolveMovieNightID gets the name, year and rating of a title by it's id.
+func resolveMovieNightID(ctx context.Context, method, id string) (movieNightOption, error) {
+	if method == searchMethodJW {
+		title, err := getJWTitleData(ctx, id)
+		if err != nil {
+			return movieNightOption{}, err
+		}
+
+		if title.Content == nil {
+			return movieNightOption{}, fmt.Errorf("movienight: no content for %s", id)
+		}
+
+		var rating float64
+		if title.Content.Scores != nil {
+			rating = float64(title.Content.Scores.ImdbRating)
+		}
+
+		return movieNightOption{Method: method, ID: id, Label: movieNightLabel(title.Content.Title, title.Content.ReleaseYear, rating)}, nil
+	}
+
+	t, err := getTitleData(ctx, id, nil)
+	if err != nil {
+		return movieNightOption{}, err
+	}
+
+	return movieNightOption{Method: searchMethodIMDb, ID: id, Label: movieNightLabel(t.Name, t.StartYear, t.Rating)}, nil
+}
+
+// resolveMovieNightQuery finds the title matching a query using the user's default search method, ids are used as they are.
+func resolveMovieNightQuery(ctx context.Context, query string) (movieNightOption, error) {
+	if titleIDPattern.MatchString(query) {
+		method := searchMethodJW
+		if strings.HasPrefix(query, "tt") {
+			method = searchMethodIMDb
+		}
+
+		return resolveMovieNightID(ctx, method, query)
+	}
+
+	p, ok := getProvider(prefsFrom(ctx).Method)
+	if !ok {
+		p = imdbProvider
+	}
+
+	results, err := p.Search(ctx, query, 1)
+	if err != nil {
+		return movieNightOption{}, err
+	}
+
+	if len(results) < 1 {
+		return movieNightOption{}, fmt.Errorf("movienight: no results for %s", query)
+	}
+
+	r := results[0]
+
+	return movieNightOption{Method: p.Info().Name, ID: r.ID, Label: movieNightLabel(r.Title, r.Year, r.Rating)}, nil
+}
+
+// cardTitle returns the method and id of the title on a card sent by the bot.
+func cardTitle(bot *gotgbot.Bot, msg *gotgbot.Message) (method, id string, ok bool) {
+	if msg == nil || msg.ReplyMarkup == nil {
+		return "", "", false
+	}
+
+	if (msg.From == nil || msg.From.Id != bot.Id) && (msg.ViaBot == nil || msg.ViaBot.Id != bot.Id) {
+		return "", "", false
+	}
+
+	for _, row := range msg.ReplyMarkup.InlineKeyboard {
+		for _, b := range row {
+			if m := titleButtonPattern.FindStringSubmatch(b.CallbackData); m != nil {
+				return m[1], m[2], true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+// addMovieNightOptions appends options that aren't in the list yet.
+func addMovieNightOptions(options []movieNightOption, add ...movieNightOption) []movieNightOption {
+	for _, o := range add {
+		dup := false
+
+		for _, e := range options {
+			if e.ID == o.ID {
+				dup = true
+				break
+			}
+		}
+
+		if !dup {
+			options = append(options, o)
+		}
+	}
+
+	return options
+}
+
+// removeMovieNightOptions returns the options that aren't in remove.
+func removeMovieNightOptions(options, remove []movieNightOption) []movieNightOption {
+	var kept []movieNightOption
+
+	for _, o := range options {
+		found := false
+
+		for _, r := range remove {
+			if r.ID == o.ID {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			kept = append(kept, o)
+		}
+	}
+
+	return kept
+}
+
+// saveMovieNightDraft saves the draft of a chat, empty drafts are deleted.
+func saveMovieNightDraft(chatID int64, draft []movieNightOption) error {
+	if len(draft) < 1 {
+		return userStore.Delete(movieNightDraftKey(chatID))
+	}
+
+	return saveRecord(movieNightDraftKey(chatID), movieNightDraft{Options: draft, UpdatedAt: time.Now()})
+}
+
+// MovieNight handles the /movienight command, titles are separated by semicolons like /movienight Dune; Arrival.
+// Replying to a card adds it's title, without any titles it's saved to a draft that's used by the next poll.
+func MovieNight(bot *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+
+	reply := func(text string) {
+		if _, err := msg.Reply(bot, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML}); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	var queries []string
+
+	if split := strings.SplitN(msg.GetText(), " ", 2); len(split) > 1 {
+		for _, q := range strings.Split(split[1], ";") {
+			if q = strings.TrimSpace(q); q != "" {
+				queries = append(queries, q)
+			}
+		}
+	}
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	var replied *movieNightOption
+
+	if method, id, ok := cardTitle(bot, msg.ReplyToMessage); ok {
+		o, err := resolveMovieNightID(reqCtx, method, id)
+		if err != nil {
+			fmt.Printf("movienight: %v\n", err)
+			reply("<i>I Couldn't Get The Title on That Card 🤧</i>")
+
+			return ext.EndGroups
+		}
+
+		replied = &o
+	}
+
+	movieNightMu.Lock()
+
+	draft := loadMovieNightDraft(msg.Chat.Id)
+
+	// A reply without titles only adds the card to the draft.
+	if replied != nil && len(queries) < 1 {
+		draft = addMovieNightOptions(draft, *replied)
+		if len(draft) > maxMovieNightOptions {
+			draft = draft[:maxMovieNightOptions]
+		}
+
+		err := saveMovieNightDraft(msg.Chat.Id, draft)
+
+		movieNightMu.Unlock()
+
+		if err != nil {
+			fmt.Printf("movienight: %v\n", err)
+			reply("<i>I Couldn't Save The Title 🤧\nPlease Try Again Later !</i>")
+
+			return ext.EndGroups
+		}
+
+		reply(fmt.Sprintf("<i>Added <b>%s</b> to movie night 🍿 (%d/%d titles)\nReply to more cards with /movienight or send /movienight to start the poll.</i>", escapeHTML(replied.Label), len(draft), maxMovieNightOptions))
+
+		return ext.EndGroups
+	}
+
+	movieNightMu.Unlock()
+
+	options := draft
+	if replied != nil {
+		options = addMovieNightOptions(options, *replied)
+	}
+
+	// Queries are resolved concurrently and added in the order they were given.
+	var (
+		wg       sync.WaitGroup
+		resolved = make([]movieNightOption, len(queries))
+		failed   = make([]bool, len(queries))
+	)
+
+	for i, q := range queries {
+		wg.Add(1)
+
+		go func(i int, q string) {
+			defer wg.Done()
+
+			o, err := resolveMovieNightQuery(reqCtx, q)
+			if err != nil {
+				fmt.Printf("movienight: %v\n", err)
+				failed[i] = true
+
+				return
+			}
+
+			resolved[i] = o
+		}(i, q)
+	}
+
+	wg.Wait()
+
+	var notFound []string
+
+	for i, o := range resolved {
+		if failed[i] {
+			notFound = append(notFound, "<code>"+escapeHTML(queries[i])+"</code>")
+			continue
+		}
+
+		options = addMovieNightOptions(options, o)
+	}
+
+	if len(options) > maxMovieNightOptions {
+		options = options[:maxMovieNightOptions]
+	}
+
+	var note string
+	if len(notFound) > 0 {
+		note = "\n\n<i>I couldn't find</i> " + strings.Join(notFound, ", ")
+	}
+
+	if len(options) < minMovieNightOptions {
+		reply("<i>A movie night needs at least 2 titles 🍿\nFor Example:</i>\n  <code>/movienight Dune; Arrival; Interstellar</code>\n<i>You can also reply to my cards with /movienight to add them.</i>" + note)
+		return ext.EndGroups
+	}
+
+	pollOptions := make([]gotgbot.InputPollOption, len(options))
+	for i, o := range options {
+		pollOptions[i] = gotgbot.InputPollOption{Text: o.Label}
+	}
+
+	poll, err := bot.SendPoll(msg.Chat.Id, movieNightQuestion, pollOptions, &gotgbot.SendPollOpts{
+		IsAnonymous: false,
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{{{Text: "🏁 Close Poll", CallbackData: "mnc"}}}},
+	})
+	if err != nil {
+		fmt.Printf("movienight: %v\n", err)
+		reply("<i>I Couldn't Start The Poll 🤧\nPlease Try Again Later !</i>")
+
+		return ext.EndGroups
+	}
+
+	movieNightMu.Lock()
+	defer movieNightMu.Unlock()
+
+	night := movieNight{ChatID: msg.Chat.Id, MessageID: poll.MessageId, CreatorID: ctx.EffectiveUser.Id, Options: options, CreatedAt: time.Now()}
+
+	if err := saveRecord(movieNightPollKey(poll.Poll.Id), night); err != nil {
+		fmt.Printf("movienight: %v\n", err)
+	}
+
+	// The draft is loaded again so cards added while the poll was being sent are kept for the next one.
+	latest := loadMovieNightDraft(msg.Chat.Id)
+
+	if err := saveMovieNightDraft(msg.Chat.Id, removeMovieNightOptions(latest, options)); err != nil {
+		fmt.Printf("movienight: %v\n", err)
+	}
+
+	if note != "" {
+		reply(strings.TrimSpace(note))
+	}
+
+	return ext.EndGroups
+}
+
+// finishMovieNight announces the winner of a closed movie night poll and sends it's full card.
+func finishMovieNight(bot *gotgbot.Bot, poll *gotgbot.Poll) {
+	movieNightMu.Lock()
+
+	night, found := loadMovieNight(poll.Id)
+	if found {
+		if err := userStore.Delete(movieNightPollKey(poll.Id)); err != nil {
+			fmt.Printf("movienight: %v\n", err)
+		}
+	}
+
+	movieNightMu.Unlock()
+
+	if !found {
+		return
+	}
+
+	var (
+		winner = -1
+		votes  int64
+		total  int64
+		tie    bool
+	)
+
+	for i, o := range poll.Options {
+		total += o.VoterCount
+
+		switch {
+		case o.VoterCount > votes:
+			winner, votes, tie = i, o.VoterCount, false
+		case o.VoterCount == votes && votes > 0:
+			tie = true
+		}
+	}
+
+	reply := &gotgbot.ReplyParameters{MessageId: night.MessageID, AllowSendingWithoutReply: true}
+
+	if winner < 0 || winner >= len(night.Options) {
+		if _, err := bot.SendMessage(night.ChatID, "<i>Nobody voted, no movie night tonight 😴</i>", &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, ReplyParameters: reply}); err != nil {
+			fmt.Printf("movienight: %v\n", err)
+		}
+
+		return
+	}
+
+	o := night.Options[winner]
+
+	text := fmt.Sprintf("<b>🏆 %s</b> <i>won movie night with %d of %d votes 🍿</i>", escapeHTML(o.Label), votes, total)
+	if tie {
+		text += "\n<i>It was a tie so the first title with the most votes was picked.</i>"
+	}
+
+	if _, err := bot.SendMessage(night.ChatID, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, ReplyParameters: reply}); err != nil {
+		fmt.Printf("movienight: %v\n", err)
+	}
+
+	p, ok := getProvider(o.Method)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(withPrefs(context.Background(), getUserPrefs(night.CreatorID)), updateBudget)
+	defer cancel()
+
+	card, err := p.GetTitle(ctx, o.ID, nil)
+	if err != nil {
+		fmt.Printf("movienight: %v\n", err)
+		return
+	}
+
+	if err := sendCard(bot, night.ChatID, card, reply); err != nil {
+		fmt.Printf("movienight: %v\n", err)
+	}
+}
+
+// MovieNightPoll handles closed polls and announces the winner if it's a movie night poll.
+func MovieNightPoll(bot *gotgbot.Bot, ctx *ext.Context) error {
+	finishMovieNight(bot, ctx.Poll)
+	return ext.EndGroups
+}
+
+// CbMovieNight handles the close button of movie night polls, only the user who started it or an admin can close it.
+func CbMovieNight(bot *gotgbot.Bot, ctx *ext.Context) error {
+	update := ctx.CallbackQuery
+
+	msg, ok := update.Message.(gotgbot.Message)
+	if !ok || msg.Poll == nil {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "This Poll Has Expired 🤧", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	night, found := loadMovieNight(msg.Poll.Id)
+	if !found {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "This Poll is Already Closed 🏁", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	if update.From.Id != night.CreatorID && !isAdmin(bot, msg.Chat.Id, update.From.Id) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Only The User Who Started The Poll or an Admin Can Close it 🙅", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	poll, err := bot.StopPoll(msg.Chat.Id, msg.MessageId, nil)
+	if err != nil {
+		fmt.Printf("cbmovienight: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Close The Poll 🤧\nPlease Try Again Later !", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Poll Closed 🏁"})
+
+	finishMovieNight(bot, poll)
+
+	return ext.EndGroups
+}
+
+// closedPoll matches polls that were closed.
+func closedPoll(p *gotgbot.Poll) bool {
+	return p.IsClosed
+}
@@ -0,0 +1,62 @@
+// (c) Jisin0
+// Tests for expiring and forgetting movie night polls and drafts.
+
+package plugins
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMovieNightRetention(t *testing.T) {
+	defer func(s Store) { userStore = s }(userStore)
+
+	userStore = newMemoryStore()
+
+	old := time.Now().Add(-movieNightMaxAge - time.Hour)
+	options := []movieNightOption{{Method: searchMethodIMDb, ID: "tt1160419", Label: "Dune (2021)"}}
+
+	records := map[string]any{
+		movieNightPollKey("fresh"): movieNight{ChatID: -1, CreatorID: 7, Options: options, CreatedAt: time.Now()},
+		movieNightPollKey("other"): movieNight{ChatID: -1, CreatorID: 8, Options: options, CreatedAt: time.Now()},
+		movieNightPollKey("old"):   movieNight{ChatID: -1, CreatorID: 7, Options: options, CreatedAt: old},
+		movieNightDraftKey(-1):     movieNightDraft{Options: options, UpdatedAt: time.Now()},
+		movieNightDraftKey(-2):     movieNightDraft{Options: options, UpdatedAt: old},
+	}
+
+	for key, v := range records {
+		if err := saveRecord(key, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, found := loadMovieNight("old"); found {
+		t.Error("old poll wasn't treated as closed")
+	}
+
+	if draft := loadMovieNightDraft(-2); draft != nil {
+		t.Errorf("old draft = %v; want none", draft)
+	}
+
+	if err := cleanMovieNights(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := userStore.Keys("movienight:")
+	if len(keys) != 3 || keys[0] != movieNightDraftKey(-1) {
+		t.Errorf("keys after cleaning = %v; want the fresh polls and draft", keys)
+	}
+
+	if err := forgetUser(7); err != nil {
+		t.Fatal(err)
+	}
+
+	if night, _ := loadMovieNight("fresh"); night.CreatorID != 0 || len(night.Options) != 1 {
+		t.Errorf("poll of a forgotten user = %+v; want it without the creator", night)
+	}
+
+	if night, _ := loadMovieNight("other"); night.CreatorID != 8 {
+		t.Errorf("poll of another user = %+v; want it unchanged", night)
+	}
+}
@@ -0,0 +1,232 @@
+// (c) Jisin0
+// Group justwatch offers by monetization type and render them.
+
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Jisin0/filmigo/justwatch"
+)
+
+// offerGroup is a list of providers offering a title the same way like streaming or renting.
+type offerGroup struct {
+	Name      string
+	Emoji     string
+	Providers []*providerOffers
+}
+
+// providerOffers are the offers of a single provider within a group.
+type providerOffers struct {
+	Name string
+	URL  string
+	// Offers in each quality in ascending order.
+	Qualities []offerQuality
+}
+
+// offerQuality is an offer in a single video quality.
+type offerQuality struct {
+	Quality string
+	// Formatted price with the currency, empty if it's included in a subscription.
+	Price string
+	Value float32
+}
+
+// offerGroupOrder lists monetization types in the order they're shown along with their names.
+var offerGroupOrder = []struct {
+	Type  string
+	Name  string
+	Emoji string
+}{
+	{"FLATRATE", "Stream", "📺"},
+	{"FREE", "Free", "🆓"},
+	{"ADS", "Ads", "📢"},
+	{"RENT", "Rent", "💵"},
+	{"BUY", "Buy", "🛒"},
+}
+
+// qualityRank orders video qualities from lowest to highest.
+var qualityRank = map[string]int{"SD": 1, "HD": 2, "4K": 3}
+
+// offerQualityName normalizes a presentation type like _4K or HD.
+func offerQualityName(presentation string) string {
+	q := strings.Trim(strings.ToUpper(presentation), "_")
+	if q == "UHD" {
+		return "4K"
+	}
+
+	return q
+}
+
+// groupOffers groups offers by monetization type and collapses the offers of each provider.
+func groupOffers(offers []*justwatch.Offer) []offerGroup {
+	var (
+		groups  = make(map[string]*offerGroup)
+		byName  = make(map[string]*providerOffers)
+		unknown []string
+	)
+
+	for _, o := range offers {
+		if o == nil || o.Package == nil || o.Package.ClearName == "" {
+			continue
+		}
+
+		typ := strings.ToUpper(o.MonetizationType)
+
+		g, ok := groups[typ]
+		if !ok {
+			g = &offerGroup{Name: capitalizeFirstLetter(strings.ToLower(typ)), Emoji: "🎬"}
+
+			isKnown := false
+
+			for _, known := range offerGroupOrder {
+				if known.Type == typ {
+					g.Name, g.Emoji, isKnown = known.Name, known.Emoji, true
+				}
+			}
+
+			if !isKnown {
+				unknown = append(unknown, typ)
+			}
+
+			groups[typ] = g
+		}
+
+		key := typ + ":" + o.Package.ClearName
+
+		p, ok := byName[key]
+		if !ok {
+			p = &providerOffers{Name: o.Package.ClearName, URL: o.URL}
+			byName[key] = p
+			g.Providers = append(g.Providers, p)
+		}
+
+		q := offerQuality{Quality: offerQualityName(o.PresentationType), Price: o.RetailPrice, Value: o.RetailPriceValue}
+		if !hasQuality(p.Qualities, q.Quality) {
+			p.Qualities = append(p.Qualities, q)
+		}
+	}
+
+	result := make([]offerGroup, 0, len(groups))
+
+	for _, known := range offerGroupOrder {
+		if g, ok := groups[known.Type]; ok {
+			result = append(result, *g)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	for _, typ := range unknown {
+		result = append(result, *groups[typ])
+	}
+
+	for _, g := range result {
+		for _, p := range g.Providers {
+			sort.SliceStable(p.Qualities, func(i, j int) bool {
+				return qualityRank[p.Qualities[i].Quality] < qualityRank[p.Qualities[j].Quality]
+			})
+		}
+	}
+
+	return result
+}
+
+func hasQuality(qualities []offerQuality, quality string) bool {
+	for _, q := range qualities {
+		if q.Quality == quality {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cheapest returns the lowest priced offer of a provider.
+func (p *providerOffers) cheapest() offerQuality {
+	best := p.Qualities[0]
+
+	for _, q := range p.Qualities[1:] {
+		if q.Price != "" && (best.Price == "" || q.Value < best.Value) {
+			best = q
+		}
+	}
+
+	return best
+}
+
+// details returns the qualities and prices of a provider's offers for ex: HD $3.99 · 4K $5.99.
+func (p *providerOffers) details() string {
+	parts := make([]string, 0, len(p.Qualities))
+
+	for _, q := range p.Qualities {
+		part := strings.TrimSpace(q.Quality + " " + q.Price)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+// renderOffers renders grouped offers as html with a line for each group.
+// Compact offers only show the cheapest price of each provider.
+func renderOffers(groups []offerGroup, compact bool) string {
+	var sb strings.Builder
+
+	for i, g := range groups {
+		if i > 0 {
+			sb.WriteRune('\n')
+		}
+
+		items := make([]string, 0, len(g.Providers))
+
+		for _, p := range g.Providers {
+			item := htmlLink(p.URL, p.Name)
+
+			var extra string
+
+			switch {
+			case !compact:
+				extra = p.details()
+			case p.cheapest().Price != "":
+				extra = "from " + p.cheapest().Price
+			}
+
+			if extra != "" {
+				item += fmt.Sprintf(" <i>(%s)</i>", escapeHTML(extra))
+			}
+
+			items = append(items, item)
+		}
+
+		sb.WriteString(fmt.Sprintf("%s <b>%s:</b> %s", g.Emoji, escapeHTML(g.Name), strings.Join(items, ", ")))
+	}
+
+	return sb.String()
+}
+
+// renderOffersTelegraph renders grouped offers as a table of providers with the price of each quality.
+func renderOffersTelegraph(groups []offerGroup) []tgNode {
+	var nodes []tgNode
+
+	for _, g := range groups {
+		nodes = append(nodes, makeHeader(g.Emoji+" "+g.Name))
+
+		for _, p := range g.Providers {
+			details := p.details()
+			if details == "" {
+				details = "Included"
+			}
+
+			nodes = append(nodes, tgNode{Tag: "p", Children: []any{
+				tgNode{Tag: "a", Attrs: &tgAttrs{Href: p.URL}, Children: []any{tgNode{Tag: "b", Children: []any{p.Name}}}},
+				": " + details,
+			}})
+		}
+	}
+
+	return nodes
+}
@@ -0,0 +1,183 @@
+// (c) Jisin0
+// Tests for grouping and rendering justwatch offers.
+
+package plugins
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Jisin0/filmigo/justwatch"
+)
+
+// testOffer returns an offer of a provider, price is empty for subscriptions.
+func testOffer(typ, provider, quality, price string, value float32) *justwatch.Offer {
+	return &justwatch.Offer{
+		MonetizationType: typ,
+		PresentationType: quality,
+		RetailPrice:      price,
+		RetailPriceValue: value,
+		URL:              "https://example.com/" + provider,
+		Package:          &justwatch.Package{ClearName: provider},
+	}
+}
+
+// offerSummary is the name of a group followed by each provider and it's qualities.
+func offerSummary(groups []offerGroup) [][]string {
+	summary := make([][]string, 0, len(groups))
+
+	for _, g := range groups {
+		line := []string{g.Emoji + " " + g.Name}
+
+		for _, p := range g.Providers {
+			line = append(line, p.Name+": "+p.details())
+		}
+
+		summary = append(summary, line)
+	}
+
+	return summary
+}
+
+func TestGroupOffers(t *testing.T) {
+	tests := []struct {
+		name   string
+		offers []*justwatch.Offer
+		want   [][]string
+	}{
+		{
+			name: "groups are shown in a fixed order",
+			offers: []*justwatch.Offer{
+				testOffer("BUY", "Apple TV", "HD", "$14.99", 14.99),
+				testOffer("RENT", "Apple TV", "HD", "$3.99", 3.99),
+				testOffer("FLATRATE", "Max", "HD", "", 0),
+				testOffer("FREE", "Tubi", "SD", "", 0),
+				testOffer("ADS", "Pluto", "SD", "", 0),
+			},
+			want: [][]string{
+				{"📺 Stream", "Max: HD"},
+				{"🆓 Free", "Tubi: SD"},
+				{"📢 Ads", "Pluto: SD"},
+				{"💵 Rent", "Apple TV: HD $3.99"},
+				{"🛒 Buy", "Apple TV: HD $14.99"},
+			},
+		},
+		{
+			name: "offers of a provider are collapsed and sorted by quality",
+			offers: []*justwatch.Offer{
+				testOffer("RENT", "Prime Video", "_4K", "$5.99", 5.99),
+				testOffer("RENT", "Prime Video", "SD", "$2.99", 2.99),
+				testOffer("RENT", "Apple TV", "UHD", "$5.99", 5.99),
+				testOffer("RENT", "Prime Video", "HD", "$3.99", 3.99),
+			},
+			want: [][]string{
+				{"💵 Rent", "Prime Video: SD $2.99 · HD $3.99 · 4K $5.99", "Apple TV: 4K $5.99"},
+			},
+		},
+		{
+			name: "duplicate offers are dropped",
+			offers: []*justwatch.Offer{
+				testOffer("flatrate", "Netflix", "HD", "", 0),
+				testOffer("FLATRATE", "Netflix", "HD", "", 0),
+				testOffer("FLATRATE", "Netflix", "4K", "", 0),
+				testOffer("BUY", "Netflix", "HD", "$9.99", 9.99),
+			},
+			want: [][]string{
+				{"📺 Stream", "Netflix: HD · 4K"},
+				{"🛒 Buy", "Netflix: HD $9.99"},
+			},
+		},
+		{
+			name: "unknown types come last in alphabetical order",
+			offers: []*justwatch.Offer{
+				testOffer("RENTAL_PASS", "Vudu", "HD", "", 0),
+				testOffer("CINEMA", "AMC", "", "$12.00", 12),
+				testOffer("RENT", "Vudu", "HD", "$3.99", 3.99),
+			},
+			want: [][]string{
+				{"💵 Rent", "Vudu: HD $3.99"},
+				{"🎬 Cinema", "AMC: $12.00"},
+				{"🎬 Rental_pass", "Vudu: HD"},
+			},
+		},
+		{
+			name: "offers without a provider are skipped",
+			offers: []*justwatch.Offer{
+				nil,
+				{MonetizationType: "FLATRATE"},
+				{MonetizationType: "FLATRATE", Package: &justwatch.Package{}},
+			},
+			want: [][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := offerSummary(groupOffers(tt.offers)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: groups = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderOffers(t *testing.T) {
+	groups := groupOffers([]*justwatch.Offer{
+		testOffer("FLATRATE", "Max", "HD", "", 0),
+		testOffer("FLATRATE", "Disney+", "4K", "", 0),
+		testOffer("RENT", "Apple TV", "4K", "$5.99", 5.99),
+		testOffer("RENT", "Apple TV", "HD", "$3.99", 3.99),
+		testOffer("RENT", "B&N <Video>", "SD", "£1.99", 1.99),
+	})
+
+	tests := []struct {
+		compact bool
+		want    string
+	}{
+		{
+			compact: false,
+			want: "📺 <b>Stream:</b> <a href='https://example.com/Max'>Max</a> <i>(HD)</i>, <a href='https://example.com/Disney+'>Disney+</a> <i>(4K)</i>\n" +
+				"💵 <b>Rent:</b> <a href='https://example.com/Apple TV'>Apple TV</a> <i>(HD $3.99 · 4K $5.99)</i>, " +
+				"<a href='https://example.com/B&amp;N &lt;Video&gt;'>B&amp;N &lt;Video&gt;</a> <i>(SD £1.99)</i>",
+		},
+		{
+			// Compact offers only show the cheapest price and nothing for subscriptions.
+			compact: true,
+			want: "📺 <b>Stream:</b> <a href='https://example.com/Max'>Max</a>, <a href='https://example.com/Disney+'>Disney+</a>\n" +
+				"💵 <b>Rent:</b> <a href='https://example.com/Apple TV'>Apple TV</a> <i>(from $3.99)</i>, " +
+				"<a href='https://example.com/B&amp;N &lt;Video&gt;'>B&amp;N &lt;Video&gt;</a> <i>(from £1.99)</i>",
+		},
+	}
+
+	for _, tt := range tests {
+		got := renderOffers(groups, tt.compact)
+		if got != tt.want {
+			t.Errorf("renderOffers(compact=%v) =\n%s\nwant\n%s", tt.compact, got, tt.want)
+		}
+
+		if err := validateHTML(got); err != nil {
+			t.Errorf("renderOffers(compact=%v) made invalid html: %v", tt.compact, err)
+		}
+	}
+
+	if got := renderOffers(nil, true); got != "" {
+		t.Errorf("renderOffers(nil) = %q; want nothing", got)
+	}
+}
+
+func TestProviderOffersCheapest(t *testing.T) {
+	tests := []struct {
+		name      string
+		qualities []offerQuality
+		want      string
+	}{
+		{"lowest price", []offerQuality{{"SD", "$4.99", 4.99}, {"HD", "$2.99", 2.99}, {"4K", "$5.99", 5.99}}, "HD"},
+		{"priced beats included", []offerQuality{{"SD", "", 0}, {"HD", "$2.99", 2.99}}, "HD"},
+		{"first of equal prices", []offerQuality{{"HD", "$2.99", 2.99}, {"4K", "$2.99", 2.99}}, "HD"},
+		{"included only", []offerQuality{{"HD", "", 0}}, "HD"},
+	}
+
+	for _, tt := range tests {
+		p := &providerOffers{Qualities: tt.qualities}
+		if got := p.cheapest().Quality; got != tt.want {
+			t.Errorf("%s: cheapest() = %s; want %s", tt.name, got, tt.want)
+		}
+	}
+}
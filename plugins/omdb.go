@@ -4,13 +4,14 @@
 package plugins
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html"
 	"io"
-	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -42,13 +43,22 @@ var (
 	telegraphToken   string
 )
 
+// OMDb titles are fetched through the hybrid apis, it's search button is only shown if an api key is set.
+var omdbProvider = registerProvider(&hybridProvider{info: ProviderInfo{
+	Name:      searchMethodOMDb,
+	Label:     "OMDb",
+	Banner:    omdbBanner,
+	ExampleID: "tt1375666",
+	IDPattern: imdbIDPattern,
+}})
+
 func init() {
 	if OmdbApiKey != "" {
 		omdbClient = omdb.NewClient(OmdbApiKey)
-		inlineSearchButtons = append(inlineSearchButtons, []gotgbot.InlineKeyboardButton{{Text: "🔍 Search OMDb", SwitchInlineQueryCurrentChat: &inlineOMDbSwitch}})
+		omdbProvider.Info().SearchButton = "🔍 Search OMDb"
 	}
 	if enableTelegraph {
-		go ensureTelegraphToken()
+		go ensureTelegraphToken(context.Background())
 	}
 }
 
@@ -60,17 +70,33 @@ type UniversalSearchResult struct {
 	Poster string
 	Type   string
 	Rating float64 // --- ADDED RATINGS ---
+
+	// Fields only populated by some providers.
+	Description string
+	Genres      []string
+	URL         string
+	// Code of the original language like en, empty if it's unknown.
+	Language string
+
+	// Providers in which the title was found, only set by the all sources search.
+	Sources []SearchSource
+}
+
+// SearchSource is the id of a search result in a single provider.
+type SearchSource struct {
+	Method string
+	ID     string
 }
 
 // ==========================================
 // 1. TELEGRAPH HELPERS
 // ==========================================
 
-func ensureTelegraphToken() {
+func ensureTelegraphToken(ctx context.Context) {
 	if telegraphToken != "" {
 		return
 	}
-	resp, err := http.Get("https://api.telegra.ph/createAccount?short_name=FilmigoBot&author_name=Filmigo+Bot")
+	resp, err := upstream.Get(ctx, "https://api.telegra.ph/createAccount?short_name=FilmigoBot&author_name=Filmigo+Bot")
 	if err == nil {
 		defer resp.Body.Close()
 		body, _ := io.ReadAll(resp.Body)
@@ -97,8 +123,8 @@ type tgAttrs struct {
 	Href string `json:"href,omitempty"`
 }
 
-func createTelegraphPage(title string, nodes []tgNode) string {
-	ensureTelegraphToken()
+func createTelegraphPage(ctx context.Context, title string, nodes []tgNode) string {
+	ensureTelegraphToken(ctx)
 	if telegraphToken == "" {
 		return ""
 	}
@@ -111,7 +137,7 @@ func createTelegraphPage(title string, nodes []tgNode) string {
 	data.Set("title", title)
 	data.Set("content", string(contentBytes))
 	data.Set("return_content", "false")
-	resp, err := http.PostForm("https://api.telegra.ph/createPage", data)
+	resp, err := upstream.PostForm(ctx, "https://api.telegra.ph/createPage", data)
 	if err != nil {
 		return ""
 	}
@@ -160,8 +186,12 @@ type primaryDetailData struct {
 		} `json:"overall"`
 	} `json:"reviewSummary"`
 	Top struct {
-		TitleText   struct{ Text string `json:"text"` } `json:"titleText"`
-		TitleType   struct{ Text string `json:"text"` } `json:"titleType"`
+		TitleText struct {
+			Text string `json:"text"`
+		} `json:"titleText"`
+		TitleType struct {
+			Text string `json:"text"`
+		} `json:"titleType"`
 		ReleaseYear struct {
 			Year    int `json:"year"`
 			EndYear int `json:"endYear"`
@@ -170,11 +200,15 @@ type primaryDetailData struct {
 			Day     int `json:"day"`
 			Month   int `json:"month"`
 			Year    int `json:"year"`
-			Country struct{ Text string `json:"text"` } `json:"country"`
+			Country struct {
+				Text string `json:"text"`
+			} `json:"country"`
 		} `json:"releaseDate"`
 		Runtime struct {
 			DisplayableProperty struct {
-				Value struct{ PlainText string `json:"plainText"` } `json:"value"`
+				Value struct {
+					PlainText string `json:"plainText"`
+				} `json:"value"`
 			} `json:"displayableProperty"`
 		} `json:"runtime"`
 		RatingsSummary struct {
@@ -182,101 +216,153 @@ type primaryDetailData struct {
 			VoteCount       int     `json:"voteCount"`
 		} `json:"ratingsSummary"`
 		Metacritic *struct {
-			Metascore struct{ Score int `json:"score"` } `json:"metascore"`
+			Metascore struct {
+				Score int `json:"score"`
+			} `json:"metascore"`
 		} `json:"metacritic"`
-		Genres    struct{ Genres []struct{ Text string `json:"text"` } `json:"genres"` } `json:"genres"`
+		Genres struct {
+			Genres []struct {
+				Text string `json:"text"`
+			} `json:"genres"`
+		} `json:"genres"`
 		Interests struct {
 			Edges []struct {
 				Node struct {
-					PrimaryText struct{ Text string `json:"text"` } `json:"primaryText"`
+					PrimaryText struct {
+						Text string `json:"text"`
+					} `json:"primaryText"`
 				} `json:"node"`
 			} `json:"edges"`
 		} `json:"interests"`
 		Plot struct {
-			PlotText struct{ PlainText string `json:"plainText"` } `json:"plotText"`
+			PlotText struct {
+				PlainText string `json:"plainText"`
+			} `json:"plotText"`
 		} `json:"plot"`
-		PrimaryImage struct{ URL string `json:"url"` } `json:"primaryImage"`
-		Directors    []struct {
+		PrimaryImage struct {
+			URL string `json:"url"`
+		} `json:"primaryImage"`
+		Directors []struct {
 			Credits []struct {
 				Name struct {
-					NameText struct{ Text string `json:"text"` } `json:"nameText"`
-					ID       string `json:"id"`
+					NameText struct {
+						Text string `json:"text"`
+					} `json:"nameText"`
+					ID string `json:"id"`
 				} `json:"name"`
 			} `json:"credits"`
 		} `json:"directorsPageTitle"`
 		PrincipalCredits []struct {
-			Grouping struct{ Text string `json:"text"` } `json:"grouping"`
-			Credits  []struct {
+			Grouping struct {
+				Text string `json:"text"`
+			} `json:"grouping"`
+			Credits []struct {
 				Name struct {
-					NameText struct{ Text string `json:"text"` } `json:"nameText"`
-					ID       string `json:"id"`
+					NameText struct {
+						Text string `json:"text"`
+					} `json:"nameText"`
+					ID string `json:"id"`
 				} `json:"name"`
 			} `json:"credits"`
 		} `json:"principalCreditsV2"`
 		Cast []struct {
-			Grouping struct{ Text string `json:"text"` } `json:"grouping"`
-			Credits  []struct {
+			Grouping struct {
+				Text string `json:"text"`
+			} `json:"grouping"`
+			Credits []struct {
 				Name struct {
-					NameText struct{ Text string `json:"text"` } `json:"nameText"`
-					ID       string `json:"id"`
+					NameText struct {
+						Text string `json:"text"`
+					} `json:"nameText"`
+					ID string `json:"id"`
 				} `json:"name"`
 			} `json:"credits"`
 		} `json:"castV2"`
-		Certificate struct{ Rating string `json:"rating"` } `json:"certificate"`
+		Certificate struct {
+			Rating string `json:"rating"`
+		} `json:"certificate"`
 		ProductionStatus struct {
-			CurrentProductionStage struct{ Text string `json:"text"` } `json:"currentProductionStage"`
+			CurrentProductionStage struct {
+				Text string `json:"text"`
+			} `json:"currentProductionStage"`
 		} `json:"productionStatus"`
 		FeaturedReviews *struct {
 			Edges []struct {
 				Node struct {
-					Author       struct{ NickName string `json:"nickName"` } `json:"author"`
-					Summary      struct{ OriginalText string `json:"originalText"` } `json:"summary"`
-					Text         struct{ OriginalText struct{ PlainHtml string `json:"plaidHtml"` } `json:"originalText"` } `json:"text"`
+					Author struct {
+						NickName string `json:"nickName"`
+					} `json:"author"`
+					Summary struct {
+						OriginalText string `json:"originalText"`
+					} `json:"summary"`
+					Text struct {
+						OriginalText struct {
+							PlainHtml string `json:"plaidHtml"`
+						} `json:"originalText"`
+					} `json:"text"`
 					AuthorRating int `json:"authorRating"`
 				} `json:"node"`
 			} `json:"edges"`
 		} `json:"featuredReviews"`
-		TriviaTotal struct{ Total int `json:"total"` } `json:"triviaTotal"`
-		Trivia      struct {
+		TriviaTotal struct {
+			Total int `json:"total"`
+		} `json:"triviaTotal"`
+		Trivia struct {
 			Edges []struct {
 				Node struct {
-					Text struct{ PlaidHtml string `json:"plaidHtml"` } `json:"text"`
+					Text struct {
+						PlaidHtml string `json:"plaidHtml"`
+					} `json:"text"`
 				} `json:"node"`
 			} `json:"edges"`
 		} `json:"trivia"`
-		GoofsTotal struct{ Total int `json:"total"` } `json:"goofsTotal"`
-		Goofs      struct {
+		GoofsTotal struct {
+			Total int `json:"total"`
+		} `json:"goofsTotal"`
+		Goofs struct {
 			Edges []struct {
 				Node struct {
-					Text struct{ PlaidHtml string `json:"plaidHtml"` } `json:"text"`
+					Text struct {
+						PlaidHtml string `json:"plaidHtml"`
+					} `json:"text"`
 				} `json:"node"`
 			} `json:"edges"`
 		} `json:"goofs"`
-		QuotesTotal struct{ Total int `json:"total"` } `json:"quotesTotal"`
-		Quotes      struct {
+		QuotesTotal struct {
+			Total int `json:"total"`
+		} `json:"quotesTotal"`
+		Quotes struct {
 			Edges []struct {
 				Node struct {
-					Lines []struct{ Text string `json:"text"` } `json:"lines"`
+					Lines []struct {
+						Text string `json:"text"`
+					} `json:"lines"`
 				} `json:"node"`
 			} `json:"edges"`
 		} `json:"quotes"`
 		FilmingLocations struct {
 			Edges []struct {
-				Node struct{ Text string `json:"text"` } `json:"node"`
+				Node struct {
+					Text string `json:"text"`
+				} `json:"node"`
 			} `json:"edges"`
 		} `json:"filmingLocations"`
 		Production struct {
 			Edges []struct {
 				Node struct {
 					Company struct {
-						CompanyText struct{ Text string `json:"text"` } `json:"companyText"`
+						CompanyText struct {
+							Text string `json:"text"`
+						} `json:"companyText"`
 					} `json:"company"`
 				} `json:"node"`
 			} `json:"edges"`
 		} `json:"production"`
 		Soundtrack struct {
 			Edges []struct {
-				Node struct{ Text string `json:"text"` } `json:"node"`
+				Node struct {
+					Text string `json:"text"`
+				} `json:"node"`
 			} `json:"edges"`
 		} `json:"soundtrack"`
 	} `json:"top"`
@@ -285,25 +371,39 @@ type primaryDetailData struct {
 			Nominations int `json:"nominations"`
 			Wins        int `json:"wins"`
 		} `json:"prestigiousAwardSummary"`
-		Wins        struct{ Total int `json:"total"` } `json:"wins"`
-		Nominations struct{ Total int `json:"total"` } `json:"nominationsExcludeWins"`
-		Languages   struct {
-			Languages []struct{ Text string `json:"text"` } `json:"spokenLanguages"`
+		Wins struct {
+			Total int `json:"total"`
+		} `json:"wins"`
+		Nominations struct {
+			Total int `json:"total"`
+		} `json:"nominationsExcludeWins"`
+		Languages struct {
+			Languages []struct {
+				Text string `json:"text"`
+			} `json:"spokenLanguages"`
 		} `json:"spokenLanguages"`
 		Countries struct {
-			Countries []struct{ Text string `json:"text"` } `json:"countries"`
+			Countries []struct {
+				Text string `json:"text"`
+			} `json:"countries"`
 		} `json:"countriesDetails"`
 		Akas struct {
 			Edges []struct {
-				Node struct{ Text string `json:"text"` } `json:"node"`
+				Node struct {
+					Text string `json:"text"`
+				} `json:"node"`
 			} `json:"edges"`
 		} `json:"akas"`
 		Cast []struct {
-			Grouping struct{ Text string `json:"text"` } `json:"grouping"`
-			Credits  []struct {
+			Grouping struct {
+				Text string `json:"text"`
+			} `json:"grouping"`
+			Credits []struct {
 				Name struct {
-					NameText struct{ Text string `json:"text"` } `json:"nameText"`
-					ID       string `json:"id"`
+					NameText struct {
+						Text string `json:"text"`
+					} `json:"nameText"`
+					ID string `json:"id"`
 				} `json:"name"`
 				Characters []struct {
 					Name string `json:"name"`
@@ -314,7 +414,9 @@ type primaryDetailData struct {
 			Seasons []struct {
 				Number int `json:"number"`
 			} `json:"seasons"`
-			TotalEpisodes struct{ Total int `json:"total"` } `json:"totalEpisodes"`
+			TotalEpisodes struct {
+				Total int `json:"total"`
+			} `json:"totalEpisodes"`
 		} `json:"episodes"`
 		ProductionBudget *struct {
 			Budget struct {
@@ -336,10 +438,14 @@ type primaryDetailData struct {
 		} `json:"worldwideGross"`
 		TechnicalSpecifications *struct {
 			SoundMixes struct {
-				Items []struct{ Text string `json:"text"` } `json:"items"`
+				Items []struct {
+					Text string `json:"text"`
+				} `json:"items"`
 			} `json:"soundMixes"`
 			AspectRatios struct {
-				Items []struct{ AspectRatio string `json:"aspectRatio"` } `json:"items"`
+				Items []struct {
+					AspectRatio string `json:"aspectRatio"`
+				} `json:"items"`
 			} `json:"aspectRatios"`
 		} `json:"technicalSpecifications"`
 	} `json:"main"`
@@ -353,12 +459,45 @@ type fallbackSearchRes struct {
 		ID           string `json:"id"`
 		PrimaryTitle string `json:"primaryTitle"`
 		StartYear    int    `json:"startYear"`
-		PrimaryImage *struct{ URL string `json:"url"` } `json:"primaryImage"`
-		Type         string `json:"type"`
-		Rating       *struct {                          // --- ADDED RATINGS EXTRACTION ---
+		PrimaryImage *struct {
+			URL string `json:"url"`
+		} `json:"primaryImage"`
+		Type   string    `json:"type"`
+		Rating *struct { // --- ADDED RATINGS EXTRACTION ---
 			AggregateRating float64 `json:"aggregateRating"`
 		} `json:"rating"`
 	} `json:"titles"` // Uses titles mapping from imdbapi.dev
+	// Token of the next page, empty on the last page.
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// Items converts the titles of a search into search results.
+func (r *fallbackSearchRes) Items() []UniversalSearchResult {
+	results := make([]UniversalSearchResult, 0, len(r.Results))
+
+	for _, item := range r.Results {
+		poster := ""
+		if item.PrimaryImage != nil {
+			poster = item.PrimaryImage.URL
+		}
+
+		typeTag := ""
+		if item.Type != "" {
+			typeTag = strings.Title(item.Type)
+		}
+
+		// --- RATINGS EXTRACTION ---
+		rating := 0.0
+		if item.Rating != nil {
+			rating = item.Rating.AggregateRating
+		}
+
+		results = append(results, UniversalSearchResult{
+			ID: item.ID, Title: item.PrimaryTitle, Year: item.StartYear, Poster: poster, Type: typeTag, Rating: rating,
+		})
+	}
+
+	return results
 }
 
 type fallbackDetailData struct {
@@ -374,50 +513,82 @@ type fallbackDetailData struct {
 		AggregateRating float64 `json:"aggregateRating"`
 		VoteCount       int     `json:"voteCount"`
 	} `json:"rating"`
-	PrimaryImage *struct{ URL string `json:"url"` } `json:"primaryImage"`
-	ReleaseDate  *string                            `json:"releaseDate"`
-	Metacritic   *struct{ Score int `json:"score"` } `json:"metacritic"`
-	Directors    []struct{ ID string `json:"id"`; Name string `json:"displayName"` } `json:"directors"`
-	Writers      []struct{ ID string `json:"id"`; Name string `json:"displayName"` } `json:"writers"`
-	Stars        []struct{ ID string `json:"id"`; Name string `json:"displayName"` } `json:"stars"`
-	Interests    []struct{ Name string `json:"name"` }                               `json:"interests"`
-	Countries    []struct{ Name string `json:"name"` }                               `json:"originCountries"`
-	Languages    []struct{ Name string `json:"name"` }                               `json:"spokenLanguages"`
+	PrimaryImage *struct {
+		URL string `json:"url"`
+	} `json:"primaryImage"`
+	ReleaseDate *string `json:"releaseDate"`
+	Metacritic  *struct {
+		Score int `json:"score"`
+	} `json:"metacritic"`
+	Directors []struct {
+		ID   string `json:"id"`
+		Name string `json:"displayName"`
+	} `json:"directors"`
+	Writers []struct {
+		ID   string `json:"id"`
+		Name string `json:"displayName"`
+	} `json:"writers"`
+	Stars []struct {
+		ID   string `json:"id"`
+		Name string `json:"displayName"`
+	} `json:"stars"`
+	Interests []struct {
+		Name string `json:"name"`
+	} `json:"interests"`
+	Countries []struct {
+		Name string `json:"name"`
+	} `json:"originCountries"`
+	Languages []struct {
+		Name string `json:"name"`
+	} `json:"spokenLanguages"`
 }
 type fallbackCredits struct {
 	Cast []struct {
-		Name       struct{ ID string `json:"id"`; DisplayName string `json:"displayName"` } `json:"name"`
-		Characters []struct{ Name string `json:"name"` }                                    `json:"characters"`
+		Name struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"displayName"`
+		} `json:"name"`
+		Characters []struct {
+			Name string `json:"name"`
+		} `json:"characters"`
 	} `json:"cast"`
 }
 type fallbackAKA struct {
-	Titles []struct{ Title string `json:"title"` } `json:"titles"`
+	Titles []struct {
+		Title string `json:"title"`
+	} `json:"titles"`
 }
 
 // --- NEW: STRUCTS FOR TMDB ---
 type tmdbFindRes struct {
-	MovieResults []struct{ ID int `json:"id"` } `json:"movie_results"`
-	TVResults    []struct{ ID int `json:"id"` } `json:"tv_results"`
+	MovieResults []struct {
+		ID int `json:"id"`
+	} `json:"movie_results"`
+	TVResults []struct {
+		ID int `json:"id"`
+	} `json:"tv_results"`
 }
 type tmdbDetailRes struct {
-	Title         string `json:"title"`          
-	OriginalTitle string `json:"original_title"` 
-	PosterPath    string `json:"poster_path"`
-	BackdropPath  string `json:"backdrop_path"`
-	Tagline       string `json:"tagline"`
-	ReleaseDate   string `json:"release_date"`   
-	FirstAirDate  string `json:"first_air_date"` 
-	OriginCountry []string `json:"origin_country"` 
-	ProductionCountries []struct{ Name string `json:"name"` } `json:"production_countries"` 
-	
-	Credits       struct {
+	Title               string   `json:"title"`
+	OriginalTitle       string   `json:"original_title"`
+	PosterPath          string   `json:"poster_path"`
+	BackdropPath        string   `json:"backdrop_path"`
+	Tagline             string   `json:"tagline"`
+	ReleaseDate         string   `json:"release_date"`
+	FirstAirDate        string   `json:"first_air_date"`
+	OriginCountry       []string `json:"origin_country"`
+	ProductionCountries []struct {
+		Name string `json:"name"`
+	} `json:"production_countries"`
+
+	Credits struct {
 		Cast []struct {
-			ID        int    `json:"id"` 
+			ID        int    `json:"id"`
 			Name      string `json:"name"`
 			Character string `json:"character"`
 		} `json:"cast"`
 		Crew []struct {
-			ID         int    `json:"id"` 
+			ID         int    `json:"id"`
 			Name       string `json:"name"`
 			Job        string `json:"job"`
 			Department string `json:"department"`
@@ -463,989 +634,764 @@ type omdbFillData struct {
 // 4. UNIFIED SEARCH FUNCTION
 // ==========================================
 
-func SearchOMDb(query string) ([]UniversalSearchResult, error) {
+func SearchOMDb(ctx context.Context, query string, limit int) ([]UniversalSearchResult, error) {
+	results, _, err := searchIMDbAPI(ctx, query, "", limit)
+	return results, err
+}
+
+// searchIMDbAPI searches imdbapi.dev for a page of titles starting at pageToken and returns the token of the next page.
+func searchIMDbAPI(ctx context.Context, query, pageToken string, limit int) ([]UniversalSearchResult, string, error) {
 	// EXCLUSIVE INLINE SEARCH: imdbapi.dev
-	apiURL := fmt.Sprintf("%s/search/titles?query=%s", apiFallback, url.QueryEscape(query))
-	if resp, err := http.Get(apiURL); err == nil {
+	apiURL := fmt.Sprintf("%s/search/titles?query=%s&limit=%d", apiFallback, url.QueryEscape(query), limit)
+	if pageToken != "" {
+		apiURL += "&pageToken=" + url.QueryEscape(pageToken)
+	}
+
+	if resp, err := upstream.Get(ctx, apiURL); err == nil {
 		defer resp.Body.Close()
 		body, _ := io.ReadAll(resp.Body)
 		var fData fallbackSearchRes
 		if json.Unmarshal(body, &fData) == nil && len(fData.Results) > 0 {
-			var results []UniversalSearchResult
-			for _, item := range fData.Results {
-				poster := ""
-				if item.PrimaryImage != nil {
-					poster = item.PrimaryImage.URL
-				}
-				
-				typeTag := ""
-				if item.Type != "" { typeTag = strings.Title(item.Type) }
-
-				// --- RATINGS EXTRACTION ---
-				rating := 0.0
-				if item.Rating != nil {
-					rating = item.Rating.AggregateRating
-				}
-
-				results = append(results, UniversalSearchResult{
-					ID: item.ID, Title: item.PrimaryTitle, Year: item.StartYear, Poster: poster, Type: typeTag, Rating: rating,
-				})
-			}
-			return results, nil
+			return fData.Items(), fData.NextPageToken, nil
 		}
 	}
 
-	return nil, errors.New("No results found via imdbapi.dev")
+	return nil, "", errors.New("No results found via imdbapi.dev")
+}
+
+// hybridProvider searches and gets titles using the hybrid apis.
+type hybridProvider struct {
+	info ProviderInfo
+}
+
+func (p *hybridProvider) Info() *ProviderInfo {
+	return &p.info
+}
+
+func (p *hybridProvider) Search(ctx context.Context, query string, limit int) ([]UniversalSearchResult, error) {
+	return cached(cacheKey("search", "hybrid", strconv.Itoa(limit), query), searchCacheTTL, func() ([]UniversalSearchResult, error) {
+		return SearchOMDb(ctx, query, limit)
+	})
+}
+
+func (p *hybridProvider) SearchPage(ctx context.Context, query, cursor string, limit int) ([]UniversalSearchResult, string, error) {
+	page, err := cached(cacheKey("searchpage", "hybrid", strconv.Itoa(limit), cursor, query), searchCacheTTL, func() (searchPage, error) {
+		items, next, err := searchIMDbAPI(ctx, query, cursor, limit)
+		return searchPage{Items: items, Next: next}, err
+	})
+
+	return page.Items, page.Next, err
+}
+
+func (p *hybridProvider) InlineResult(item UniversalSearchResult) gotgbot.InlineQueryResult {
+	return renderInlineResult(&p.info, item)
+}
+
+func (p *hybridProvider) GetTitle(ctx context.Context, id string, progress func(string)) (*TitleCard, error) {
+	return GetOMDbTitle(ctx, id, progress)
 }
 
-func OMDbInlineSearch(query string) []gotgbot.InlineQueryResult {
-	results, err := SearchOMDb(query)
+// ==========================================
+// 5. UNIFIED DETAILS FUNCTION
+// ==========================================
+
+// GetOMDbTitle gets the details of a title by it's imdb id and renders it's card.
+func GetOMDbTitle(ctx context.Context, id string, progress func(string)) (*TitleCard, error) {
+	t, err := getTitleData(ctx, id, progress)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	tgResults := make([]gotgbot.InlineQueryResult, 0, len(results))
-	for _, item := range results {
-		posterURL := item.Poster
-		if posterURL == "" || posterURL == "N/A" {
-			posterURL = omdbBanner
-		}
+	return renderTitleCard(ctx, localizeTitle(ctx, t)), nil
+}
 
-		// --- FIX: Title and Subtext formatting ---
-		title := item.Title
-		if item.Year > 0 {
-			title = fmt.Sprintf("%s [%d]", item.Title, item.Year)
-		}
+// tmdbTranslation is the name, plot and tagline of a title in a language on tmdb.
+type tmdbTranslation struct {
+	// Title of a movie or Name of a series.
+	Title    string `json:"title"`
+	Name     string `json:"name"`
+	Overview string `json:"overview"`
+	Tagline  string `json:"tagline"`
+}
 
-		description := item.Type
-		if item.Rating > 0 {
-			description = fmt.Sprintf("%s | Ratings: %.1f ⭐", item.Type, item.Rating)
-		} else {
-			description = fmt.Sprintf("%s | Ratings: N/A", item.Type)
+// localizeTitle returns a copy of a title with it's name, plot and tagline in the user's language if tmdb has them.
+// Fields that aren't translated are left as they are.
+func localizeTitle(ctx context.Context, t *Title) *Title {
+	lang := prefsFrom(ctx).Language
+	if lang == "" || lang == defaultLanguage {
+		return t
+	}
+
+	tr, err := cached(cacheKey("tmdblang", lang, t.ID), titleCacheTTL, func() (tmdbTranslation, error) {
+		ref := tmdbRef{MediaType: t.TmdbType, ID: t.TmdbID}
+		if ref.ID == 0 {
+			var err error
+
+			if ref, err = tmdbFindTitle(ctx, t.ID); err != nil {
+				return tmdbTranslation{}, err
+			}
 		}
 
-		tgResults = append(tgResults, gotgbot.InlineQueryResultArticle{
-			Id:           searchMethodOMDb + "_" + item.ID,
-			Title:        title,
-			Description:  description,
-			ThumbnailUrl: posterURL,
-			InputMessageContent: gotgbot.InputTextMessageContent{
-				MessageText: fmt.Sprintf("<i>Loading details for %s...</i>", item.Title),
-				ParseMode:   gotgbot.ParseModeHTML,
-			},
-			ReplyMarkup: &gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
-				{{Text: "Open IMDb", CallbackData: fmt.Sprintf("open_%s_%s", searchMethodOMDb, item.ID)}},
-			}},
-		})
+		var tr tmdbTranslation
+
+		return tr, getTMDBJSON(ctx, fmt.Sprintf("/%s/%d?language=%s", ref.MediaType, ref.ID, lang), &tr)
+	})
+	if err != nil {
+		fmt.Printf("localizetitle: %v\n", err)
+		return t
+	}
+
+	c := *t
+
+	switch {
+	case tr.Title != "":
+		c.Name = tr.Title
+	case tr.Name != "":
+		c.Name = tr.Name
+	}
+
+	if tr.Overview != "" {
+		c.Plot = tr.Overview
+	}
+
+	if tr.Tagline != "" {
+		c.Tagline = tr.Tagline
 	}
-	return tgResults
+
+	return &c
 }
 
-// ==========================================
-// 5. UNIFIED DETAILS FUNCTION
-// ==========================================
+// getTitleData gets the details of a title from the primary api or the fallback apis if it fails.
+func getTitleData(ctx context.Context, id string, progress func(string)) (*Title, error) {
+	return cached(cacheKey("title", id), titleCacheTTL, func() (*Title, error) {
+		return fetchTitleData(ctx, id, progress)
+	})
+}
+
+// refreshTitleData gets the latest details of a title skipping the cache, for ex: to check if it's been released.
+func refreshTitleData(ctx context.Context, id string) (*Title, error) {
+	return refreshed(cacheKey("title", id), titleCacheTTL, func() (*Title, error) {
+		return fetchTitleData(ctx, id, nil)
+	})
+}
 
-func GetOMDbTitle(id string, progress func(string)) (string, string, [][]gotgbot.InlineKeyboardButton, error) {
+func fetchTitleData(ctx context.Context, id string, progress func(string)) (*Title, error) {
 	if progress != nil {
 		go progress("<i>Using Primary API...</i>")
 	}
-	p, c, b, err := getDetailsPrimary(id)
+
+	t, err := getDetailsPrimary(ctx, id)
 	if err == nil {
-		return p, c, b, nil
+		return t, nil
 	}
+
 	if progress != nil {
 		go progress("<i>Primary API is offline. Using Fallback...</i>")
 	}
-	return getDetailsFallback(id)
+
+	return getDetailsFallback(ctx, id)
 }
 
-func getDetailsPrimary(id string) (string, string, [][]gotgbot.InlineKeyboardButton, error) {
-	var buttons [][]gotgbot.InlineKeyboardButton
+func getDetailsPrimary(ctx context.Context, id string) (*Title, error) {
 	apiURL := fmt.Sprintf("%s?tt=%s", apiPrimary, id)
-	resp, err := http.Get(apiURL)
+
+	resp, err := upstream.Get(ctx, apiURL)
 	if err != nil {
-		return "", "", buttons, err
+		return nil, err
 	}
 	defer resp.Body.Close()
+
 	body, _ := io.ReadAll(resp.Body)
 
-	var t primaryDetailData
-	if err := json.Unmarshal(body, &t); err != nil {
-		return "", "", buttons, err
-	}
-	if !t.Ok || t.Top.TitleText.Text == "" {
-		return "", "", buttons, errors.New("Not found in Primary")
+	var p primaryDetailData
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, err
 	}
 
-	// Maps
-	isSeries := (t.Top.TitleType.Text == "TV Series" || t.Top.TitleType.Text == "TV Mini Series")
-	monthMap := map[int]string{1: "January", 2: "February", 3: "March", 4: "April", 5: "May", 6: "June", 7: "July", 8: "August", 9: "September", 10: "October", 11: "November", 12: "December"}
-	genreEmojiMap := map[string]string{
-		"Action": "💥", "Adventure": "🗺️", "Sci-Fi": "🚀", "Comedy": "🤣", "Drama": "🎭", "Romance": "🌹",
-		"Thriller": "🔪", "Horror": "👻", "Fantasy": "✨",
-		"Mystery": "❓", "Crime": "-", "Animation": "-",
-		"War": "-", "History": "-","Music": "🎶",
-	}
-	countryFlagMap := map[string]string{
-		"United States": "🇺🇸", "USA": "🇺🇸",
-		"United Kingdom": "🇬🇧", "UK": "🇬🇧",
-		"India": "🇮🇳", "France": "🇫🇷",
-		"Japan": "🇯🇵", "Canada": "🇨🇦",
-		"Germany": "🇩🇪",
+	if !p.Ok || p.Top.TitleText.Text == "" {
+		return nil, errors.New("Not found in Primary")
 	}
-	genreMap := make(map[string]bool)
 
-	var sb strings.Builder
-	imdbURL := omdbHomepage + "/title/" + id
+	return primaryTitle(id, &p), nil
+}
 
-	// Title
-	var yearString string
-	if isSeries {
-		if t.Top.ReleaseYear.EndYear > 0 {
-			yearString = fmt.Sprintf("[%d-%d]", t.Top.ReleaseYear.Year, t.Top.ReleaseYear.EndYear)
-		} else {
-			yearString = fmt.Sprintf("[%d-Present]", t.Top.ReleaseYear.Year)
-		}
-	} else {
-		yearString = fmt.Sprintf("[%d]", t.Top.ReleaseYear.Year)
-	}
-	sb.WriteString(fmt.Sprintf("<i>%s: </i><b>%s %s</b> | <a href=\"%s\">IMDb Link</a>\n", t.Top.TitleType.Text, t.Top.TitleText.Text, yearString, imdbURL))
+// primaryTitle maps the response of the primary api to a Title.
+//
+//nolint:gocyclo // mapping every field of the api.
+func primaryTitle(id string, p *primaryDetailData) *Title {
+	t := &Title{
+		ID:        id,
+		Type:      p.Top.TitleType.Text,
+		IsSeries:  p.Top.TitleType.Text == "TV Series" || p.Top.TitleType.Text == "TV Mini Series",
+		Name:      p.Top.TitleText.Text,
+		StartYear: p.Top.ReleaseYear.Year,
+		EndYear:   p.Top.ReleaseYear.EndYear,
+		Runtime:   p.Top.Runtime.DisplayableProperty.Value.PlainText,
+		Rating:    p.Top.RatingsSummary.AggregateRating,
+		Votes:     p.Top.RatingsSummary.VoteCount,
 
-	if len(t.Main.Akas.Edges) > 0 {
-		sb.WriteString(fmt.Sprintf("<i>(AKA: %s)</i>\n", t.Main.Akas.Edges[0].Node.Text))
+		Certificate: p.Top.Certificate.Rating,
+		Status:      p.Top.ProductionStatus.CurrentProductionStage.Text,
+		Plot:        p.Top.Plot.PlotText.PlainText,
+		Trailer:     p.Short.Trailer.EmbedURL,
 	}
 
-	if isSeries && t.Main.Episodes != nil {
-		seasonCount := len(t.Main.Episodes.Seasons)
-		episodeCount := t.Main.Episodes.TotalEpisodes.Total
-		if seasonCount > 0 && episodeCount > 0 {
-			sb.WriteString(fmt.Sprintf("<b>%d Seasons (%d Episodes)</b>\n", seasonCount, episodeCount))
-		}
+	if len(p.Main.Akas.Edges) > 0 {
+		t.AKA = p.Main.Akas.Edges[0].Node.Text
 	}
 
-	if t.Top.Runtime.DisplayableProperty.Value.PlainText != "" {
-		dur := t.Top.Runtime.DisplayableProperty.Value.PlainText
-		if isSeries {
-			dur += "/Episode"
-		}
-		sb.WriteString(fmt.Sprintf("<i>Duration: </i>%s\n", dur))
+	if t.IsSeries && p.Main.Episodes != nil {
+		t.Seasons = len(p.Main.Episodes.Seasons)
+		t.Episodes = p.Main.Episodes.TotalEpisodes.Total
 	}
 
-	rd := t.Top.ReleaseDate
-	if rd.Year > 0 {
-		date := fmt.Sprintf("%d %s %d", rd.Day, monthMap[rd.Month], rd.Year)
-		if rd.Country.Text != "" {
-			date += " (" + rd.Country.Text + ")"
-			flag := getFlag(rd.Country.Text)
-			if flag != "" {
-				date += " " + flag
-			}
-		}
-		if isSeries {
-			date += " - For First Episode"
-		}
-		sb.WriteString(fmt.Sprintf("<i>Release Date: </i>%s\n", date))
+	if rd := p.Top.ReleaseDate; rd.Year > 0 {
+		t.ReleaseDate = time.Date(rd.Year, time.Month(max(rd.Month, 1)), max(rd.Day, 1), 0, 0, 0, 0, time.UTC)
+		t.ReleaseCountry = rd.Country.Text
 	}
 
-	rating := ""
-	if t.Top.RatingsSummary.AggregateRating > 0 {
-		rating = fmt.Sprintf("<i>Rating ⭐️ </i><b>%.1f / 10</b> (from %d votes)", t.Top.RatingsSummary.AggregateRating, t.Top.RatingsSummary.VoteCount)
-	}
-	if t.Top.Metacritic != nil && t.Top.Metacritic.Metascore.Score > 0 {
-		if rating != "" {
-			rating += " | "
-		}
-		rating += fmt.Sprintf("<b>Ⓜ️ %d/100</b>", t.Top.Metacritic.Metascore.Score)
+	if p.Top.Metacritic != nil {
+		t.Metascore = p.Top.Metacritic.Metascore.Score
 	}
-	if rating != "" {
-		sb.WriteString(rating + "\n")
+
+	genreMap := make(map[string]bool)
+
+	for _, g := range p.Top.Genres.Genres {
+		t.Genres = append(t.Genres, g.Text)
+		genreMap[g.Text] = true
 	}
 
-	sb.WriteString("<blockquote>")
-	if len(t.Top.Genres.Genres) > 0 {
-		var gs []string
-		for _, g := range t.Top.Genres.Genres {
-			emoji := "- "
-			if e, ok := genreEmojiMap[g.Text]; ok {
-				emoji = e + " "
-			}
-			gs = append(gs, fmt.Sprintf("%s#%s", emoji, g.Text))
-			genreMap[g.Text] = true
-		}
-		sb.WriteString(fmt.Sprintf("<i>Genres: </i>%s\n", strings.Join(gs, " ")))
-	}
-	if len(t.Top.Interests.Edges) > 0 {
-		var ts []string
-		for _, tx := range t.Top.Interests.Edges {
-			name := tx.Node.PrimaryText.Text
-			if !genreMap[name] {
-				ts = append(ts, "#"+strings.ReplaceAll(name, " ", "_"))
-			}
-		}
-		if len(ts) > 0 {
-			sb.WriteString(fmt.Sprintf("<i>Themes: </i>%s\n", strings.Join(ts, " ")))
+	for _, tx := range p.Top.Interests.Edges {
+		if name := tx.Node.PrimaryText.Text; !genreMap[name] {
+			t.Themes = append(t.Themes, name)
 		}
 	}
-	var langs, countries []string
-	for _, l := range t.Main.Languages.Languages {
-		langs = append(langs, "#"+l.Text)
-	}
-	for _, c := range t.Main.Countries.Countries {
-		flag := ""
-		if f, ok := countryFlagMap[c.Text]; ok {
-			flag = f + " "
-		}
-		countries = append(countries, fmt.Sprintf("%s#%s", flag, strings.ReplaceAll(c.Text, " ", "_")))
+
+	for _, l := range p.Main.Languages.Languages {
+		t.Languages = append(t.Languages, l.Text)
 	}
-	sb.WriteString(fmt.Sprintf("<i>Language (Country): </i>%s (%s)", strings.Join(langs, " "), strings.Join(countries, " ")))
-	sb.WriteString("</blockquote>\n\n")
 
-	if t.Top.Plot.PlotText.PlainText != "" {
-		sb.WriteString(fmt.Sprintf("<blockquote><b>Story Line: </b><i>%s</i></blockquote>\n\n", t.Top.Plot.PlotText.PlainText))
+	for _, c := range p.Main.Countries.Countries {
+		t.Countries = append(t.Countries, c.Text)
 	}
 
-	if enableAIReview && t.ReviewSummary != nil && t.ReviewSummary.Overall.Medium.Value.PlaidHtml != "" {
-		sb.WriteString(fmt.Sprintf("<blockquote><b>AI Review: </b><i>%s</i></blockquote>\n\n", html.UnescapeString(t.ReviewSummary.Overall.Medium.Value.PlaidHtml)))
+	if p.ReviewSummary != nil {
+		t.AIReview = html.UnescapeString(p.ReviewSummary.Overall.Medium.Value.PlaidHtml)
 	}
 
-	sb.WriteString("<blockquote>")
-	var dirs []string
-	if len(t.Top.Directors) > 0 {
-		for _, d := range t.Top.Directors[0].Credits {
-			dirs = append(dirs, link(d.Name.NameText.Text, d.Name.ID))
+	// Directors, falling back to creators for series.
+	if len(p.Top.Directors) > 0 {
+		for _, d := range p.Top.Directors[0].Credits {
+			t.Directors = append(t.Directors, Person{Name: d.Name.NameText.Text, ID: d.Name.ID})
 		}
 	}
-	if len(dirs) == 0 {
-		for _, g := range t.Top.PrincipalCredits {
-			if strings.Contains(g.Grouping.Text, "Director") {
-				for _, c := range g.Credits {
-					dirs = append(dirs, link(c.Name.NameText.Text, c.Name.ID))
-				}
-			}
+
+	for _, group := range []string{"Director", "Creator"} {
+		if len(t.Directors) > 0 || (group == "Creator" && !t.IsSeries) {
+			break
 		}
-	}
-	if isSeries && len(dirs) == 0 {
-		for _, g := range t.Top.PrincipalCredits {
-			if strings.Contains(g.Grouping.Text, "Creator") {
+
+		for _, g := range p.Top.PrincipalCredits {
+			if strings.Contains(g.Grouping.Text, group) {
 				for _, c := range g.Credits {
-					dirs = append(dirs, link(c.Name.NameText.Text, c.Name.ID))
+					t.Directors = append(t.Directors, Person{Name: c.Name.NameText.Text, ID: c.Name.ID})
 				}
 			}
 		}
 	}
-	if len(dirs) > 0 {
-		sb.WriteString(fmt.Sprintf("<i><b>Directors:</b></i> %s\n", strings.Join(dirs, ", ")))
-	}
 
-	var writers, stars []string
 	isStar := make(map[string]bool)
-	for _, g := range t.Top.PrincipalCredits {
+
+	for _, g := range p.Top.PrincipalCredits {
 		if strings.Contains(g.Grouping.Text, "Writer") {
 			for _, c := range g.Credits {
-				writers = append(writers, link(c.Name.NameText.Text, c.Name.ID))
+				t.Writers = append(t.Writers, Person{Name: c.Name.NameText.Text, ID: c.Name.ID})
 			}
 		}
+
 		if strings.Contains(g.Grouping.Text, "Star") {
 			for _, c := range g.Credits {
-				stars = append(stars, link(c.Name.NameText.Text, c.Name.ID))
+				t.Stars = append(t.Stars, Person{Name: c.Name.NameText.Text, ID: c.Name.ID})
 				isStar[c.Name.NameText.Text] = true
 			}
 		}
 	}
-	if len(writers) > 0 {
-		sb.WriteString(fmt.Sprintf("<i><b>Writers:</b></i> %s\n", strings.Join(writers, ", ")))
-	}
-	if len(stars) > 0 {
-		sb.WriteString(fmt.Sprintf("<i><b>Stars:</b></i> %s\n", strings.Join(stars, ", ")))
-	}
 
-	var topCast []string
-	for _, g := range t.Main.Cast {
-		if g.Grouping.Text == "Top Cast" {
-			for _, c := range g.Credits {
-				if !isStar[c.Name.NameText.Text] {
-					if len(topCast) < topCastLimit {
-						topCast = append(topCast, link(c.Name.NameText.Text, c.Name.ID))
-					} else {
-						break
-					}
-				}
+	for _, g := range p.Main.Cast {
+		group := CreditGroup{Name: g.Grouping.Text}
+
+		for _, c := range g.Credits {
+			person := Person{Name: c.Name.NameText.Text, ID: c.Name.ID}
+			if len(c.Characters) > 0 {
+				person.Role = c.Characters[0].Name
+			}
+
+			group.People = append(group.People, person)
+
+			if g.Grouping.Text == "Top Cast" && !isStar[person.Name] {
+				t.TopCast = append(t.TopCast, person)
 			}
-			break
 		}
-	}
-	if len(topCast) > 0 {
-		sb.WriteString(fmt.Sprintf("<i><b>Top Cast:</b></i> %s", strings.Join(topCast, ", ")))
-	}
-	sb.WriteString("</blockquote>\n\n")
 
-	sb.WriteString("<blockquote>")
-	awardsURL := fmt.Sprintf("%s/title/%s/awards", omdbHomepage, id)
-	awards := ""
-	if t.Main.PrestigiousAwardSummary != nil {
-		awards = fmt.Sprintf("Won %d Oscars. %d wins & %d nominations total.", t.Main.PrestigiousAwardSummary.Wins, t.Main.Wins.Total, t.Main.Nominations.Total)
-	} else if t.Main.Wins.Total > 0 {
-		awards = fmt.Sprintf("%d wins & %d nominations total.", t.Main.Wins.Total, t.Main.Nominations.Total)
-	}
-	if awards != "" {
-		sb.WriteString(fmt.Sprintf("<b>Awards: </b><a href=\"%s\">%s</a>\n", awardsURL, awards))
+		t.Credits = append(t.Credits, group)
 	}
-	sb.WriteString(fmt.Sprintf("<b>OTT Info: </b><a href=\"https://www.justwatch.com/in/search?q=%s\">Find on JustWatch</a></blockquote>", url.QueryEscape(t.Top.TitleText.Text)))
 
-	// Telegraph Generation
-	if enableTelegraph {
-		var nodes []tgNode
-		nodes = append(nodes, tgNode{Tag: "h3", Children: []any{fmt.Sprintf("%s (%d)", t.Top.TitleText.Text, t.Top.ReleaseYear.Year)}})
-		if t.Top.PrimaryImage.URL != "" {
-			nodes = append(nodes, tgNode{Tag: "figure", Children: []any{tgNode{Tag: "img", Attrs: &tgAttrs{Src: t.Top.PrimaryImage.URL}}}})
-		}
-		nodes = append(nodes, makeHeader("Info"))
-		nodes = append(nodes, makeRow("Type", t.Top.TitleType.Text), makeRow("Rating", rating))
-		if t.Top.Certificate.Rating != "" {
-			nodes = append(nodes, makeRow("Content Rating", t.Top.Certificate.Rating))
-		}
-		if len(dirs) > 0 {
-			nodes = append(nodes, makeRow("Directors", strings.Join(dirs, ", ")))
-		}
-		if len(writers) > 0 {
-			nodes = append(nodes, makeRow("Writers", strings.Join(writers, ", ")))
-		}
+	if s := p.Main.PrestigiousAwardSummary; s != nil {
+		t.Awards = fmt.Sprintf("Won %d Oscars. %d wins & %d nominations total.", s.Wins, p.Main.Wins.Total, p.Main.Nominations.Total)
+	} else if p.Main.Wins.Total > 0 {
+		t.Awards = fmt.Sprintf("%d wins & %d nominations total.", p.Main.Wins.Total, p.Main.Nominations.Total)
+	}
 
-		if t.Top.Plot.PlotText.PlainText != "" {
-			nodes = append(nodes, makeHeader("Plot"))
-			nodes = append(nodes, tgNode{Tag: "p", Children: []any{t.Top.Plot.PlotText.PlainText}})
+	if p.Top.FeaturedReviews != nil {
+		for _, r := range p.Top.FeaturedReviews.Edges {
+			txt := strings.ReplaceAll(html.UnescapeString(r.Node.Text.OriginalText.PlainHtml), "<br/>", "\n")
+			t.Reviews = append(t.Reviews, Review{Rating: r.Node.AuthorRating, Text: txt})
 		}
+	}
 
-		if t.ReviewSummary != nil && t.ReviewSummary.Overall.Medium.Value.PlaidHtml != "" {
-			nodes = append(nodes, makeHeader("AI Review Summary"))
-			nodes = append(nodes, tgNode{Tag: "i", Children: []any{html.UnescapeString(t.ReviewSummary.Overall.Medium.Value.PlaidHtml)}})
-		}
+	if p.Main.ProductionBudget != nil {
+		t.Budget = fmt.Sprintf("%d %s", p.Main.ProductionBudget.Budget.Amount, p.Main.ProductionBudget.Budget.Currency)
+	}
 
-		if len(t.Main.Cast) > 0 {
-			nodes = append(nodes, makeHeader("Full Cast & Crew"))
-			for _, g := range t.Main.Cast {
-				nodes = append(nodes, makeSubHeader(g.Grouping.Text))
-				var members []string
-				c := 0
-				for _, cr := range g.Credits {
-					if c > 100 {
-						break
-					}
-					ch := ""
-					if len(cr.Characters) > 0 {
-						ch = " as " + cr.Characters[0].Name
-					}
-					members = append(members, cr.Name.NameText.Text+ch)
-					c++
-				}
-				nodes = append(nodes, tgNode{Tag: "p", Children: []any{strings.Join(members, ", ")}})
-			}
-		}
+	if p.Main.WorldwideGross != nil {
+		t.Revenue = fmt.Sprintf("%d %s", p.Main.WorldwideGross.Total.Amount, p.Main.WorldwideGross.Total.Currency)
+	}
 
-		if t.Top.FeaturedReviews != nil && len(t.Top.FeaturedReviews.Edges) > 0 {
-			nodes = append(nodes, makeHeader("Featured Reviews"))
-			for _, r := range t.Top.FeaturedReviews.Edges {
-				txt := strings.ReplaceAll(html.UnescapeString(r.Node.Text.OriginalText.PlainHtml), "<br/>", "\n")
-				nodes = append(nodes, tgNode{Tag: "blockquote", Children: []any{tgNode{Tag: "b", Children: []any{fmt.Sprintf("%d/10: ", r.Node.AuthorRating)}}, txt}})
-			}
-		}
+	for _, c := range p.Top.Production.Edges {
+		t.Companies = append(t.Companies, c.Node.Company.CompanyText.Text)
+	}
 
-		if t.Main.ProductionBudget != nil {
-			nodes = append(nodes, makeHeader("Box Office"), makeRow("Budget", fmt.Sprintf("%d %s", t.Main.ProductionBudget.Budget.Amount, t.Main.ProductionBudget.Budget.Currency)))
-		}
+	for _, x := range p.Top.Trivia.Edges {
+		txt := strings.ReplaceAll(html.UnescapeString(x.Node.Text.PlaidHtml), "<br/>", "\n")
+		txt = strings.ReplaceAll(txt, "</a>", "")
 
-		if t.Top.TriviaTotal.Total > 0 {
-			nodes = append(nodes, makeHeader("Trivia"))
-			for i, x := range t.Top.Trivia.Edges {
-				if i >= 50 {
-					break
-				}
-				txt := strings.ReplaceAll(html.UnescapeString(x.Node.Text.PlaidHtml), "<br/>", "\n")
-				txt = strings.ReplaceAll(txt, "</a>", "")
-				if idx := strings.Index(txt, ">"); idx != -1 && strings.Contains(txt, "<a") {
-					txt = txt[idx+1:]
-				}
-				nodes = append(nodes, tgNode{Tag: "blockquote", Children: []any{txt}})
-			}
-		}
-		if t.Top.GoofsTotal.Total > 0 {
-			nodes = append(nodes, makeHeader("Goofs"))
-			for i, x := range t.Top.Goofs.Edges {
-				if i >= 50 {
-					break
-				}
-				nodes = append(nodes, tgNode{Tag: "p", Children: []any{"• " + html.UnescapeString(x.Node.Text.PlaidHtml)}})
-			}
+		if idx := strings.Index(txt, ">"); idx != -1 && strings.Contains(txt, "<a") {
+			txt = txt[idx+1:]
 		}
 
-		page := createTelegraphPage(t.Top.TitleText.Text+" Details", nodes)
-		sb.WriteString(fmt.Sprintf("\n\n<a href=\"%s\">Read More...</a>", imdbURL))
-		if page != "" {
-			sb.WriteString(fmt.Sprintf(" | <a href=\"%s\">Full Details</a>", page))
-		}
-	} else {
-		sb.WriteString(fmt.Sprintf("\n\n<a href=\"%s\">Read More...</a>", imdbURL))
+		t.Trivia = append(t.Trivia, txt)
 	}
 
-	trailer := t.Short.Trailer.EmbedURL
-	if trailer == "" {
-		trailer = fmt.Sprintf("https://www.youtube.com/results?search_query=%s", url.QueryEscape(t.Top.TitleText.Text+" trailer"))
+	for _, x := range p.Top.Goofs.Edges {
+		t.Goofs = append(t.Goofs, html.UnescapeString(x.Node.Text.PlaidHtml))
 	}
-	sb.WriteString(fmt.Sprintf(" | <a href=\"%s\">Trailer</a>", trailer))
 
-	poster := t.Top.PrimaryImage.URL
-	dl := ""
-	if poster != "" && poster != notAvailable {
+	if poster := p.Top.PrimaryImage.URL; poster != "" && poster != notAvailable {
 		if strings.Contains(poster, "._V1_") {
 			base := strings.Split(poster, "._V1_")[0]
-			poster = base + "._V1_FMjpg_UX2000_.jpg"
-			dl = base + "._V1_FMjpg_UX3000_.jpg"
+			t.Poster = base + "._V1_FMjpg_UX2000_.jpg"
+			t.PosterDownload = base + "._V1_FMjpg_UX3000_.jpg"
 		} else {
-			dl = poster
+			t.Poster = poster
+			t.PosterDownload = poster
 		}
-		sb.WriteString(fmt.Sprintf(" | <a href=\"%s\">Download Poster</a>", dl))
-	} else {
-		poster = omdbBanner
 	}
 
-	return poster, sb.String(), buttons, nil
+	return t
 }
 
-func getDetailsFallback(id string) (string, string, [][]gotgbot.InlineKeyboardButton, error) {
-	var buttons [][]gotgbot.InlineKeyboardButton
+// fallbackTypeName returns the display name of a title type from imdbapi.dev for ex: tvSeries becomes TV Series.
+func fallbackTypeName(s string) string {
+	switch s {
+	case "tvSeries":
+		return "TV Series"
+	case "tvMiniSeries":
+		return "TV Mini Series"
+	case "tvEpisode":
+		return "TV Episode"
+	default:
+		return strings.Title(s) //nolint:staticcheck // titles are ascii.
+	}
+}
 
+func getDetailsFallback(ctx context.Context, id string) (*Title, error) {
 	// 1. ImdbApiDev (Base)
-	resp, err := http.Get(fmt.Sprintf("%s/titles/%s", apiFallback, id))
+	resp, err := upstream.Get(ctx, fmt.Sprintf("%s/titles/%s", apiFallback, id))
 	if err != nil {
-		return "", "", buttons, err
+		return nil, err
 	}
 	defer resp.Body.Close()
+
 	body, _ := io.ReadAll(resp.Body)
 
-	type fbDetail struct {
-		PrimaryTitle    string                                                      `json:"primaryTitle"`
-		StartYear       int                                                         `json:"startYear"`
-		EndYear         int                                                         `json:"endYear"`
-		Plot            string                                                      `json:"plot"`
-		Type            string                                                      `json:"type"`
-		PrimaryImage    *struct{ URL string `json:"url"` }                          `json:"primaryImage"`
-		Rating          *struct{ AggregateRating float64 `json:"aggregateRating"`
-		                        VoteCount int `json:"voteCount"` } `json:"rating"`
-		Genres          []string                                                    `json:"genres"`
-		RuntimeSeconds  int                                                         `json:"runtimeSeconds"`
-		ReleaseDate     *string                                                     `json:"releaseDate"`
-		Metacritic      *struct{ Score int `json:"score"` }                         `json:"metacritic"`
-		Directors       []struct{ ID string `json:"id"`; Name string `json:"displayName"` } `json:"directors"`
-		Writers         []struct{ ID string `json:"id"`; Name string `json:"displayName"` } `json:"writers"`
-		Stars           []struct{ ID string `json:"id"`; Name string `json:"displayName"` } `json:"stars"`
-		Interests       []struct{ Name string `json:"name"` }                               `json:"interests"`
-		OriginCountries []struct{ Name string `json:"name"` }                               `json:"originCountries"`
-		SpokenLanguages []struct{ Name string `json:"name"` }                               `json:"spokenLanguages"`
-	}
-	var t fbDetail
-	if json.Unmarshal(body, &t) != nil {
-		return "", "", buttons, errors.New("Fallback parse error")
+	var f fallbackDetailData
+	if json.Unmarshal(body, &f) != nil || f.PrimaryTitle == "" {
+		return nil, errors.New("Fallback parse error")
 	}
 
 	// 2. Parallel Fetch: AKAs, OMDb, TMDB
-	var credits fallbackCredits
-	var akas fallbackAKA
-	var omdbFill omdbFillData
-	var tmdbDetails tmdbDetailRes
-	var tmdbFound bool
-	var wg sync.WaitGroup
+	var (
+		credits     fallbackCredits
+		akas        fallbackAKA
+		omdbFill    omdbFillData
+		tmdbDetails tmdbDetailRes
+		tmdbID      int
+		mediaType   string
+		tmdbFound   bool
+		wg          sync.WaitGroup
+	)
 
 	wg.Add(3)
 
 	// A. AKAs & Credits (from imdbapi.dev)
 	go func() {
 		defer wg.Done()
-		if r, e := http.Get(fmt.Sprintf("%s/titles/%s/credits", apiFallback, id)); e == nil {
+
+		if r, e := upstream.Get(ctx, fmt.Sprintf("%s/titles/%s/credits", apiFallback, id)); e == nil {
 			defer r.Body.Close()
+
 			b, _ := io.ReadAll(r.Body)
-			json.Unmarshal(b, &credits)
+			json.Unmarshal(b, &credits) //nolint:errcheck // missing credits are skipped.
 		}
-		if r, e := http.Get(fmt.Sprintf("%s/titles/%s/akas", apiFallback, id)); e == nil {
+
+		if r, e := upstream.Get(ctx, fmt.Sprintf("%s/titles/%s/akas", apiFallback, id)); e == nil {
 			defer r.Body.Close()
+
 			b, _ := io.ReadAll(r.Body)
-			json.Unmarshal(b, &akas)
+			json.Unmarshal(b, &akas) //nolint:errcheck // missing akas are skipped.
 		}
 	}()
+
 	// B. OMDb
 	go func() {
 		defer wg.Done()
-		if r, e := http.Get(fmt.Sprintf("https://www.omdbapi.com/?i=%s&apikey=%s", id, OmdbApiKey)); e == nil {
+
+		if r, e := upstream.Get(ctx, fmt.Sprintf("https://www.omdbapi.com/?i=%s&apikey=%s", id, OmdbApiKey)); e == nil {
 			defer r.Body.Close()
-			json.NewDecoder(r.Body).Decode(&omdbFill)
+			json.NewDecoder(r.Body).Decode(&omdbFill) //nolint:errcheck // omdb data is optional.
 		}
 	}()
+
 	// C. TMDB (Find -> Details)
 	go func() {
 		defer wg.Done()
+
 		findURL := fmt.Sprintf("%s/find/%s?api_key=%s&external_source=imdb_id", apiTMDB, id, tmdbKey)
-		if r, e := http.Get(findURL); e == nil {
-			defer r.Body.Close()
-			b, _ := io.ReadAll(r.Body)
-			var findRes tmdbFindRes
-			if json.Unmarshal(b, &findRes) == nil {
-				var tmdbID int
-				var mediaType string
-				if len(findRes.MovieResults) > 0 {
-					tmdbID = findRes.MovieResults[0].ID
-					mediaType = "movie"
-				} else if len(findRes.TVResults) > 0 {
-					tmdbID = findRes.TVResults[0].ID
-					mediaType = "tv"
-				}
 
-				if tmdbID > 0 {
-					// --- FIX: append_to_response adjusted for series ---
-					appendQuery := "credits,release_dates,content_ratings,alternative_titles"
-					if mediaType == "tv" {
-						appendQuery = "aggregate_credits,content_ratings,alternative_titles"
-					}
-					
-					detailURL := fmt.Sprintf("%s/%s/%d?api_key=%s&append_to_response=%s", apiTMDB, mediaType, tmdbID, tmdbKey, appendQuery)
-					if r2, e2 := http.Get(detailURL); e2 == nil {
-						defer r2.Body.Close()
-						b2, _ := io.ReadAll(r2.Body)
-						if json.Unmarshal(b2, &tmdbDetails) == nil {
-							tmdbFound = true
-						}
-					}
-				}
-			}
+		r, e := upstream.Get(ctx, findURL)
+		if e != nil {
+			return
+		}
+		defer r.Body.Close()
+
+		b, _ := io.ReadAll(r.Body)
+
+		var findRes tmdbFindRes
+		if json.Unmarshal(b, &findRes) != nil {
+			return
+		}
+
+		if len(findRes.MovieResults) > 0 {
+			tmdbID = findRes.MovieResults[0].ID
+			mediaType = "movie"
+		} else if len(findRes.TVResults) > 0 {
+			tmdbID = findRes.TVResults[0].ID
+			mediaType = "tv"
+		}
+
+		if tmdbID == 0 {
+			return
+		}
+
+		// --- FIX: append_to_response adjusted for series ---
+		appendQuery := "credits,release_dates,content_ratings,alternative_titles"
+		if mediaType == "tv" {
+			appendQuery = "aggregate_credits,content_ratings,alternative_titles"
+		}
+
+		detailURL := fmt.Sprintf("%s/%s/%d?api_key=%s&append_to_response=%s", apiTMDB, mediaType, tmdbID, tmdbKey, appendQuery)
+
+		r2, e2 := upstream.Get(ctx, detailURL)
+		if e2 != nil {
+			return
+		}
+		defer r2.Body.Close()
+
+		b2, _ := io.ReadAll(r2.Body)
+		if json.Unmarshal(b2, &tmdbDetails) == nil {
+			tmdbFound = true
 		}
 	}()
+
 	wg.Wait()
 
-	// --- BUILD CAPTION ---
-	var sb strings.Builder
-	isSeries := (t.Type == "tvSeries" || t.Type == "tvMiniSeries")
-	typeStr := strings.Title(t.Type)
-	if t.Type == "tvSeries" {
-		typeStr = "TV Series"
+	src := fallbackSources{Base: f, Credits: credits, AKAs: akas, OMDb: omdbFill}
+	if tmdbFound {
+		src.TMDB, src.TMDBID, src.TMDBType = &tmdbDetails, tmdbID, mediaType
+	}
+
+	return mergeFallback(id, &src), nil
+}
+
+// fallbackSources are the responses of the fallback apis used to build a title.
+type fallbackSources struct {
+	// Base details from imdbapi.dev.
+	Base    fallbackDetailData
+	Credits fallbackCredits
+	AKAs    fallbackAKA
+	OMDb    omdbFillData
+	// Details from tmdb, nil if the title wasn't found there.
+	TMDB     *tmdbDetailRes
+	TMDBID   int
+	TMDBType string
+}
+
+// mergeFallback merges the responses of the fallback apis into a Title, tmdb is preferred where apis overlap.
+//
+//nolint:gocyclo // merges data from multiple apis.
+func mergeFallback(id string, src *fallbackSources) *Title {
+	var (
+		f           = src.Base
+		credits     = src.Credits
+		akas        = src.AKAs
+		omdbFill    = src.OMDb
+		tmdbFound   = src.TMDB != nil
+		tmdbDetails tmdbDetailRes
+	)
+
+	if tmdbFound {
+		tmdbDetails = *src.TMDB
+	}
+
+	t := &Title{
+		ID:        id,
+		Type:      fallbackTypeName(f.Type),
+		IsSeries:  f.Type == "tvSeries" || f.Type == "tvMiniSeries",
+		Name:      f.PrimaryTitle,
+		StartYear: f.StartYear,
+		EndYear:   f.EndYear,
+		Plot:      f.Plot,
+		Genres:    f.Genres,
+	}
+
+	if tmdbFound {
+		t.TmdbID = src.TMDBID
+		t.TmdbType = src.TMDBType
 	}
-	
+
 	// --- FIX: Use TMDB Title if available, else PrimaryTitle ---
-	mainTitle := t.PrimaryTitle
 	if tmdbFound && tmdbDetails.Title != "" {
-		mainTitle = tmdbDetails.Title
+		t.Name = tmdbDetails.Title
 	}
 
-	var yearStr string
-	if isSeries && t.EndYear > 0 {
-		yearStr = fmt.Sprintf("[%d-%d]", t.StartYear, t.EndYear)
-	} else if isSeries {
-		yearStr = fmt.Sprintf("[%d-Present]", t.StartYear)
-	} else {
-		yearStr = fmt.Sprintf("[%d]", t.StartYear)
+	if tmdbFound {
+		t.OriginalName = tmdbDetails.OriginalTitle
+		t.Tagline = tmdbDetails.Tagline
+	}
+
+	for _, c := range f.Countries {
+		t.Countries = append(t.Countries, c.Name)
 	}
-	sb.WriteString(fmt.Sprintf("<i>%s: </i><b>%s %s</b> | <a href=\"%s\">IMDb Link</a>\n", typeStr, mainTitle, yearStr, omdbHomepage+"/title/"+id))
-	
-	// Original Title Logic
-	orgTitle := ""
-	if tmdbFound && tmdbDetails.OriginalTitle != "" {
-		orgTitle = tmdbDetails.OriginalTitle
+
+	for _, l := range f.Languages {
+		t.Languages = append(t.Languages, l.Name)
 	}
-	if orgTitle != "" && orgTitle != mainTitle {
-		sb.WriteString(fmt.Sprintf("<i>(Original Title: %s)</i>\n", orgTitle))
+
+	for _, i := range f.Interests {
+		t.Themes = append(t.Themes, i.Name)
 	}
 
 	// AKA Logic (Smart US/IN Fallback)
-	akaStr := ""
 	if tmdbFound && len(tmdbDetails.AlternativeTitles.Titles) > 0 {
-		isUS := false
-		if len(t.OriginCountries) > 0 {
-			for _, c := range t.OriginCountries {
-				if c.Name == "United States" { isUS = true; break }
-			}
-		}
 		target := "US"
-		if isUS { target = "IN" } // If US movie, prefer India AKA
-		
+		if Contains(t.Countries, "United States") {
+			target = "IN" // If US movie, prefer India AKA
+		}
+
 		// 1. Try Target Region
 		for _, alt := range tmdbDetails.AlternativeTitles.Titles {
-			if alt.Iso == target && alt.Title != mainTitle {
-				akaStr = alt.Title
+			if alt.Iso == target && alt.Title != t.Name {
+				t.AKA = alt.Title
 				break
 			}
 		}
+
 		// 2. Try any different title
-		if akaStr == "" {
+		if t.AKA == "" {
 			for _, alt := range tmdbDetails.AlternativeTitles.Titles {
-				if alt.Title != mainTitle {
-					akaStr = alt.Title
+				if alt.Title != t.Name {
+					t.AKA = alt.Title
 					break
 				}
 			}
 		}
 	}
-	if akaStr == "" && len(akas.Titles) > 0 {
-		akaStr = akas.Titles[0].Title
-	}
-	if akaStr != "" && akaStr != mainTitle {
-		sb.WriteString(fmt.Sprintf("<i>(AKA %s)</i>\n", akaStr))
+
+	if t.AKA == "" && len(akas.Titles) > 0 {
+		t.AKA = akas.Titles[0].Title
 	}
 
 	// --- SEASONS (Prefer TMDB) ---
-	if isSeries {
+	if t.IsSeries {
 		if tmdbFound && tmdbDetails.NumSeasons > 0 {
-			sb.WriteString(fmt.Sprintf("<b>%d Seasons (%d Episodes)</b>\n", tmdbDetails.NumSeasons, tmdbDetails.NumEpisodes))
-		} else if omdbFill.TotalSeasons != "" && omdbFill.TotalSeasons != notAvailable {
-			sb.WriteString(fmt.Sprintf("<b>%s Seasons</b>\n", omdbFill.TotalSeasons))
+			t.Seasons = tmdbDetails.NumSeasons
+			t.Episodes = tmdbDetails.NumEpisodes
+		} else if n, err := strconv.Atoi(omdbFill.TotalSeasons); err == nil {
+			t.Seasons = n
 		}
 	}
 
-	if t.RuntimeSeconds > 0 {
-		h := t.RuntimeSeconds / 3600
-		m := (t.RuntimeSeconds % 3600) / 60
-		dur := fmt.Sprintf("%dh %dm", h, m)
-		if isSeries {
-			dur += "/Episode"
-		}
-		sb.WriteString(fmt.Sprintf("<i>Duration: </i>%s\n", dur))
+	if f.RuntimeSeconds > 0 {
+		t.Runtime = fmt.Sprintf("%dh %dm", f.RuntimeSeconds/3600, (f.RuntimeSeconds%3600)/60)
 	}
 
 	// --- RELEASE DATE ---
-	var dateStr string
-	if tmdbFound && (tmdbDetails.ReleaseDate != "" || tmdbDetails.FirstAirDate != "") {
+	switch {
+	case tmdbFound && (tmdbDetails.ReleaseDate != "" || tmdbDetails.FirstAirDate != ""):
 		raw := tmdbDetails.ReleaseDate
-		if isSeries {
+		if t.IsSeries {
 			raw = tmdbDetails.FirstAirDate
 		}
-		if parsed, err := time.Parse("2006-01-02", raw); err == nil {
-			dateStr = parsed.Format("02 January 2006")
-		}
-	} else if omdbFill.Released != "" && omdbFill.Released != notAvailable {
-		dateStr = omdbFill.Released
-	} else if t.ReleaseDate != nil {
-		if parsed, err := time.Parse("2006-01-02", *t.ReleaseDate); err == nil {
-			dateStr = parsed.Format("02 January 2006")
-		}
-	}
-	if dateStr != "" {
-		flag := ""
-		countryName := ""
-		
-		// Priority 1: TMDB Production Countries (Movies)
-		if tmdbFound && len(tmdbDetails.ProductionCountries) > 0 {
-			countryName = tmdbDetails.ProductionCountries[0].Name
-		}
-		
-		// Priority 2: Fallback Origin Countries
-		if countryName == "" && len(t.OriginCountries) > 0 {
-			countryName = t.OriginCountries[0].Name
-		}
-		
-		// Priority 3: OMDb Country
-		if countryName == "" && omdbFill.Country != "" && omdbFill.Country != notAvailable {
-			countryName = omdbFill.Country
-		}
-		
-		if countryName != "" {
-			flag = getFlag(countryName)
-		}
-		
-		if flag != "" {
-			dateStr += fmt.Sprintf(" (%s)", flag)
-		}
-		
-		if isSeries {
-			dateStr += " - For First Episode"
-		}
-		sb.WriteString(fmt.Sprintf("<i>Release Date: </i>%s\n", dateStr))
-	}
 
-	ratingStr := ""
-	if t.Rating != nil {
-		ratingStr = fmt.Sprintf("<i>Rating ⭐️ </i><b>%.1f / 10</b> (from %d votes)", t.Rating.AggregateRating, t.Rating.VoteCount)
+		t.ReleaseDate, _ = time.Parse("2006-01-02", raw)
+	case omdbFill.Released != "" && omdbFill.Released != notAvailable:
+		t.ReleaseDate, _ = time.Parse("02 Jan 2006", omdbFill.Released)
+	case f.ReleaseDate != nil:
+		t.ReleaseDate, _ = time.Parse("2006-01-02", *f.ReleaseDate)
 	}
-	if t.Metacritic != nil && t.Metacritic.Score > 0 {
-		if ratingStr != "" {
-			ratingStr += " | "
-		}
-		ratingStr += fmt.Sprintf("<b>Ⓜ️ %d/100</b>", t.Metacritic.Score)
+
+	// Priority: TMDB Production Countries, Fallback Origin Countries then OMDb Country.
+	switch {
+	case tmdbFound && len(tmdbDetails.ProductionCountries) > 0:
+		t.ReleaseCountry = tmdbDetails.ProductionCountries[0].Name
+	case len(t.Countries) > 0:
+		t.ReleaseCountry = t.Countries[0]
+	case omdbFill.Country != "" && omdbFill.Country != notAvailable:
+		t.ReleaseCountry = omdbFill.Country
 	}
-	if ratingStr != "" {
-		sb.WriteString(ratingStr + "\n")
+
+	if f.Rating != nil {
+		t.Rating = f.Rating.AggregateRating
+		t.Votes = f.Rating.VoteCount
 	}
 
-	sb.WriteString("<blockquote>")
-	genreEmojiMap := map[string]string{"Action": "💥", "Adventure": "🗺️", "Sci-Fi": "🚀", "Comedy": "🤣", "Drama": "🎭", "Romance": "🌹", "Thriller": "🔪", "Horror": "👻"}
-	countryFlagMap := map[string]string{"United States": "🇺🇸", "USA": "🇺🇸", "United Kingdom": "🇬🇧", "UK": "🇬🇧", "India": "🇮🇳", "France": "🇫🇷", "Japan": "🇯🇵", "Canada": "🇨🇦", "Germany": "🇩🇪"}
+	if f.Metacritic != nil {
+		t.Metascore = f.Metacritic.Score
+	}
 
-	if len(t.Genres) > 0 {
-		var gs []string
-		for _, g := range t.Genres {
-			emoji := "- "
-			if e, ok := genreEmojiMap[g]; ok {
-				emoji = e + " "
+	// Cast & Crew, prefer TMDB.
+	if tmdbFound {
+		if t.IsSeries {
+			for _, c := range tmdbDetails.CreatedBy {
+				t.Directors = append(t.Directors, Person{Name: c.Name, TmdbID: c.ID})
 			}
-			gs = append(gs, fmt.Sprintf("%s#%s", emoji, g))
 		}
-		sb.WriteString(fmt.Sprintf("<i>Genres: </i>%s\n", strings.Join(gs, " ")))
-	}
-	if len(t.Interests) > 0 {
-		var is []string
-		for _, i := range t.Interests {
-			is = append(is, "#"+strings.ReplaceAll(i.Name, " ", "_"))
-		}
-		sb.WriteString(fmt.Sprintf("<i>Themes: </i>%s\n", strings.Join(is, " ")))
-	}
 
-	var langs, countries []string
-	for _, l := range t.SpokenLanguages {
-		langs = append(langs, "#"+l.Name)
-	}
-	for _, c := range t.OriginCountries {
-		flag := ""
-		if f, ok := countryFlagMap[c.Name]; ok {
-			flag = f + " "
-		}
-		countries = append(countries, fmt.Sprintf("%s#%s", flag, strings.ReplaceAll(c.Name, " ", "_")))
-	}
-	if len(langs) > 0 || len(countries) > 0 {
-		sb.WriteString(fmt.Sprintf("<i>Language (Country): </i>%s (%s)", strings.Join(langs, " "), strings.Join(countries, " ")))
-	}
-	sb.WriteString("</blockquote>\n\n")
+		crewGroups := make(map[string]int)
 
-	// --- TAGLINE (TMDB) ---
-	if tmdbFound && tmdbDetails.Tagline != "" {
-		sb.WriteString(fmt.Sprintf("<b>\"%s\"</b>\n\n", tmdbDetails.Tagline))
-	}
+		for _, c := range tmdbDetails.Credits.Crew {
+			person := Person{Name: c.Name, TmdbID: c.ID, Role: c.Job}
 
-	if t.Plot != "" {
-		sb.WriteString(fmt.Sprintf("<blockquote><b>Story Line: </b><i>%s</i></blockquote>\n\n", t.Plot))
-	}
+			switch {
+			case !t.IsSeries && c.Job == "Director":
+				t.Directors = append(t.Directors, person)
+			case (c.Job == "Producer" || (t.IsSeries && c.Job == "Executive Producer")) && len(t.Producers) < 5:
+				t.Producers = append(t.Producers, person)
+			}
 
-	sb.WriteString("<blockquote>")
-	// Cast & Crew
-	var dirs, writers, stars, producers []string
+			if c.Department == "Writing" {
+				t.Writers = append(t.Writers, person)
+			}
 
-	// Prefer TMDB Cast
-	if tmdbFound {
-		if isSeries {
-			for _, c := range tmdbDetails.CreatedBy {
-				dirs = append(dirs, link(c.Name, c.ID))
+			i, ok := crewGroups[c.Department]
+			if !ok {
+				i = len(t.Credits)
+				crewGroups[c.Department] = i
+				t.Credits = append(t.Credits, CreditGroup{Name: c.Department})
 			}
-			// --- FIX: TV Producers (Search in aggregate credits crew) ---
-			for _, c := range tmdbDetails.Credits.Crew {
-				if (c.Job == "Executive Producer" || c.Job == "Producer") && len(producers) < 5 {
-					producers = append(producers, link(c.Name, c.ID))
+
+			t.Credits[i].People = append(t.Credits[i].People, person)
+		}
+
+		// Switch to Aggregate Credits for TV if available
+		var cast []Person
+
+		if t.IsSeries && len(tmdbDetails.AggregateCredits.Cast) > 0 {
+			for _, ac := range tmdbDetails.AggregateCredits.Cast {
+				person := Person{Name: ac.Name, TmdbID: ac.ID}
+				if len(ac.Roles) > 0 {
+					person.Role = ac.Roles[0].Character
 				}
+
+				cast = append(cast, person)
 			}
 		} else {
-			for _, c := range tmdbDetails.Credits.Crew {
-				if c.Job == "Director" {
-					dirs = append(dirs, link(c.Name, c.ID))
-				}
-				if c.Job == "Producer" && len(producers) < 5 {
-					producers = append(producers, link(c.Name, c.ID))
-				}
+			for _, c := range tmdbDetails.Credits.Cast {
+				cast = append(cast, Person{Name: c.Name, TmdbID: c.ID, Role: c.Character})
 			}
 		}
 
-		for _, c := range tmdbDetails.Credits.Crew {
-			if c.Department == "Writing" {
-				writers = append(writers, link(c.Name, c.ID))
-			}
+		if len(cast) > 0 {
+			t.Credits = append([]CreditGroup{{Name: "Cast", People: cast}}, t.Credits...)
 		}
+
 		for i, c := range tmdbDetails.Credits.Cast {
 			if i < 4 {
-				stars = append(stars, link(c.Name, c.ID))
+				t.Stars = append(t.Stars, Person{Name: c.Name, TmdbID: c.ID})
 			}
 		}
-	}
 
-	// Fallback to imdbapi.dev if needed
-	if len(dirs) == 0 {
-		for _, d := range t.Directors {
-			dirs = append(dirs, link(d.Name, d.ID))
-		}
-	}
-	if len(writers) == 0 {
-		for _, w := range t.Writers {
-			writers = append(writers, link(w.Name, w.ID))
-		}
-	}
-	if len(stars) == 0 {
-		for _, s := range t.Stars {
-			stars = append(stars, link(s.Name, s.ID))
+		if len(cast) > 4 {
+			t.TopCast = cast[4:]
 		}
-	}
 
-	if len(dirs) > 0 {
-		sb.WriteString(fmt.Sprintf("<i><b>Directors:</b></i> %s\n", strings.Join(dirs, ", ")))
-	}
-	if len(writers) > 0 {
-		sb.WriteString(fmt.Sprintf("<i><b>Writers:</b></i> %s\n", strings.Join(writers, ", ")))
-	}
-	if len(producers) > 0 {
-		sb.WriteString(fmt.Sprintf("<i><b>Producers:</b></i> %s\n", strings.Join(producers, ", ")))
-	}
-	if len(stars) > 0 {
-		sb.WriteString(fmt.Sprintf("<i><b>Stars:</b></i> %s\n", strings.Join(stars, ", ")))
-	}
+		if tmdbDetails.Budget > 0 {
+			t.Budget = fmt.Sprintf("$%d", tmdbDetails.Budget)
+		}
 
-	// Top Cast
-	var topCast []string
-	if tmdbFound {
-		// Switch to Aggregate Credits for TV if available
-		targetCast := tmdbDetails.Credits.Cast
-		if isSeries && len(tmdbDetails.AggregateCredits.Cast) > 0 {
-			targetCast = nil 
-			for _, ac := range tmdbDetails.AggregateCredits.Cast {
-				charName := ""
-				if len(ac.Roles) > 0 { charName = ac.Roles[0].Character }
-				targetCast = append(targetCast, struct{ID int `json:"id"`; Name string `json:"name"`; Character string `json:"character"`}{ac.ID, ac.Name, charName})
-			}
+		if tmdbDetails.Revenue > 0 {
+			t.Revenue = fmt.Sprintf("$%d", tmdbDetails.Revenue)
 		}
 
-		for i, c := range targetCast {
-			if i >= 4 && i < topCastLimit+4 {
-				topCast = append(topCast, link(c.Name, c.ID))
-			}
+		for _, c := range tmdbDetails.ProductionCompanies {
+			t.Companies = append(t.Companies, c.Name)
 		}
 	} else {
 		for _, c := range credits.Cast {
-			if len(topCast) < topCastLimit {
-				topCast = append(topCast, link(c.Name.DisplayName, c.Name.ID))
-			} else {
-				break
+			person := Person{Name: c.Name.DisplayName, ID: c.Name.ID}
+			if len(c.Characters) > 0 {
+				person.Role = c.Characters[0].Name
 			}
-		}
-	}
-	if len(topCast) > 0 {
-		sb.WriteString(fmt.Sprintf("<i><b>Top Cast:</b></i> %s", strings.Join(topCast, ", ")))
-	}
-	sb.WriteString("</blockquote>\n\n")
 
-	sb.WriteString("<blockquote>")
-	// Awards (OMDb)
-	awardsURL := fmt.Sprintf("%s/title/%s/awards", omdbHomepage, id)
-	if omdbFill.Awards != "" && omdbFill.Awards != notAvailable {
-		// --- FIX: Attached URL to text ---
-		sb.WriteString(fmt.Sprintf("<b>Awards: </b><a href=\"%s\">%s</a>\n", awardsURL, omdbFill.Awards))
-	}
-	sb.WriteString(fmt.Sprintf("<b>OTT Info: </b><a href=\"https://www.justwatch.com/in/search?q=%s\">Find on JustWatch</a></blockquote>", url.QueryEscape(t.PrimaryTitle)))
-
-	// --- FALLBACK TELEGRAPH PAGE (USING TMDB & IMDBAPI DATA) ---
-	if enableTelegraph && tmdbFound {
-		var nodes []tgNode
-		nodes = append(nodes, tgNode{Tag: "h3", Children: []any{fmt.Sprintf("%s (%d)", t.PrimaryTitle, t.StartYear)}})
-		
-		posterPath := t.PrimaryImage.URL
-		if tmdbDetails.PosterPath != "" {
-			posterPath = "https://image.tmdb.org/t/p/original" + tmdbDetails.PosterPath
-		}
-		if posterPath != "" {
-			nodes = append(nodes, tgNode{Tag: "figure", Children: []any{tgNode{Tag: "img", Attrs: &tgAttrs{Src: posterPath}}}})
+			t.TopCast = append(t.TopCast, person)
 		}
 
-		nodes = append(nodes, makeHeader("Info"))
-		nodes = append(nodes, makeRow("Type", t.Type))
-		nodes = append(nodes, makeRow("Plot", t.Plot))
-		if tmdbDetails.Tagline != "" {
-			nodes = append(nodes, makeRow("Tagline", tmdbDetails.Tagline))
-		}
-		if tmdbDetails.Budget > 0 {
-			nodes = append(nodes, makeRow("Budget", fmt.Sprintf("$%d", tmdbDetails.Budget)))
-		}
-		if tmdbDetails.Revenue > 0 {
-			nodes = append(nodes, makeRow("Revenue", fmt.Sprintf("$%d", tmdbDetails.Revenue)))
+		if len(t.TopCast) > 0 {
+			t.Credits = append(t.Credits, CreditGroup{Name: "Cast", People: t.TopCast})
 		}
+	}
 
-		// Full Cast for Telegraph
-		targetCast := tmdbDetails.Credits.Cast
-		if isSeries && len(tmdbDetails.AggregateCredits.Cast) > 0 {
-			targetCast = nil 
-			for _, ac := range tmdbDetails.AggregateCredits.Cast {
-				charName := ""
-				if len(ac.Roles) > 0 { charName = ac.Roles[0].Character }
-				targetCast = append(targetCast, struct{ID int `json:"id"`; Name string `json:"name"`; Character string `json:"character"`}{ac.ID, ac.Name, charName})
-			}
+	// Fallback to imdbapi.dev if needed
+	if len(t.Directors) == 0 {
+		for _, d := range f.Directors {
+			t.Directors = append(t.Directors, Person{Name: d.Name, ID: d.ID})
 		}
+	}
 
-		if len(targetCast) > 0 {
-			nodes = append(nodes, makeHeader("Full Cast"))
-			var castList []string
-			for _, c := range targetCast {
-				role := ""
-				if c.Character != "" {
-					role = " as " + c.Character
-				}
-				castList = append(castList, c.Name+role)
-			}
-			nodes = append(nodes, tgNode{Tag: "p", Children: []any{strings.Join(castList, ", ")}})
-		}
-		
-		if len(tmdbDetails.ProductionCompanies) > 0 {
-			nodes = append(nodes, makeHeader("Production Companies"))
-			var comps []string
-			for _, c := range tmdbDetails.ProductionCompanies {
-				comps = append(comps, c.Name)
-			}
-			nodes = append(nodes, tgNode{Tag: "p", Children: []any{strings.Join(comps, ", ")}})
+	if len(t.Writers) == 0 {
+		for _, w := range f.Writers {
+			t.Writers = append(t.Writers, Person{Name: w.Name, ID: w.ID})
 		}
+	}
 
-		page := createTelegraphPage(t.PrimaryTitle+" Details", nodes)
-		sb.WriteString(fmt.Sprintf("\n\n<a href=\"%s\">Read More...</a>", omdbHomepage+"/title/"+id))
-		if page != "" {
-			sb.WriteString(fmt.Sprintf(" | <a href=\"%s\">Full Details</a>", page))
+	if len(t.Stars) == 0 {
+		for _, s := range f.Stars {
+			t.Stars = append(t.Stars, Person{Name: s.Name, ID: s.ID})
 		}
-	} else {
-		sb.WriteString(fmt.Sprintf("\n\n<a href=\"%s\">Read More...</a>", omdbHomepage+"/title/"+id))
 	}
 
-	sb.WriteString(fmt.Sprintf(" | <a href=\"%s\">Trailer</a>", fmt.Sprintf("https://www.youtube.com/results?search_query=%s", url.QueryEscape(t.PrimaryTitle+" trailer"))))
+	// Awards (OMDb)
+	if omdbFill.Awards != "" && omdbFill.Awards != notAvailable {
+		t.Awards = omdbFill.Awards
+	}
 
-	poster := omdbBanner
 	if tmdbFound && tmdbDetails.PosterPath != "" {
-		poster = "https://image.tmdb.org/t/p/original" + tmdbDetails.PosterPath
-	} else if t.PrimaryImage != nil {
-		poster = t.PrimaryImage.URL
+		t.Poster = "https://image.tmdb.org/t/p/original" + tmdbDetails.PosterPath
+	} else if f.PrimaryImage != nil {
+		t.Poster = f.PrimaryImage.URL
 	}
-	sb.WriteString(fmt.Sprintf(" | <a href=\"%s\">Download Poster</a>", poster))
 
-	return poster, sb.String(), buttons, nil
-}
+	t.PosterDownload = t.Poster
 
-func getFlag(country string) string {
-    flagMap := map[string]string{
-        "United States": "🇺🇸 US", "USA": "🇺🇸 US", "US": "🇺🇸 US",
-        "United Kingdom": "🇬🇧 UK", "UK": "🇬🇧 UK", "GB": "🇬🇧 UK",
-        "India": "🇮🇳 IN", "IN": "🇮🇳 IN",
-        "France": "🇫🇷 FR", "FR": "🇫🇷 FR",
-        "Japan": "🇯🇵 JP", "JP": "🇯🇵 JP",
-        "Canada": "🇨🇦 CA", "CA": "🇨🇦 CA",
-        "Germany": "🇩🇪 DE", "DE": "🇩🇪 DE",
-        "Australia": "🇦🇺 AU", "AU": "🇦🇺 AU",
-        "Korea": "🇰🇷 KR", "South Korea": "🇰🇷 KR", "KR": "🇰🇷 KR",
-        "China": "🇨🇳 CN", "CN": "🇨🇳 CN",
-        "Russia": "🇷🇺 RU", "RU": "🇷🇺 RU",
-        "Italy": "🇮🇹 IT", "IT": "🇮🇹 IT",
-        "Spain": "🇪🇸 ES", "ES": "🇪🇸 ES",
-        "Brazil": "🇧🇷 BR", "BR": "🇧🇷 BR",
-    }
-    
-    if val, ok := flagMap[country]; ok {
-        return val
-    }
-    for k, v := range flagMap {
-        if strings.Contains(country, k) {
-            return v
-        }
-    }
-    return ""
-}
-
-func link(name string, id any) string {
-	if idStr, ok := id.(string); ok {
-		return fmt.Sprintf("<a href=\"https://imdb.com/name/%s\">%s</a>", idStr, name)
-	}
-	if idInt, ok := id.(int); ok {
-		return fmt.Sprintf("<a href=\"https://www.themoviedb.org/person/%d\">%s</a>", idInt, name)
-	}
-	return name
+	return t
 }
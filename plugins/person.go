@@ -0,0 +1,469 @@
+// (c) Jisin0
+// Search people on tmdb and show their profiles and filmographies.
+
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	searchMethodPerson = "person"
+	tmdbHomepage       = "https://www.themoviedb.org"
+
+	// Number of titles on each page of a filmography.
+	filmographyPageSize = 8
+	// Number of titles shown as known for on a profile.
+	knownForLimit = 5
+	// Maximum length of the biography on a profile.
+	biographyLength = 800
+)
+
+// personIDPattern matches a tmdb person id or an imdb name id.
+var personIDPattern = regexp.MustCompile(`^(nm\d+|\d+)$`)
+
+// People are searched and fetched from tmdb, their ids are tmdb ids.
+var personProvider = registerProvider(&tmdbPersonProvider{info: ProviderInfo{
+	Name:         searchMethodPerson,
+	Label:        "People",
+	Banner:       imdbBanner,
+	SearchButton: "👤 Search People",
+	Commands:     []string{"person"},
+	ExampleID:    "nm0000138",
+	ExampleQuery: "Christopher Nolan",
+	IDPattern:    personIDPattern,
+	People:       true,
+}})
+
+// tmdbPersonResult is a person in tmdb search results.
+type tmdbPersonResult struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	ProfilePath        string `json:"profile_path"`
+	KnownForDepartment string `json:"known_for_department"`
+	KnownFor           []struct {
+		Title string `json:"title"`
+		Name  string `json:"name"`
+	} `json:"known_for"`
+}
+
+// tmdbCredit is a title that a person worked on.
+type tmdbCredit struct {
+	ID           int     `json:"id"`
+	MediaType    string  `json:"media_type"`
+	Title        string  `json:"title"`
+	Name         string  `json:"name"`
+	ReleaseDate  string  `json:"release_date"`
+	FirstAirDate string  `json:"first_air_date"`
+	Character    string  `json:"character"`
+	Job          string  `json:"job"`
+	VoteCount    int     `json:"vote_count"`
+	VoteAverage  float64 `json:"vote_average"`
+}
+
+// tmdbPerson is the full profile of a person.
+type tmdbPerson struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Biography          string `json:"biography"`
+	Birthday           string `json:"birthday"`
+	Deathday           string `json:"deathday"`
+	PlaceOfBirth       string `json:"place_of_birth"`
+	ProfilePath        string `json:"profile_path"`
+	KnownForDepartment string `json:"known_for_department"`
+	ImdbID             string `json:"imdb_id"`
+	CombinedCredits    struct {
+		Cast []tmdbCredit `json:"cast"`
+		Crew []tmdbCredit `json:"crew"`
+	} `json:"combined_credits"`
+}
+
+// filmographyEntry is a title in a person's filmography with all of their roles in it.
+type filmographyEntry struct {
+	TmdbID    int
+	MediaType string
+	Title     string
+	Date      string
+	Roles     []string
+	VoteCount int
+}
+
+// Year returns the year the title was released or an empty string if it's unknown.
+func (e filmographyEntry) Year() string {
+	return dateYear(e.Date)
+}
+
+// tmdbPersonProvider searches people instead of titles.
+type tmdbPersonProvider struct {
+	info ProviderInfo
+}
+
+func (p *tmdbPersonProvider) Info() *ProviderInfo {
+	return &p.info
+}
+
+func (p *tmdbPersonProvider) Search(ctx context.Context, query string, limit int) ([]UniversalSearchResult, error) {
+	return cached(cacheKey("search", searchMethodPerson, strconv.Itoa(limit), query), searchCacheTTL, func() ([]UniversalSearchResult, error) {
+		var res struct {
+			Results []tmdbPersonResult `json:"results"`
+		}
+
+		if err := getTMDBJSON(ctx, "/search/person?include_adult=false&query="+url.QueryEscape(query), &res); err != nil {
+			return nil, err
+		}
+
+		results := make([]UniversalSearchResult, 0, min(limit, len(res.Results)))
+
+		for _, r := range res.Results {
+			if len(results) >= limit {
+				break
+			}
+
+			knownFor := make([]string, 0, len(r.KnownFor))
+			for _, k := range r.KnownFor {
+				knownFor = append(knownFor, k.Title+k.Name)
+			}
+
+			results = append(results, UniversalSearchResult{
+				ID:          strconv.Itoa(r.ID),
+				Title:       r.Name,
+				Poster:      tmdbImage(r.ProfilePath, ""),
+				Type:        r.KnownForDepartment,
+				Description: strings.Join(knownFor, ", "),
+				URL:         fmt.Sprintf("%s/person/%d", tmdbHomepage, r.ID),
+			})
+		}
+
+		return results, nil
+	})
+}
+
+func (p *tmdbPersonProvider) InlineResult(item UniversalSearchResult) gotgbot.InlineQueryResult {
+	description := item.Type
+	if item.Description != "" {
+		description += " | Known For: " + item.Description
+	}
+
+	return gotgbot.InlineQueryResultArticle{
+		Id:           p.info.Name + "_" + item.ID,
+		Title:        item.Title,
+		Description:  description,
+		ThumbnailUrl: item.Poster,
+		InputMessageContent: gotgbot.InputTextMessageContent{
+			MessageText: fmt.Sprintf("<i>Loading profile of %s...</i>", escapeHTML(item.Title)),
+			ParseMode:   gotgbot.ParseModeHTML,
+		},
+		ReplyMarkup: &gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: "Open Profile", CallbackData: fmt.Sprintf("open_%s_%s", p.info.Name, item.ID)}},
+		}},
+	}
+}
+
+func (p *tmdbPersonProvider) GetTitle(ctx context.Context, id string, _ func(string)) (*TitleCard, error) {
+	person, err := getTMDBPerson(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return personCard(person), nil
+}
+
+// tmdbPersonID returns the tmdb id of a person from a tmdb id or an imdb name id.
+func tmdbPersonID(ctx context.Context, id string) (int, error) {
+	if !strings.HasPrefix(id, "nm") {
+		return strconv.Atoi(id)
+	}
+
+	return cached(cacheKey("tmdbperson", id), titleCacheTTL, func() (int, error) {
+		var res struct {
+			PersonResults []struct {
+				ID int `json:"id"`
+			} `json:"person_results"`
+		}
+
+		if err := getTMDBJSON(ctx, fmt.Sprintf("/find/%s?external_source=imdb_id", id), &res); err != nil {
+			return 0, err
+		}
+
+		if len(res.PersonResults) < 1 {
+			return 0, errors.New("tmdb: no person found for " + id)
+		}
+
+		return res.PersonResults[0].ID, nil
+	})
+}
+
+// getTMDBPerson gets the profile and credits of a person by their tmdb or imdb id.
+func getTMDBPerson(ctx context.Context, id string) (*tmdbPerson, error) {
+	tmdbID, err := tmdbPersonID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return cached(cacheKey("person", strconv.Itoa(tmdbID)), titleCacheTTL, func() (*tmdbPerson, error) {
+		var p tmdbPerson
+
+		return &p, getTMDBJSON(ctx, fmt.Sprintf("/person/%d?append_to_response=combined_credits", tmdbID), &p)
+	})
+}
+
+// tmdbToIMDb returns the imdb id of a tmdb movie or series, mediaType is either movie or tv.
+func tmdbToIMDb(ctx context.Context, mediaType string, id int) (string, error) {
+	return cached(cacheKey("tmdbimdb", mediaType, strconv.Itoa(id)), titleCacheTTL, func() (string, error) {
+		var res struct {
+			ImdbID string `json:"imdb_id"`
+		}
+
+		if err := getTMDBJSON(ctx, fmt.Sprintf("/%s/%d/external_ids", mediaType, id), &res); err != nil {
+			return "", err
+		}
+
+		if res.ImdbID == "" {
+			return "", fmt.Errorf("tmdb: no imdb id for %s %d", mediaType, id)
+		}
+
+		return res.ImdbID, nil
+	})
+}
+
+// filmography merges the cast and crew credits of a person with the newest titles first.
+func (p *tmdbPerson) filmography() []filmographyEntry {
+	var (
+		entries []filmographyEntry
+		index   = make(map[string]int)
+	)
+
+	add := func(c tmdbCredit, role string) {
+		key := c.MediaType + strconv.Itoa(c.ID)
+
+		i, ok := index[key]
+		if !ok {
+			i = len(entries)
+			index[key] = i
+
+			entries = append(entries, filmographyEntry{
+				TmdbID:    c.ID,
+				MediaType: c.MediaType,
+				Title:     c.Title + c.Name,
+				Date:      c.ReleaseDate + c.FirstAirDate,
+				VoteCount: c.VoteCount,
+			})
+		}
+
+		if role != "" && !Contains(entries[i].Roles, role) {
+			entries[i].Roles = append(entries[i].Roles, role)
+		}
+	}
+
+	for _, c := range p.CombinedCredits.Cast {
+		add(c, c.Character)
+	}
+
+	for _, c := range p.CombinedCredits.Crew {
+		add(c, c.Job)
+	}
+
+	// Unreleased titles without a date are listed first.
+	sort.SliceStable(entries, func(i, j int) bool {
+		if (entries[i].Date == "") != (entries[j].Date == "") {
+			return entries[i].Date == ""
+		}
+
+		return entries[i].Date > entries[j].Date
+	})
+
+	return entries
+}
+
+// knownFor returns the most popular titles of a person.
+func (p *tmdbPerson) knownFor() []filmographyEntry {
+	entries := p.filmography()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].VoteCount > entries[j].VoteCount
+	})
+
+	return entries[:min(knownForLimit, len(entries))]
+}
+
+func filmographyData(id, page int) string {
+	return fmt.Sprintf("pfm_%d_%d", id, page)
+}
+
+// personCard renders the profile of a person.
+func personCard(p *tmdbPerson) *TitleCard {
+	var cb captionBuilder
+
+	header := fmt.Sprintf("<b>👤 %s</b> | <a href=\"%s/person/%d\">TMDB</a>", escapeHTML(p.Name), tmdbHomepage, p.ID)
+	if p.ImdbID != "" {
+		header += fmt.Sprintf(" | <a href=\"%s/name/%s\">IMDb</a>", imdbHomepage, escapeHTML(p.ImdbID))
+	}
+
+	cb.Add(0, header+"\n")
+
+	if p.KnownForDepartment != "" {
+		cb.Add(1, fmt.Sprintf("<i>Known For: </i>%s\n", escapeHTML(p.KnownForDepartment)))
+	}
+
+	if p.Birthday != "" {
+		born := formatAirDate(p.Birthday)
+		if p.PlaceOfBirth != "" {
+			born += ", " + p.PlaceOfBirth
+		}
+
+		cb.Add(1, fmt.Sprintf("<i>Born: </i>%s\n", escapeHTML(born)))
+	}
+
+	if p.Deathday != "" {
+		cb.Add(1, fmt.Sprintf("<i>Died: </i>%s\n", escapeHTML(formatAirDate(p.Deathday))))
+	}
+
+	cb.Add(0, "\n")
+
+	if p.Biography != "" {
+		cb.Add(2, fmt.Sprintf("<blockquote expandable><b>Biography: </b><i>%s</i></blockquote>\n\n", truncateHTML(escapeHTML(p.Biography), biographyLength)))
+	}
+
+	if known := p.knownFor(); len(known) > 0 {
+		cb.StartQuote()
+		cb.Add(1, "<b>🌟 Known For</b>\n")
+
+		for _, e := range known {
+			cb.Add(1, fmt.Sprintf("• %s\n", filmographyLine(e)))
+		}
+
+		cb.EndQuote()
+	}
+
+	var buttons [][]gotgbot.InlineKeyboardButton
+	if len(p.CombinedCredits.Cast)+len(p.CombinedCredits.Crew) > 0 {
+		buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: "🎬 Filmography", CallbackData: filmographyData(p.ID, 0)}})
+	}
+
+	return &TitleCard{
+		Poster:  tmdbImage(p.ProfilePath, imdbBanner),
+		Caption: cb.Fit(messageLimit - 1),
+		Buttons: buttons,
+	}
+}
+
+// filmographyLine renders a title of a filmography like <b>Inception</b> (2010) as Cobb.
+func filmographyLine(e filmographyEntry) string {
+	line := "<b>" + escapeHTML(e.Title) + "</b>"
+
+	if year := e.Year(); year != "" {
+		line += " (" + year + ")"
+	}
+
+	if len(e.Roles) > 0 {
+		line += " <i>as " + escapeHTML(strings.Join(e.Roles, ", ")) + "</i>"
+	}
+
+	return line
+}
+
+// filmographyCard renders a page of a person's filmography with a button to open each title.
+func filmographyCard(ctx context.Context, p *tmdbPerson, page int) *TitleCard {
+	var (
+		cb      captionBuilder
+		entries = p.filmography()
+		pages   = pageCount(len(entries), filmographyPageSize)
+	)
+
+	page = max(0, min(page, pages-1))
+	entries = entries[page*filmographyPageSize : min((page+1)*filmographyPageSize, len(entries))]
+
+	// Titles are opened with their imdb ids.
+	var (
+		imdbIDs = make([]string, len(entries))
+		wg      sync.WaitGroup
+	)
+
+	for i, e := range entries {
+		wg.Add(1)
+
+		go func(i int, e filmographyEntry) {
+			defer wg.Done()
+
+			imdbIDs[i], _ = tmdbToIMDb(ctx, e.MediaType, e.TmdbID)
+		}(i, e)
+	}
+
+	wg.Wait()
+
+	cb.Add(0, fmt.Sprintf("<b>🎬 Filmography of %s</b>\n\n", escapeHTML(p.Name)))
+
+	var buttons [][]gotgbot.InlineKeyboardButton
+
+	for i, e := range entries {
+		cb.Add(0, fmt.Sprintf("%d. %s\n", page*filmographyPageSize+i+1, filmographyLine(e)))
+
+		if imdbIDs[i] == "" {
+			continue
+		}
+
+		text := e.Title
+		if year := e.Year(); year != "" {
+			text += " (" + year + ")"
+		}
+
+		buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: text, CallbackData: fmt.Sprintf("open_%s_%s", searchMethodIMDb, imdbIDs[i])}})
+	}
+
+	if nav := pageButtons(page, pages, func(n int) string { return filmographyData(p.ID, n) }); len(nav) > 0 {
+		buttons = append(buttons, nav)
+	}
+
+	buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: "⬅️ Back to Profile", CallbackData: fmt.Sprintf("open_%s_%d", searchMethodPerson, p.ID)}})
+
+	return &TitleCard{
+		Poster:  tmdbImage(p.ProfilePath, imdbBanner),
+		Caption: cb.Fit(messageLimit - 1),
+		Buttons: buttons,
+	}
+}
+
+// CbFilmography handles the buttons of a filmography, data is like pfm_<tmdb id>_<page>.
+func CbFilmography(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.Split(update.Data, "_")
+	)
+
+	if len(split) < 3 || !personIDPattern.MatchString(split[1]) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	page, _ := strconv.Atoi(split[2])
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	person, err := getTMDBPerson(reqCtx, split[1])
+	if err != nil {
+		fmt.Printf("cbfilmography: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Fetch The Filmography 🤧\nPlease Try Again Later !", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	if err := editCard(bot, callbackTarget(update), filmographyCard(reqCtx, person, page)); err != nil {
+		fmt.Printf("cbfilmography: %v\n", err)
+	}
+
+	update.Answer(bot, nil)
+
+	return ext.EndGroups
+}
@@ -0,0 +1,126 @@
+// (c) Jisin0
+// Bounded and optionally persistent store of composited justwatch posters.
+
+package plugins
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Maximum number of poster urls kept in the store.
+const posterStoreSize = 500
+
+// jWPosterCache stores the uploaded url of composited posters mapped by justwatch id.
+var jWPosterCache *posterStore
+
+// initPosterStore sets up the poster store from the environment.
+func initPosterStore() {
+	jWPosterCache = newPosterStore(posterStoreSize, PosterCachePath)
+}
+
+// posterStore is a concurrency-safe size bounded store of poster urls that's saved to a json file if a path is set.
+type posterStore struct {
+	mu    sync.Mutex
+	cache *memoryCache
+	path  string
+
+	// Posters being created mapped by id so concurrent requests wait for the same upload.
+	inflight map[string]*posterCall
+}
+
+type posterCall struct {
+	wg  sync.WaitGroup
+	url string
+	err error
+}
+
+// posterEntry is a single saved poster in the json file.
+type posterEntry struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// newPosterStore creates a store holding at most size posters and loads saved posters from path if it's not empty.
+func newPosterStore(size int, path string) *posterStore {
+	s := &posterStore{
+		cache:    newMemoryCache(size),
+		path:     path,
+		inflight: make(map[string]*posterCall),
+	}
+
+	if path == "" {
+		return s
+	}
+
+	var entries []posterEntry
+
+	if err := loadJSONFile(path, &entries); err == nil {
+		for _, e := range entries {
+			s.cache.Set(e.ID, []byte(e.URL), 0)
+		}
+	}
+
+	return s
+}
+
+// Get returns the saved poster url of a title.
+func (s *posterStore) Get(id string) (string, bool) {
+	v, ok := s.cache.Get(id)
+	return string(v), ok
+}
+
+// Set saves the poster url of a title and writes the store to disk.
+func (s *posterStore) Set(id, url string) {
+	s.cache.Set(id, []byte(url), 0)
+
+	if s.path == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]posterEntry, 0, s.cache.Len())
+	s.cache.Range(func(key string, value []byte) {
+		entries = append(entries, posterEntry{ID: key, URL: string(value)})
+	})
+
+	if err := saveJSONFile(s.path, entries); err != nil {
+		fmt.Printf("posterstore: %v\n", err)
+	}
+}
+
+// GetOrCreate returns the saved poster of a title or calls create only once for concurrent callers and saves the result.
+func (s *posterStore) GetOrCreate(id string, create func() (string, error)) (string, error) {
+	if url, ok := s.Get(id); ok {
+		return url, nil
+	}
+
+	s.mu.Lock()
+
+	if c, ok := s.inflight[id]; ok {
+		s.mu.Unlock()
+		c.wg.Wait()
+
+		return c.url, c.err
+	}
+
+	c := &posterCall{}
+	c.wg.Add(1)
+	s.inflight[id] = c
+	s.mu.Unlock()
+
+	c.url, c.err = create()
+	if c.err == nil {
+		s.Set(id, c.url)
+	}
+
+	s.mu.Lock()
+	delete(s.inflight, id)
+	s.mu.Unlock()
+
+	c.wg.Done()
+
+	return c.url, c.err
+}
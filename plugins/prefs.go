@@ -0,0 +1,353 @@
+// (c) Jisin0
+// Per-user preferences and the /settings menu.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// Caption verbosity levels.
+const (
+	verbosityFull    = "full"
+	verbosityCompact = "compact"
+)
+
+// Default language of titles, plots and justwatch results.
+const defaultLanguage = "en"
+
+// UserPrefs are the settings chosen by a user, empty fields use the defaults.
+type UserPrefs struct {
+	// Default inline search method.
+	Method string `json:"method,omitempty"`
+	// Country code used for justwatch offers.
+	Country string `json:"country,omitempty"`
+	// Language code of title names, plots and taglines from justwatch and tmdb, other caption text is in english.
+	Language string `json:"language,omitempty"`
+	// Show posters without blurring them as spoilers.
+	NoSpoilers bool `json:"no_spoilers,omitempty"`
+	// Caption verbosity either full or compact.
+	Verbosity string `json:"verbosity,omitempty"`
+	// Don't send alerts when titles on the watchlist start streaming.
+	NoAlerts bool `json:"no_alerts,omitempty"`
+}
+
+var (
+	// Countries that can be picked from the settings menu.
+	settingsCountries = []string{"US", "GB", "IN", "CA", "AU", "DE", "FR", "ES", "IT", "BR", "MX", "JP", "KR", "NL"}
+	// Languages that can be picked from the settings menu.
+	settingsLanguages = []string{"en", "es", "fr", "de", "it", "pt", "hi", "ja", "ko", "ar"}
+)
+
+func prefsKey(userID int64) string {
+	return fmt.Sprintf("prefs:%d", userID)
+}
+
+// getUserPrefs returns the preferences of a user with defaults filled in.
+func getUserPrefs(userID int64) UserPrefs {
+	var p UserPrefs
+
+	if userID != 0 {
+		loadRecord(prefsKey(userID), &p)
+	}
+
+	if !isDefaultMethodChoice(p.Method) {
+		p.Method = DefaultMethod
+	}
+
+	if p.Country == "" {
+		p.Country = jWCountryCode
+	}
+
+	if p.Language == "" {
+		p.Language = defaultLanguage
+	}
+
+	if p.Verbosity != verbosityCompact {
+		p.Verbosity = verbosityFull
+	}
+
+	return p
+}
+
+// saveUserPrefs saves the preferences of a user.
+func saveUserPrefs(userID int64, p UserPrefs) error {
+	return saveRecord(prefsKey(userID), p)
+}
+
+type prefsKeyType struct{}
+
+// withPrefs returns a context carrying a user's preferences.
+func withPrefs(ctx context.Context, p UserPrefs) context.Context {
+	return context.WithValue(ctx, prefsKeyType{}, p)
+}
+
+// prefsFrom returns the preferences carried by a context or the defaults.
+func prefsFrom(ctx context.Context) UserPrefs {
+	if p, ok := ctx.Value(prefsKeyType{}).(UserPrefs); ok {
+		return p
+	}
+
+	return getUserPrefs(0)
+}
+
+// withCountry returns a context whose preferences use another country.
+func withCountry(ctx context.Context, country string) context.Context {
+	p := prefsFrom(ctx)
+	p.Country = strings.ToUpper(country)
+
+	return withPrefs(ctx, p)
+}
+
+// cutCountryCode splits a leading upper case country code like IN from a query, the code must be followed by more text.
+func cutCountryCode(input string) (country, rest string, ok bool) {
+	country, rest, found := strings.Cut(strings.TrimSpace(input), " ")
+	if !found || !isCountryCode(country) || strings.TrimSpace(rest) == "" {
+		return "", input, false
+	}
+
+	return country, strings.TrimSpace(rest), true
+}
+
+// isCountryCode reports whether s is two upper case letters.
+func isCountryCode(s string) bool {
+	if len(s) != 2 { //nolint:mnd // iso 3166 codes.
+		return false
+	}
+
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// countryLabel returns the flag and code of a two letter country code for ex: 🇺🇸 US.
+func countryLabel(code string) string {
+	code = strings.ToUpper(code)
+	if !isCountryCode(code) {
+		return code
+	}
+
+	var flag strings.Builder
+
+	// Regional indicator symbols start at U+1F1E6 for A.
+	for _, r := range code {
+		flag.WriteRune(0x1F1E6 + r - 'A')
+	}
+
+	return flag.String() + " " + code
+}
+
+const settingsText = `<b>⚙️ Settings</b>
+
+<i>Default Search:</i> <b>%s</b>
+<i>JustWatch Country:</i> <b>%s</b>
+<i>Title Language:</i> <b>%s</b>
+<i>Posters:</i> <b>%s</b>
+<i>Captions:</i> <b>%s</b>
+<i>Availability Alerts:</i> <b>%s</b>
+
+<i>Use the buttons below to change them 👇</i>`
+
+// settingsMenu returns the text and buttons of the main settings menu.
+func settingsMenu(p UserPrefs) (string, [][]gotgbot.InlineKeyboardButton) {
+	method := p.Method
+	if provider, ok := getProvider(method); ok {
+		method = provider.Info().Label
+	}
+
+	posters := "Blurred"
+	if p.NoSpoilers {
+		posters = "Visible"
+	}
+
+	alerts := "On"
+	if p.NoAlerts {
+		alerts = "Off"
+	}
+
+	text := fmt.Sprintf(settingsText, method, countryLabel(p.Country), strings.ToUpper(p.Language), posters, capitalizeFirstLetter(p.Verbosity), alerts)
+
+	buttons := [][]gotgbot.InlineKeyboardButton{
+		{{Text: "🔎 Search Method", CallbackData: "set_method"}, {Text: "🌍 Country", CallbackData: "set_country"}},
+		{{Text: "🗣 Title Language", CallbackData: "set_lang"}, {Text: "🙈 Toggle Blur", CallbackData: "set_spoiler_toggle"}},
+		{{Text: "📝 Toggle Compact Captions", CallbackData: "set_verbosity_toggle"}, {Text: "🔔 Toggle Alerts", CallbackData: "set_alerts_toggle"}},
+		{homeButton},
+	}
+
+	return text, buttons
+}
+
+// settingsChoices returns buttons to pick a value of a setting, the current value is marked.
+func settingsChoices(field string, values []string, labels []string, current string) [][]gotgbot.InlineKeyboardButton {
+	return choiceButtons("set_"+field+"_", values, labels, current, gotgbot.InlineKeyboardButton{Text: "⬅️ Back", CallbackData: "set_menu"})
+}
+
+// choiceButtons returns a grid of buttons with callback data dataPrefix+value followed by a back button, the current value is marked.
+func choiceButtons(dataPrefix string, values []string, labels []string, current string, back gotgbot.InlineKeyboardButton) [][]gotgbot.InlineKeyboardButton {
+	const perRow = 3
+
+	var (
+		buttons [][]gotgbot.InlineKeyboardButton
+		row     []gotgbot.InlineKeyboardButton
+	)
+
+	for i, v := range values {
+		text := labels[i]
+		if strings.EqualFold(v, current) {
+			text = "✅ " + text
+		}
+
+		row = append(row, gotgbot.InlineKeyboardButton{Text: text, CallbackData: dataPrefix + v})
+
+		if len(row) == perRow {
+			buttons = append(buttons, row)
+			row = nil
+		}
+	}
+
+	if len(row) > 0 {
+		buttons = append(buttons, row)
+	}
+
+	return append(buttons, []gotgbot.InlineKeyboardButton{back})
+}
+
+// Settings handles the /settings command.
+func Settings(bot *gotgbot.Bot, ctx *ext.Context) error {
+	update := ctx.EffectiveMessage
+
+	text, buttons := settingsMenu(getUserPrefs(ctx.EffectiveUser.Id))
+
+	_, err := bot.SendMessage(update.Chat.Id, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons}})
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	return ext.EndGroups
+}
+
+// CbSettings handles buttons of the settings menu, data is like set_<field> to list choices or set_<field>_<value> to save one.
+func CbSettings(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.SplitN(update.Data, "_", 3)
+		userID = update.From.Id
+		prefs  = getUserPrefs(userID)
+	)
+
+	if len(split) < 2 {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	var (
+		field   = split[1]
+		value   string
+		text    string
+		buttons [][]gotgbot.InlineKeyboardButton
+		changed bool
+	)
+
+	if len(split) > 2 {
+		value = split[2]
+	}
+
+	switch field {
+	case "method":
+		if value == "" {
+			methods := defaultMethodChoices()
+
+			labels := make([]string, 0, len(methods))
+			for _, m := range methods {
+				labels = append(labels, providerRegistry[m].Info().Label)
+			}
+
+			text = "<i>Pick the method used when you search inline without a prefix 👇</i>"
+			buttons = settingsChoices(field, methods, labels, prefs.Method)
+
+			break
+		}
+
+		if isDefaultMethodChoice(value) {
+			prefs.Method, changed = value, true
+		}
+	case "country":
+		if value == "" {
+			labels := make([]string, 0, len(settingsCountries))
+			for _, c := range settingsCountries {
+				labels = append(labels, countryLabel(c))
+			}
+
+			text = "<i>Pick the country used to find streaming offers on JustWatch 👇</i>"
+			buttons = settingsChoices(field, settingsCountries, labels, prefs.Country)
+
+			break
+		}
+
+		if Contains(settingsCountries, strings.ToUpper(value)) {
+			prefs.Country, changed = strings.ToUpper(value), true
+		}
+	case "lang":
+		if value == "" {
+			labels := make([]string, 0, len(settingsLanguages))
+			for _, l := range settingsLanguages {
+				labels = append(labels, strings.ToUpper(l))
+			}
+
+			text = "<i>Pick the language of title names, plots and taglines 👇\nThey're shown in English when no translation is available, the rest of the caption is always in English.</i>"
+			buttons = settingsChoices(field, settingsLanguages, labels, prefs.Language)
+
+			break
+		}
+
+		if Contains(settingsLanguages, value) {
+			prefs.Language, changed = value, true
+		}
+	case "spoiler":
+		prefs.NoSpoilers, changed = !prefs.NoSpoilers, true
+	case "alerts":
+		prefs.NoAlerts, changed = !prefs.NoAlerts, true
+	case "verbosity":
+		if prefs.Verbosity == verbosityCompact {
+			prefs.Verbosity = verbosityFull
+		} else {
+			prefs.Verbosity = verbosityCompact
+		}
+
+		changed = true
+	}
+
+	if changed {
+		if err := saveUserPrefs(userID, prefs); err != nil {
+			fmt.Printf("cbsettings: %v\n", err)
+			update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Save Your Settings 🤧\nPlease Try Again Later !", ShowAlert: true})
+
+			return ext.EndGroups
+		}
+
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Saved ✅"})
+	} else {
+		// Sub-menus, going back and invalid values only need the loading animation stopped.
+		update.Answer(bot, nil)
+	}
+
+	if text == "" {
+		text, buttons = settingsMenu(prefs)
+	}
+
+	_, _, err := update.Message.EditText(bot, text, &gotgbot.EditMessageTextOpts{ParseMode: gotgbot.ParseModeHTML, ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons}})
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	return ext.EndGroups
+}
@@ -0,0 +1,247 @@
+// (c) Jisin0
+// Provider interface and registry of all supported movie databases.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// Provider is a movie database that can be searched and queried for the full details of a title.
+type Provider interface {
+	// Info returns static details about the provider.
+	Info() *ProviderInfo
+	// Search searches the provider for up to limit titles matching the query.
+	Search(ctx context.Context, query string, limit int) ([]UniversalSearchResult, error)
+	// InlineResult builds the inline query result for a single search result.
+	InlineResult(item UniversalSearchResult) gotgbot.InlineQueryResult
+	// GetTitle fetches a title using it's id and builds the card that should be sent or edited.
+	GetTitle(ctx context.Context, id string, progress func(string)) (*TitleCard, error)
+}
+
+// pagedProvider is a provider whose searches can continue from where the previous page ended.
+type pagedProvider interface {
+	// SearchPage returns up to limit titles after cursor and the cursor of the next page, which is empty after the last page.
+	SearchPage(ctx context.Context, query, cursor string, limit int) (items []UniversalSearchResult, next string, err error)
+}
+
+// searchPage is a page of search results saved in the cache.
+type searchPage struct {
+	Items []UniversalSearchResult `json:"items"`
+	// Cursor of the next page, empty on the last page.
+	Next string `json:"next,omitempty"`
+}
+
+// ProviderInfo holds static details about a provider used to build messages and buttons.
+type ProviderInfo struct {
+	// Name is the unique search method of the provider used in inline queries, result ids and callback data.
+	Name string
+	// Label is the user friendly name of the provider for ex: IMDb.
+	Label string
+	// Banner image used for search results and errors.
+	Banner string
+	// SearchButton is the text of the inline search button, leave empty to hide the button.
+	SearchButton string
+	// Commands that search or get a title from the provider.
+	Commands []string
+	// ExampleID is an example title id shown in command usage.
+	ExampleID string
+	// ExampleQuery is an example search query shown in command usage, defaults to Inception.
+	ExampleQuery string
+	// IDPattern matches a title id of the provider for ex: tt\d+ for imdb.
+	IDPattern *regexp.Regexp
+	// PhotoCards indicates wether messages are sent as photos instead of text with a link preview.
+	PhotoCards bool
+	// Regional indicates wether results depend on the country, commands accept a country code before the query for ex: /jw IN Inception.
+	Regional bool
+	// People indicates wether the provider searches people instead of titles, it can't be the default search method.
+	People bool
+}
+
+// TitleCard is a fully rendered message about a title or a list of titles.
+type TitleCard struct {
+	// Poster image shown as the link preview or as the photo itself.
+	Poster string
+	// Html formatted text or caption of the message.
+	Caption string
+	// Buttons attached to the message.
+	Buttons [][]gotgbot.InlineKeyboardButton
+	// Photo indicates wether the card should be sent as a photo instead of text with a link preview.
+	Photo bool
+	// Spoiler indicates wether the photo should be covered with a spoiler animation.
+	Spoiler bool
+}
+
+var (
+	// All registered providers mapped by their search method.
+	providerRegistry = make(map[string]Provider)
+	// Search methods of all registered providers in the order they were registered.
+	allSearchMethods []string
+)
+
+// registerProvider adds a provider to the registry, it should only be called while initializing package variables.
+func registerProvider(p Provider) Provider {
+	name := p.Info().Name
+
+	if _, ok := providerRegistry[name]; ok {
+		panic("provider already registered: " + name)
+	}
+
+	providerRegistry[name] = p
+	allSearchMethods = append(allSearchMethods, name)
+
+	return p
+}
+
+// getProvider returns the provider registered for a search method.
+func getProvider(method string) (Provider, bool) {
+	p, ok := providerRegistry[strings.ToLower(method)]
+	return p, ok
+}
+
+// defaultMethodChoices returns the search methods that can be used as the default method, people searches are left out.
+func defaultMethodChoices() []string {
+	methods := make([]string, 0, len(allSearchMethods))
+
+	for _, m := range allSearchMethods {
+		if !providerRegistry[m].Info().People {
+			methods = append(methods, m)
+		}
+	}
+
+	return methods
+}
+
+// isDefaultMethodChoice reports whether a search method can be used as the default method.
+func isDefaultMethodChoice(method string) bool {
+	p, ok := getProvider(method)
+	return ok && !p.Info().People
+}
+
+// inlineSearchButtons returns a button to search inline for each visible provider.
+func inlineSearchButtons() [][]gotgbot.InlineKeyboardButton {
+	buttons := make([][]gotgbot.InlineKeyboardButton, 0, len(allSearchMethods))
+
+	for _, method := range allSearchMethods {
+		info := providerRegistry[method].Info()
+		if info.SearchButton == "" {
+			continue
+		}
+
+		// Search method with a whitespace added after for a seamless search.
+		switchQuery := info.Name + " "
+
+		buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: info.SearchButton, SwitchInlineQueryCurrentChat: &switchQuery}})
+	}
+
+	return buttons
+}
+
+// TitleCommand returns a handler for commands that search or get a title from a provider.
+func TitleCommand(method string) func(bot *gotgbot.Bot, ctx *ext.Context) error {
+	return func(bot *gotgbot.Bot, ctx *ext.Context) error {
+		var (
+			update = ctx.EffectiveMessage
+			p, _   = getProvider(method)
+			info   = p.Info()
+		)
+
+		split := strings.SplitN(update.GetText(), " ", 2)
+		if len(split) < 2 {
+			cmd := strings.Split(strings.Fields(update.GetText())[0], "@")[0]
+
+			example := info.ExampleQuery
+			if example == "" {
+				example = "Inception"
+			}
+
+			text := fmt.Sprintf("<i>Please provide a search query or id along with this command !\nFor Example:</i>\n  <code>%s %s</code>\n  <code>%s %s</code>", cmd, example, cmd, info.ExampleID)
+			update.Reply(bot, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML})
+
+			return ext.EndGroups
+		}
+
+		input := split[1]
+
+		var (
+			card *TitleCard
+			err  error
+		)
+
+		reqCtx, cancel := requestContext(ctx, updateBudget)
+		defer cancel()
+
+		if info.Regional {
+			if country, rest, ok := cutCountryCode(input); ok {
+				reqCtx = withCountry(reqCtx, country)
+				input = rest
+			}
+		}
+
+		if id := info.IDPattern.FindString(input); id != "" {
+			card, err = p.GetTitle(reqCtx, id, nil)
+		} else {
+			card, err = searchResultsCard(reqCtx, p, input, ctx.EffectiveUser)
+		}
+
+		if err != nil {
+			card = &TitleCard{
+				Poster:  info.Banner,
+				Caption: fmt.Sprintf("<i>I'm Sorry %s I Couldn't find Anything for <code>%s</code> 🤧</i>", mention(ctx.EffectiveUser), escapeHTML(input)),
+				Buttons: [][]gotgbot.InlineKeyboardButton{{{Text: "Search On Google 🔎", Url: fmt.Sprintf("https://google.com/search?q=%s", url.QueryEscape(input))}}},
+				Photo:   info.PhotoCards,
+			}
+		}
+
+		err = sendCard(bot, ctx.EffectiveChat.Id, card, nil)
+		if err != nil {
+			fmt.Printf("%scommand: %v\n", info.Name, err)
+		}
+
+		return ext.EndGroups
+	}
+}
+
+// searchResultsCard searches a provider and builds a card with a button to open each result.
+func searchResultsCard(reqCtx context.Context, p Provider, query string, user *gotgbot.User) (*TitleCard, error) {
+	info := p.Info()
+
+	results, err := p.Search(reqCtx, query, inlinePageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) < 1 {
+		return nil, fmt.Errorf("no results found for %s", query)
+	}
+
+	// Regional results are opened in the same country they were searched in.
+	var suffix string
+	if info.Regional {
+		suffix = "_" + prefsFrom(reqCtx).Country
+	}
+
+	buttons := make([][]gotgbot.InlineKeyboardButton, 0, len(results))
+	for _, r := range results {
+		text := r.Title
+		if r.Year > 0 {
+			text = fmt.Sprintf("%s (%d)", r.Title, r.Year)
+		}
+
+		buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: text, CallbackData: fmt.Sprintf("open_%s_%s%s", info.Name, r.ID, suffix)}})
+	}
+
+	return &TitleCard{
+		Poster:  info.Banner,
+		Caption: fmt.Sprintf("<i>👋 Hey <tg-spoiler>%s</tg-spoiler> I've got %d Results for you 👇</i>", mention(user), len(results)),
+		Buttons: buttons,
+		Photo:   info.PhotoCards,
+	}, nil
+}
@@ -0,0 +1,258 @@
+// (c) Jisin0
+// Parse filters like y:2021 type:movie rating>7 out of search queries.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Kinds of titles that can be filtered by.
+const (
+	kindMovie   = "movie"
+	kindSeries  = "series"
+	kindEpisode = "episode"
+)
+
+// searchFilters are the filters typed along with a search query.
+type searchFilters struct {
+	// Range of release years, zero if unbounded.
+	YearFrom int
+	YearTo   int
+	// Kind of title either movie, series or episode.
+	Kind string
+	// Minimum rating out of 10.
+	MinRating float64
+	// Code of the original language of titles.
+	Language string
+}
+
+// Narrows reports whether any filter that removes results was set.
+func (f searchFilters) Narrows() bool {
+	return f.YearFrom > 0 || f.Kind != "" || f.MinRating > 0 || f.Language != ""
+}
+
+// parseSearchQuery extracts filters from a query, tokens that aren't valid filters are left in the search text.
+//
+// Supported filters are y:2021 or year:2021, y:2010-2015, type:movie|series|show|episode, rating>7 or rating>=7 and lang:en.
+func parseSearchQuery(query string) (string, searchFilters) {
+	var (
+		f    searchFilters
+		text []string
+	)
+
+	for _, token := range strings.Fields(query) {
+		if !parseFilter(token, &f) {
+			text = append(text, token)
+		}
+	}
+
+	return strings.Join(text, " "), f
+}
+
+// parseFilter sets the filter in a token and reports whether it was valid.
+func parseFilter(token string, f *searchFilters) bool {
+	lower := strings.ToLower(token)
+
+	for _, prefix := range []string{"rating>=", "rating>", "r>=", "r>"} {
+		if v, ok := strings.CutPrefix(lower, prefix); ok {
+			rating, err := strconv.ParseFloat(v, 64)
+			if err != nil || rating < 0 || rating > 10 {
+				return false
+			}
+
+			f.MinRating = rating
+
+			return true
+		}
+	}
+
+	key, value, ok := strings.Cut(lower, ":")
+	if !ok || value == "" {
+		return false
+	}
+
+	switch key {
+	case "y", "year":
+		from, to, isRange := strings.Cut(value, "-")
+
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return false
+		}
+
+		end := start
+
+		if isRange {
+			end, err = strconv.Atoi(to)
+			if err != nil || end < start {
+				return false
+			}
+		}
+
+		f.YearFrom, f.YearTo = start, end
+	case "type", "t":
+		kind := titleKind(value)
+		if kind == "" {
+			return false
+		}
+
+		f.Kind = kind
+	case "lang", "language":
+		if len(value) != 2 { //nolint:mnd // iso 639-1 codes.
+			return false
+		}
+
+		f.Language = value
+	default:
+		return false
+	}
+
+	return true
+}
+
+// titleKind returns the kind of a title from it's type like Movie, TV Series, tvMiniSeries or show.
+func titleKind(typ string) string {
+	t := strings.ReplaceAll(strings.ToLower(typ), " ", "")
+
+	switch {
+	case strings.Contains(t, "episode"):
+		return kindEpisode
+	case strings.Contains(t, "movie"), t == "film", t == "short", t == "video":
+		return kindMovie
+	case strings.Contains(t, "series"), strings.Contains(t, "show"), t == "tv":
+		return kindSeries
+	default:
+		return ""
+	}
+}
+
+// Match reports whether a search result passes the filters, results missing a filtered field don't match.
+func (f searchFilters) Match(item UniversalSearchResult) bool {
+	if f.YearFrom > 0 && (item.Year < f.YearFrom || item.Year > f.YearTo) {
+		return false
+	}
+
+	if f.Kind != "" && titleKind(item.Type) != f.Kind {
+		return false
+	}
+
+	if f.MinRating > 0 && item.Rating < f.MinRating {
+		return false
+	}
+
+	if f.Language != "" && item.Language != f.Language {
+		return false
+	}
+
+	return true
+}
+
+// Filter returns the results that match the filters.
+func (f searchFilters) Filter(items []UniversalSearchResult) []UniversalSearchResult {
+	if !f.Narrows() {
+		return items
+	}
+
+	out := make([]UniversalSearchResult, 0, len(items))
+
+	for _, item := range items {
+		if f.Match(item) {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+// Maximum number of titles fetched at once to find their languages.
+const languageLookups = 8
+
+// languageNames maps language codes to the names used by imdb.
+var languageNames = map[string]string{
+	"en": "English", "es": "Spanish", "fr": "French", "de": "German", "it": "Italian", "pt": "Portuguese",
+	"hi": "Hindi", "ja": "Japanese", "ko": "Korean", "ar": "Arabic", "zh": "Mandarin", "ru": "Russian",
+	"tr": "Turkish", "ta": "Tamil", "te": "Telugu", "ml": "Malayalam", "bn": "Bengali", "mr": "Marathi",
+	"nl": "Dutch", "sv": "Swedish", "da": "Danish", "no": "Norwegian", "pl": "Polish", "th": "Thai",
+	"id": "Indonesian", "fa": "Persian", "he": "Hebrew", "el": "Greek", "cs": "Czech", "hu": "Hungarian",
+}
+
+// languageCode returns the code of a language name like French, empty if it's unknown.
+func languageCode(name string) string {
+	for code, n := range languageNames {
+		if strings.EqualFold(n, name) {
+			return code
+		}
+	}
+
+	return ""
+}
+
+// imdbSourceID returns the imdb id of a search result if it's from imdb or was also found there.
+func imdbSourceID(item UniversalSearchResult) string {
+	if strings.HasPrefix(item.ID, "tt") {
+		return item.ID
+	}
+
+	for _, s := range item.Sources {
+		if strings.HasPrefix(s.ID, "tt") {
+			return s.ID
+		}
+	}
+
+	return ""
+}
+
+// fillLanguages sets the original language of imdb results from the first spoken language of the title,
+// justwatch results already have it.
+func fillLanguages(ctx context.Context, items []UniversalSearchResult) {
+	var (
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, languageLookups)
+	)
+
+	for i := range items {
+		id := imdbSourceID(items[i])
+		if items[i].Language != "" || id == "" {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(item *UniversalSearchResult, id string) {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			t, err := getTitleData(ctx, id, nil)
+			if err != nil {
+				fmt.Printf("filllanguages: %v\n", err)
+				return
+			}
+
+			if len(t.Languages) > 0 {
+				item.Language = languageCode(t.Languages[0])
+			}
+		}(&items[i], id)
+	}
+
+	wg.Wait()
+}
+
+type filtersKey struct{}
+
+// withSearchFilters returns a context carrying the filters so providers can use them upstream.
+func withSearchFilters(ctx context.Context, f searchFilters) context.Context {
+	return context.WithValue(ctx, filtersKey{}, f)
+}
+
+// filtersFrom returns the filters carried by a context.
+func filtersFrom(ctx context.Context) searchFilters {
+	f, _ := ctx.Value(filtersKey{}).(searchFilters)
+	return f
+}
@@ -0,0 +1,137 @@
+// (c) Jisin0
+// Tests for parsing and matching search filters.
+
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		text    string
+		filters searchFilters
+	}{
+		{"dune", "dune", searchFilters{}},
+		{"dune y:2021", "dune", searchFilters{YearFrom: 2021, YearTo: 2021}},
+		{"dune year:2021", "dune", searchFilters{YearFrom: 2021, YearTo: 2021}},
+		{"batman y:2010-2015", "batman", searchFilters{YearFrom: 2010, YearTo: 2015}},
+		{"batman y:2015-2010", "batman y:2015-2010", searchFilters{}},
+		{"batman y:20xx", "batman y:20xx", searchFilters{}},
+		{"dune rating>7", "dune", searchFilters{MinRating: 7}},
+		{"dune rating>=7.5", "dune", searchFilters{MinRating: 7.5}},
+		{"dune r>8", "dune", searchFilters{MinRating: 8}},
+		{"dune rating>11", "dune rating>11", searchFilters{}},
+		{"office type:show", "office", searchFilters{Kind: kindSeries}},
+		{"office TYPE:Movie", "office", searchFilters{Kind: kindMovie}},
+		{"office type:cartoon", "office type:cartoon", searchFilters{}},
+		{"amelie lang:fr", "amelie", searchFilters{Language: "fr"}},
+		{"amelie lang:french", "amelie lang:french", searchFilters{}},
+		{"mission: impossible", "mission: impossible", searchFilters{}},
+		{"re:zero y:2016", "re:zero", searchFilters{YearFrom: 2016, YearTo: 2016}},
+		{"dune y:2021 type:movie rating>7", "dune", searchFilters{YearFrom: 2021, YearTo: 2021, Kind: kindMovie, MinRating: 7}},
+		{"y:2021", "", searchFilters{YearFrom: 2021, YearTo: 2021}},
+	}
+
+	for _, tt := range tests {
+		text, filters := parseSearchQuery(tt.query)
+		if text != tt.text || filters != tt.filters {
+			t.Errorf("parseSearchQuery(%q) = %q, %+v; want %q, %+v", tt.query, text, filters, tt.text, tt.filters)
+		}
+	}
+}
+
+func TestSearchFiltersMatch(t *testing.T) {
+	dune := UniversalSearchResult{ID: "tt1160419", Title: "Dune", Year: 2021, Type: "Movie", Rating: 8, Language: "en"}
+
+	tests := []struct {
+		name    string
+		filters searchFilters
+		item    UniversalSearchResult
+		want    bool
+	}{
+		{"no filters", searchFilters{}, dune, true},
+		{"year", searchFilters{YearFrom: 2021, YearTo: 2021}, dune, true},
+		{"year outside", searchFilters{YearFrom: 2022, YearTo: 2022}, dune, false},
+		{"year range", searchFilters{YearFrom: 2020, YearTo: 2022}, dune, true},
+		{"year missing", searchFilters{YearFrom: 2021, YearTo: 2021}, UniversalSearchResult{Type: "Movie"}, false},
+		{"kind", searchFilters{Kind: kindMovie}, dune, true},
+		{"kind mismatch", searchFilters{Kind: kindSeries}, dune, false},
+		{"kind of tv series", searchFilters{Kind: kindSeries}, UniversalSearchResult{Type: "tvMiniSeries"}, true},
+		{"kind of episode", searchFilters{Kind: kindEpisode}, UniversalSearchResult{Type: "TV Episode"}, true},
+		{"rating", searchFilters{MinRating: 7}, dune, true},
+		{"rating equal", searchFilters{MinRating: 8}, dune, true},
+		{"rating below", searchFilters{MinRating: 8.5}, dune, false},
+		{"rating missing", searchFilters{MinRating: 1}, UniversalSearchResult{Type: "Movie"}, false},
+		{"language", searchFilters{Language: "en"}, dune, true},
+		{"language mismatch", searchFilters{Language: "fr"}, dune, false},
+		{"language missing", searchFilters{Language: "en"}, UniversalSearchResult{Type: "Movie"}, false},
+		{"all", searchFilters{YearFrom: 2021, YearTo: 2021, Kind: kindMovie, MinRating: 7, Language: "en"}, dune, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filters.Match(tt.item); got != tt.want {
+			t.Errorf("%s: Match() = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSearchFiltersNarrows(t *testing.T) {
+	if !(searchFilters{Language: "fr"}).Narrows() {
+		t.Error("a language filter should narrow results")
+	}
+
+	if !(searchFilters{MinRating: 7}).Narrows() {
+		t.Error("a rating filter should narrow results")
+	}
+
+	items := []UniversalSearchResult{{ID: "a", Language: "fr"}, {ID: "b", Language: "en"}, {ID: "c"}}
+	if got := (searchFilters{Language: "fr"}).Filter(items); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("Filter() with a language = %v; want only the french title", got)
+	}
+}
+
+func TestLanguageCode(t *testing.T) {
+	for name, want := range map[string]string{"French": "fr", "english": "en", "Mandarin": "zh", "Klingon": ""} {
+		if got := languageCode(name); got != want {
+			t.Errorf("languageCode(%s) = %q; want %q", name, got, want)
+		}
+	}
+
+	// Every language in the settings can be filtered by.
+	for _, code := range settingsLanguages {
+		if languageNames[code] == "" {
+			t.Errorf("%s has no name", code)
+		}
+	}
+}
+
+func TestFillLanguages(t *testing.T) {
+	defer func(c Cache) { responseCache = c }(responseCache)
+
+	responseCache = newMemoryCache(defaultCacheSize)
+
+	for id, lang := range map[string]string{"tt0211915": "French", "tt0816692": "English"} {
+		data, _ := json.Marshal(&Title{ID: id, Languages: []string{lang, "Spanish"}})
+		responseCache.Set(cacheKey("title", id), data, time.Hour)
+	}
+
+	items := []UniversalSearchResult{
+		{ID: "tt0211915"},
+		{ID: "tm1", Sources: []SearchSource{{Method: searchMethodJW, ID: "tm1"}, {Method: searchMethodIMDb, ID: "tt0816692"}}},
+		{ID: "tm2", Language: "ja"},
+		{ID: "tm3"},
+	}
+
+	fillLanguages(context.Background(), items)
+
+	for i, want := range []string{"fr", "en", "ja", ""} {
+		if items[i].Language != want {
+			t.Errorf("language of %s = %q; want %q", items[i].ID, items[i].Language, want)
+		}
+	}
+}
@@ -0,0 +1,446 @@
+// (c) Jisin0
+// Tests for the redis cache client against a fake in-process server.
+
+package plugins
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadRedisReply(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  any
+		err   error
+	}{
+		{"simple string", "+OK\r\n", "OK", nil},
+		{"error", "-WRONGPASS invalid password\r\n", nil, redisError("WRONGPASS invalid password")},
+		{"integer", ":42\r\n", int64(42), nil},
+		{"bulk string", "$5\r\nhello\r\n", []byte("hello"), nil},
+		{"bulk string with line breaks", "$7\r\nhel\r\nlo\r\n", []byte("hel\r\nlo"), nil},
+		{"empty bulk string", "$0\r\n\r\n", []byte{}, nil},
+		{"nil bulk string", "$-1\r\n", nil, nil},
+		{"array", "*3\r\n$1\r\na\r\n:1\r\n$-1\r\n", []any{[]byte("a"), int64(1), nil}, nil},
+		{"nil array", "*-1\r\n", nil, nil},
+	}
+
+	for _, tt := range tests {
+		got, err := readRedisReply(bufio.NewReader(strings.NewReader(tt.input)))
+
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: error = %v; want %v", tt.name, err, tt.err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+
+		if fmt.Sprintf("%#v", got) != fmt.Sprintf("%#v", tt.want) {
+			t.Errorf("%s: reply = %#v; want %#v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "\r\n", "?what\r\n", "$5\r\nhi\r\n", ":abc\r\n"} {
+		if _, err := readRedisReply(bufio.NewReader(strings.NewReader(bad))); err == nil {
+			t.Errorf("readRedisReply(%q) didn't fail", bad)
+		}
+	}
+}
+
+// fakeRedis is a redis server that keeps values in memory and records the commands it received.
+type fakeRedis struct {
+	t        *testing.T
+	listener net.Listener
+	password string
+	// Time each GET takes to answer.
+	delay time.Duration
+
+	mu       sync.Mutex
+	values   map[string]string
+	commands []string
+	conns    []net.Conn
+	// Number of connections accepted and the most used at the same time.
+	accepted int
+	busy     int
+	maxBusy  int
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("can't listen on loopback: %v", err)
+	}
+
+	s := &fakeRedis{t: t, listener: l, password: password, values: make(map[string]string)}
+
+	go s.serve()
+
+	t.Cleanup(func() {
+		l.Close()
+		s.dropConnections()
+	})
+
+	return s
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.accepted++
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+// dropConnections closes every open connection like a server restart would.
+func (s *fakeRedis) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.conns {
+		c.Close()
+	}
+
+	s.conns = nil
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var (
+		r      = bufio.NewReader(conn)
+		authed = s.password == ""
+	)
+
+	for {
+		req, err := readRedisReply(r)
+		if err != nil {
+			return
+		}
+
+		items, _ := req.([]any)
+		args := make([]string, 0, len(items))
+
+		for _, item := range items {
+			b, _ := item.([]byte)
+			args = append(args, string(b))
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, strings.Join(args, " "))
+		s.mu.Unlock()
+
+		var reply string
+
+		switch {
+		case len(args) < 1:
+			reply = "-ERR empty command\r\n"
+		case args[0] == "AUTH":
+			if args[len(args)-1] == s.password {
+				authed = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "SELECT":
+			reply = "+OK\r\n"
+		case args[0] == "GET":
+			s.mu.Lock()
+			s.busy++
+			s.maxBusy = max(s.maxBusy, s.busy)
+			v, ok := s.values[args[1]]
+			s.mu.Unlock()
+
+			time.Sleep(s.delay)
+
+			s.mu.Lock()
+			s.busy--
+			s.mu.Unlock()
+
+			if ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case args[0] == "SET":
+			s.mu.Lock()
+			s.values[args[1]] = args[2]
+			s.mu.Unlock()
+
+			reply = "+OK\r\n"
+		case args[0] == "DEL":
+			s.mu.Lock()
+			delete(s.values, args[1])
+			s.mu.Unlock()
+
+			reply = ":1\r\n"
+		case args[0] == "SCAN":
+			reply = s.scan(args)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// scan answers SCAN cursor MATCH pattern COUNT n with n sorted keys after cursor, patterns can only end in *.
+func (s *fakeRedis) scan(args []string) string {
+	start, _ := strconv.Atoi(args[1])
+	count, _ := strconv.Atoi(args[5])
+	prefix := strings.ReplaceAll(strings.TrimSuffix(args[3], "*"), `\`, "")
+
+	s.mu.Lock()
+
+	var keys []string
+
+	for k := range s.values {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+
+	s.mu.Unlock()
+
+	sort.Strings(keys)
+
+	end := min(start+count, len(keys))
+
+	next := strconv.Itoa(end)
+	if end == len(keys) {
+		next = "0"
+	}
+
+	reply := fmt.Sprintf("*2\r\n$%d\r\n%s\r\n*%d\r\n", len(next), next, end-start)
+	for _, k := range keys[start:end] {
+		reply += fmt.Sprintf("$%d\r\n%s\r\n", len(k), k)
+	}
+
+	return reply
+}
+
+func (s *fakeRedis) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.commands...)
+}
+
+func (s *fakeRedis) Accepted() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accepted
+}
+
+func TestRedisCacheAuthAndSelect(t *testing.T) {
+	s := newFakeRedis(t, "secret")
+
+	c, err := newRedisCache(fmt.Sprintf("redis://bot:secret@%s/3", s.listener.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := s.Commands(), []string{"AUTH bot secret", "SELECT 3"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("commands = %q; want %q", got, want)
+	}
+
+	c.Set("key", []byte("value"), time.Minute)
+
+	if v, ok := c.Get("key"); !ok || string(v) != "value" {
+		t.Errorf("Get() = %q, %v; want value", v, ok)
+	}
+
+	if cmds := s.Commands(); cmds[2] != "SET key value PX 60000" {
+		t.Errorf("SET command = %q", cmds[2])
+	}
+
+	c.Delete("key")
+
+	if _, ok := c.Get("key"); ok {
+		t.Error("key wasn't deleted")
+	}
+
+	if _, err := newRedisCache(fmt.Sprintf("redis://:wrong@%s", s.listener.Addr())); err == nil {
+		t.Error("connecting with a wrong password didn't fail")
+	}
+
+	if _, err := newRedisCache(fmt.Sprintf("redis://%s/x", s.listener.Addr())); err == nil {
+		t.Error("a bad database number didn't fail")
+	}
+}
+
+func TestRedisCacheNoAuth(t *testing.T) {
+	s := newFakeRedis(t, "")
+
+	if _, err := newRedisCache("redis://" + s.listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is sent for the default database without a password.
+	if cmds := s.Commands(); len(cmds) != 0 {
+		t.Errorf("commands = %q; want none", cmds)
+	}
+}
+
+func TestRedisCacheReconnects(t *testing.T) {
+	s := newFakeRedis(t, "secret")
+
+	c, err := newRedisCache(fmt.Sprintf("redis://:secret@%s/1", s.listener.Addr()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Set("key", []byte("value"), 0)
+
+	// The idle connection breaks, the next command should open a new one and authenticate again.
+	s.dropConnections()
+
+	if v, ok := c.Get("key"); !ok || string(v) != "value" {
+		t.Fatalf("Get() after the connection broke = %q, %v; want value", v, ok)
+	}
+
+	cmds := s.Commands()
+	if got := strings.Join(cmds[len(cmds)-3:], "|"); got != "AUTH secret|SELECT 1|GET key" {
+		t.Errorf("commands after reconnecting = %q", got)
+	}
+
+	// Errors from the server don't close the connection.
+	accepted := s.Accepted()
+
+	if _, err := c.do("BADCMD"); err == nil {
+		t.Error("an unknown command didn't fail")
+	}
+
+	c.Get("key")
+
+	if s.Accepted() != accepted {
+		t.Error("an error reply closed the connection")
+	}
+}
+
+func TestRedisCacheConcurrent(t *testing.T) {
+	s := newFakeRedis(t, "")
+	s.delay = 100 * time.Millisecond
+
+	c, err := newRedisCache("redis://" + s.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Set("key", []byte("value"), 0)
+
+	const n = 8
+
+	var (
+		wg    sync.WaitGroup
+		start = time.Now()
+	)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if v, ok := c.Get("key"); !ok || string(v) != "value" {
+				t.Errorf("Get() = %q, %v; want value", v, ok)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// A single connection would take n times the delay.
+	if elapsed := time.Since(start); elapsed > n*s.delay/2 {
+		t.Errorf("%d concurrent gets took %v, they were serialized", n, elapsed)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxBusy < 2 {
+		t.Errorf("at most %d commands ran at once; want them to run concurrently", s.maxBusy)
+	}
+
+	if s.accepted > redisMaxConns {
+		t.Errorf("%d connections were opened; want at most %d", s.accepted, redisMaxConns)
+	}
+
+	if idle := len(c.idle); idle > redisIdleConns {
+		t.Errorf("%d connections are idle; want at most %d", idle, redisIdleConns)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	s := newFakeRedis(t, "")
+
+	c, err := newRedisCache("redis://" + s.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &redisStore{c: c}
+
+	// Cached responses aren't user data.
+	c.Set("watchlist:9", []byte("cached"), time.Minute)
+
+	for i := 1; i <= redisScanCount+5; i++ {
+		if err := store.Set(fmt.Sprintf("watchlist:%03d", i), []byte(`["tt1"]`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.Set("prefs:1", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := store.Get("watchlist:001"); !ok || string(v) != `["tt1"]` {
+		t.Errorf("Get = %s, %v; want the saved value", v, ok)
+	}
+
+	keys := store.Keys("watchlist:")
+	if len(keys) != redisScanCount+5 || keys[0] != "watchlist:001" || !sort.StringsAreSorted(keys) {
+		t.Errorf("Keys returned %d keys starting with %v; want %d sorted keys", len(keys), keys[:min(1, len(keys))], redisScanCount+5)
+	}
+
+	if err := store.Delete("watchlist:001"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := store.Get("watchlist:001"); ok {
+		t.Error("deleted key is still saved")
+	}
+
+	if got := redisGlobEscape("a*b?[c]\\"); got != `a\*b\?\[c\]\\` {
+		t.Errorf("redisGlobEscape = %s", got)
+	}
+}
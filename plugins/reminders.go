@@ -0,0 +1,415 @@
+// (c) Jisin0
+// Remind users when an upcoming movie is released or a new episode of a series airs.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	// Time after which a reminder without a known release date is checked again.
+	reminderRecheck = 24 * time.Hour
+	// Maximum number of reminders a user can set.
+	maxReminders = 50
+
+	remindersKeyPrefix = "reminders:"
+)
+
+// releasedStatus is the production status of titles that have been released.
+const releasedStatus = "Released"
+
+// reminder is a pending notification about the release of a title.
+type reminder struct {
+	// IMDb id of the title.
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Series bool   `json:"series,omitempty"`
+	// Time at which the title should be checked next, reminders past due are sent on the next run.
+	DueAt time.Time `json:"due_at"`
+	// Last episode the user was reminded of like S01E02, only used by series.
+	LastEpisode string    `json:"last_episode,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// tmdbEpisodeRef is an episode in the schedule of a series.
+type tmdbEpisodeRef struct {
+	Name          string `json:"name"`
+	AirDate       string `json:"air_date"`
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeNumber int    `json:"episode_number"`
+}
+
+// Code returns the season and episode number of the episode like S01E02.
+func (e *tmdbEpisodeRef) Code() string {
+	return fmt.Sprintf("S%02dE%02d", e.SeasonNumber, e.EpisodeNumber)
+}
+
+// Aired returns the day the episode airs on, it's zero if it's unknown.
+func (e *tmdbEpisodeRef) Aired() time.Time {
+	t, _ := time.Parse("2006-01-02", e.AirDate)
+	return t
+}
+
+// tmdbEpisodeSchedule is the status of a series along with the last and next episodes.
+type tmdbEpisodeSchedule struct {
+	// Status of the series for ex: Returning Series, Ended or Canceled.
+	Status           string          `json:"status"`
+	LastEpisodeToAir *tmdbEpisodeRef `json:"last_episode_to_air"`
+	NextEpisodeToAir *tmdbEpisodeRef `json:"next_episode_to_air"`
+}
+
+// Ended reports whether no more episodes of the series will air.
+func (s *tmdbEpisodeSchedule) Ended() bool {
+	return s.NextEpisodeToAir == nil && (s.Status == "Ended" || s.Status == "Canceled")
+}
+
+// remindersMu serializes changes to reminders so the job and button presses don't overwrite each other.
+var remindersMu sync.Mutex
+
+// The job runs often so reminders are sent soon after midnight on release day.
+var remindersJob = registerJob(&backgroundJob{Name: "reminders", Interval: 30 * time.Minute, Run: sendDueReminders})
+
+func remindersKey(userID int64) string {
+	return remindersKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+func getReminders(userID int64) []reminder {
+	var items []reminder
+
+	loadRecord(remindersKey(userID), &items)
+
+	return items
+}
+
+// saveReminders saves the reminders of a user, empty lists are deleted.
+func saveReminders(userID int64, items []reminder) error {
+	if len(items) < 1 {
+		return userStore.Delete(remindersKey(userID))
+	}
+
+	return saveRecord(remindersKey(userID), items)
+}
+
+// today returns the start of the current day in utc.
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// reminderEligible reports whether a title is upcoming, still in production or a running series.
+// Movies that were released are never eligible even if their status wasn't updated.
+func reminderEligible(t *Title) bool {
+	switch {
+	case t.IsSeries:
+		return t.EndYear == 0
+	case !t.ReleaseDate.IsZero():
+		return t.ReleaseDate.After(today())
+	default:
+		return t.Status != "" && t.Status != releasedStatus
+	}
+}
+
+// hasReminder reports whether a user has set a reminder for a title.
+func hasReminder(userID int64, id string) bool {
+	if userID == 0 {
+		return false
+	}
+
+	for _, r := range getReminders(userID) {
+		if r.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reminderButton returns the button that sets or cancels a reminder for a title, set is shown on the label.
+func reminderButton(id string, set bool) gotgbot.InlineKeyboardButton {
+	text := "🔔 Remind Me"
+	if set {
+		text = "🔕 Cancel Reminder"
+	}
+
+	return gotgbot.InlineKeyboardButton{Text: text, CallbackData: "rmd_" + id}
+}
+
+// getEpisodeSchedule fetches the latest episode schedule of a series by it's imdb id, bypassing the cache.
+func getEpisodeSchedule(ctx context.Context, imdbID string) (*tmdbEpisodeSchedule, error) {
+	id, err := tmdbSeriesID(ctx, imdbID)
+	if err != nil {
+		return nil, err
+	}
+
+	var s tmdbEpisodeSchedule
+
+	return &s, getTMDBJSON(ctx, fmt.Sprintf("/tv/%d", id), &s)
+}
+
+// nextCheck returns when a reminder should be checked next from the release date or air date it's waiting for.
+func nextCheck(release time.Time) time.Time {
+	if release.After(today()) {
+		return release
+	}
+
+	return time.Now().Add(reminderRecheck)
+}
+
+// newReminder creates a reminder for a title due on it's release date or the air date of the next episode.
+func newReminder(ctx context.Context, t *Title) reminder {
+	r := reminder{ID: t.ID, Title: t.Name, Series: t.IsSeries, CreatedAt: time.Now()}
+
+	if !t.IsSeries {
+		r.DueAt = nextCheck(t.ReleaseDate)
+		return r
+	}
+
+	var next time.Time
+
+	if s, err := getEpisodeSchedule(ctx, t.ID); err == nil {
+		// Episodes that already aired aren't reminded of.
+		if s.LastEpisodeToAir != nil {
+			r.LastEpisode = s.LastEpisodeToAir.Code()
+		}
+
+		if s.NextEpisodeToAir != nil {
+			next = s.NextEpisodeToAir.Aired()
+		}
+	} else {
+		fmt.Printf("reminders: %v\n", err)
+	}
+
+	// Episodes airing today are checked right away.
+	if next.Equal(today()) {
+		r.DueAt = time.Now()
+	} else {
+		r.DueAt = nextCheck(next)
+	}
+
+	return r
+}
+
+// checkReminder sends a reminder if it's title has been released and returns it with it's next due time.
+// Reminders that shouldn't be checked again are returned with keep set to false.
+func checkReminder(ctx context.Context, bot *gotgbot.Bot, userID int64, r reminder) (next reminder, keep bool, err error) {
+	if r.Series {
+		return checkEpisodeReminder(ctx, bot, userID, r)
+	}
+
+	// Cached details could be a day old and miss a new release date.
+	t, err := refreshTitleData(ctx, r.ID)
+	if err != nil {
+		return r, true, err
+	}
+
+	released := !t.ReleaseDate.IsZero() && !t.ReleaseDate.After(today())
+	if !released && !(t.ReleaseDate.IsZero() && t.Status == releasedStatus) {
+		r.DueAt = nextCheck(t.ReleaseDate)
+		return r, true, nil
+	}
+
+	text := fmt.Sprintf("<b>🔔 %s %s</b> <i>is out now !</i>", escapeHTML(t.Name), t.YearString())
+	if released && t.ReleaseDate.Before(today()) {
+		text = fmt.Sprintf("<b>🔔 %s %s</b> <i>was released on %s.</i>", escapeHTML(t.Name), t.YearString(), t.ReleaseDate.Format("02 January 2006"))
+	}
+
+	if err := sendReminder(bot, userID, r.ID, text); err != nil {
+		r.DueAt = time.Now().Add(reminderRecheck)
+		return r, true, err
+	}
+
+	return r, false, nil
+}
+
+// checkEpisodeReminder reminds the user of the latest episode of a series that aired since the last reminder.
+func checkEpisodeReminder(ctx context.Context, bot *gotgbot.Bot, userID int64, r reminder) (reminder, bool, error) {
+	s, err := getEpisodeSchedule(ctx, r.ID)
+	if err != nil {
+		r.DueAt = time.Now().Add(reminderRecheck)
+		return r, true, err
+	}
+
+	// The next episode is still listed on the day it airs, the last one is checked in case runs were missed.
+	var aired *tmdbEpisodeRef
+
+	for _, e := range []*tmdbEpisodeRef{s.LastEpisodeToAir, s.NextEpisodeToAir} {
+		if e != nil && !e.Aired().IsZero() && !e.Aired().After(today()) {
+			aired = e
+		}
+	}
+
+	var next time.Time
+	if s.NextEpisodeToAir != nil && s.NextEpisodeToAir.Aired().After(today()) {
+		next = s.NextEpisodeToAir.Aired()
+	}
+
+	r.DueAt = nextCheck(next)
+
+	if aired != nil && aired.Code() != r.LastEpisode {
+		text := fmt.Sprintf("<b>🔔 %s %s</b> <i>of</i> <b>%s</b> <i>aired on %s.</i>", aired.Code(), escapeHTML(aired.Name), escapeHTML(r.Title), aired.Aired().Format("02 January 2006"))
+		if aired.Aired().Equal(today()) {
+			text = fmt.Sprintf("<b>🔔 %s %s</b> <i>of</i> <b>%s</b> <i>airs today !</i>", aired.Code(), escapeHTML(aired.Name), escapeHTML(r.Title))
+		}
+
+		if err := sendReminder(bot, userID, r.ID, text); err != nil {
+			return r, true, err
+		}
+
+		r.LastEpisode = aired.Code()
+	}
+
+	return r, !s.Ended(), nil
+}
+
+// sendReminder sends a reminder to a user with a button to open the title.
+func sendReminder(bot *gotgbot.Bot, userID int64, id, text string) error {
+	_, err := bot.SendMessage(userID, text, &gotgbot.SendMessageOpts{
+		ParseMode: gotgbot.ParseModeHTML,
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: "🎬 Open Title", CallbackData: fmt.Sprintf("open_%s_%s", searchMethodIMDb, id)}},
+		}},
+	})
+
+	return err
+}
+
+// sendDueReminders checks every reminder that is due, including those missed while the bot was offline.
+func sendDueReminders(ctx context.Context, bot *gotgbot.Bot) error {
+	for _, key := range userStore.Keys(remindersKeyPrefix) {
+		userID, err := strconv.ParseInt(strings.TrimPrefix(key, remindersKeyPrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if err := sendUserReminders(ctx, bot, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendUserReminders checks the due reminders of a user and saves them after each one so sent reminders aren't repeated.
+func sendUserReminders(ctx context.Context, bot *gotgbot.Bot, userID int64) error {
+	remindersMu.Lock()
+	defer remindersMu.Unlock()
+
+	var (
+		items = getReminders(userID)
+		kept  = make([]reminder, 0, len(items))
+	)
+
+	ctx = withPrefs(ctx, getUserPrefs(userID))
+
+	for i, r := range items {
+		if ctx.Err() != nil {
+			if err := saveReminders(userID, append(kept, items[i:]...)); err != nil {
+				fmt.Printf("reminders: %v\n", err)
+			}
+
+			return ctx.Err()
+		}
+
+		if r.DueAt.After(time.Now()) {
+			kept = append(kept, r)
+			continue
+		}
+
+		next, keep, err := checkReminder(ctx, bot, userID, r)
+		if err != nil {
+			fmt.Printf("reminders: %d: %s: %v\n", userID, r.ID, err)
+		}
+
+		if keep {
+			kept = append(kept, next)
+		}
+	}
+
+	return saveReminders(userID, kept)
+}
+
+// CbReminder handles the remind me button of title cards, data is like rmd_<imdb id>.
+// A reminder is set if the user doesn't have one for the title and cancelled otherwise.
+func CbReminder(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		id     = strings.TrimPrefix(update.Data, "rmd_")
+		userID = update.From.Id
+	)
+
+	if !imdbIDPattern.MatchString(id) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	t, err := getTitleData(reqCtx, id, nil)
+	if err != nil {
+		fmt.Printf("cbreminder: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Find This Title 🤧", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	remindersMu.Lock()
+	defer remindersMu.Unlock()
+
+	items := getReminders(userID)
+
+	for i, r := range items {
+		if r.ID != id {
+			continue
+		}
+
+		if err := saveReminders(userID, append(items[:i], items[i+1:]...)); err != nil {
+			fmt.Printf("cbreminder: %v\n", err)
+			update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Update Your Reminders 🤧\nPlease Try Again Later !", ShowAlert: true})
+
+			return ext.EndGroups
+		}
+
+		relabelButton(bot, update, reminderButton(id, false))
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Reminder Cancelled 🔕"})
+
+		return ext.EndGroups
+	}
+
+	if len(items) >= maxReminders {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: fmt.Sprintf("You Can't Set More Than %d Reminders 🫠", maxReminders), ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	r := newReminder(reqCtx, t)
+
+	if err := saveReminders(userID, append(items, r)); err != nil {
+		fmt.Printf("cbreminder: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Update Your Reminders 🤧\nPlease Try Again Later !", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	relabelButton(bot, update, reminderButton(id, true))
+
+	text := "I'll Remind You When It's Released 🔔"
+
+	switch {
+	case t.IsSeries:
+		text = "I'll Remind You When New Episodes Air 🔔"
+	case t.ReleaseDate.After(today()):
+		text = fmt.Sprintf("I'll Remind You on %s 🔔", t.ReleaseDate.Format("02 Jan 2006"))
+	}
+
+	update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: text + "\nMake Sure You've Started Me in Private !", ShowAlert: true})
+
+	return ext.EndGroups
+}
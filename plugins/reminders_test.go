@@ -0,0 +1,35 @@
+// (c) Jisin0
+// Tests for deciding which titles can have release reminders.
+
+package plugins
+
+import "testing"
+
+func TestReminderEligible(t *testing.T) {
+	var (
+		past   = today().AddDate(0, -1, 0)
+		future = today().AddDate(0, 1, 0)
+	)
+
+	tests := []struct {
+		name  string
+		title Title
+		want  bool
+	}{
+		{"upcoming movie", Title{ReleaseDate: future}, true},
+		{"released movie", Title{ReleaseDate: past, Status: releasedStatus}, false},
+		{"released movie with an old status", Title{ReleaseDate: past, Status: "Post Production"}, false},
+		{"released today", Title{ReleaseDate: today()}, false},
+		{"in production without a date", Title{Status: "In Production"}, true},
+		{"released without a date", Title{Status: releasedStatus}, false},
+		{"unknown", Title{}, false},
+		{"running series", Title{IsSeries: true, StartYear: 2020, ReleaseDate: past}, true},
+		{"ended series", Title{IsSeries: true, StartYear: 2008, EndYear: 2013}, false},
+	}
+
+	for _, tt := range tests {
+		if got := reminderEligible(&tt.title); got != tt.want {
+			t.Errorf("%s: reminderEligible() = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,580 @@
+// (c) Jisin0
+// Render titles into telegram captions, telegraph pages and inline results.
+
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const (
+	// Maximum number of entries shown on telegraph pages for trivia, goofs and each credit group.
+	telegraphListLimit = 50
+	telegraphCastLimit = 100
+)
+
+var genreEmojiMap = map[string]string{
+	"Action": "💥", "Adventure": "🗺️", "Sci-Fi": "🚀", "Comedy": "🤣", "Drama": "🎭", "Romance": "🌹",
+	"Thriller": "🔪", "Horror": "👻", "Fantasy": "✨",
+	"Mystery": "❓", "Crime": "-", "Animation": "-",
+	"War": "-", "History": "-", "Music": "🎶",
+}
+
+var countryFlagMap = map[string]string{
+	"United States": "🇺🇸", "USA": "🇺🇸",
+	"United Kingdom": "🇬🇧", "UK": "🇬🇧",
+	"India": "🇮🇳", "France": "🇫🇷",
+	"Japan": "🇯🇵", "Canada": "🇨🇦",
+	"Germany": "🇩🇪",
+}
+
+// renderTitleCard renders the full card of a title, a telegraph page is created if enabled.
+// Titles may be localized so a page is saved for each language.
+func renderTitleCard(ctx context.Context, t *Title) *TitleCard {
+	var page string
+	if enableTelegraph {
+		lang := prefsFrom(ctx).Language
+		if lang == "" {
+			lang = defaultLanguage
+		}
+
+		page, _ = cached(cacheKey("telegraph", t.ID, lang), telegraphCacheTTL, func() (string, error) {
+			if u := createTelegraphPage(ctx, t.Name+" Details", renderTelegraph(t)); u != "" {
+				return u, nil
+			}
+
+			return "", errors.New("failed to create telegraph page")
+		})
+	}
+
+	poster := t.Poster
+	if poster == "" || poster == notAvailable {
+		poster = omdbBanner
+	}
+
+	return &TitleCard{Poster: poster, Caption: renderCaption(t, page, prefsFrom(ctx)), Buttons: titleButtons(ctx, t)}
+}
+
+// titleButtons returns the buttons attached to the card of a title, labels show the state of the user it's rendered for in private chats.
+func titleButtons(ctx context.Context, t *Title) [][]gotgbot.InlineKeyboardButton {
+	var (
+		browse []gotgbot.InlineKeyboardButton
+		userID = viewerFrom(ctx)
+	)
+
+	if t.IsSeries && t.Seasons > 0 {
+		browse = append(browse, gotgbot.InlineKeyboardButton{Text: "📺 Seasons", CallbackData: seasonsData(t.ID, 0)})
+	}
+
+	if len(t.Credits) > 0 {
+		browse = append(browse, gotgbot.InlineKeyboardButton{Text: "👥 Cast", CallbackData: castData(t.ID, 0, 0)})
+	}
+
+	if reminderEligible(t) {
+		browse = append(browse, reminderButton(t.ID, hasReminder(userID, t.ID)))
+	}
+
+	buttons := [][]gotgbot.InlineKeyboardButton{{trailerButton(searchMethodIMDb, t.ID, ""), similarButton(searchMethodIMDb, t.ID, ""), watchlistButton(searchMethodIMDb, t.ID, inWatchlist(userID, searchMethodIMDb, t.ID))}}
+	if len(browse) > 0 {
+		buttons = append(buttons, browse)
+	}
+
+	return buttons
+}
+
+// renderCaption renders the html caption of a title, telegraphURL is linked if not empty.
+// Compact captions skip secondary fields like themes, tagline and the full cast,
+// long captions drop the least important fields first since they're all on the telegraph page.
+//
+//nolint:gocyclo // it's just a long list of optional fields.
+func renderCaption(t *Title, telegraphURL string, prefs UserPrefs) string {
+	var (
+		cb      captionBuilder
+		compact = prefs.Verbosity == verbosityCompact
+	)
+
+	cb.Add(0, fmt.Sprintf("<i>%s: </i><b>%s %s</b> | <a href=\"%s\">IMDb Link</a>\n", escapeHTML(t.Type), escapeHTML(t.Name), t.YearString(), t.URL()))
+
+	if !compact && t.OriginalName != "" && t.OriginalName != t.Name {
+		cb.Add(3, fmt.Sprintf("<i>(Original Title: %s)</i>\n", escapeHTML(t.OriginalName)))
+	}
+
+	if !compact && t.AKA != "" && t.AKA != t.Name {
+		cb.Add(3, fmt.Sprintf("<i>(AKA: %s)</i>\n", escapeHTML(t.AKA)))
+	}
+
+	if t.IsSeries && t.Seasons > 0 {
+		if t.Episodes > 0 {
+			cb.Add(1, fmt.Sprintf("<b>%d Seasons (%d Episodes)</b>\n", t.Seasons, t.Episodes))
+		} else {
+			cb.Add(1, fmt.Sprintf("<b>%d Seasons</b>\n", t.Seasons))
+		}
+	}
+
+	if dur := titleDuration(t); dur != "" {
+		cb.Add(1, fmt.Sprintf("<i>Duration: </i>%s\n", escapeHTML(dur)))
+	}
+
+	if date := titleReleaseDate(t); date != "" {
+		cb.Add(1, fmt.Sprintf("<i>Release Date: </i>%s\n", escapeHTML(date)))
+	}
+
+	if rating := renderRating(t, true); rating != "" {
+		cb.Add(1, rating+"\n")
+	}
+
+	cb.StartQuote()
+
+	if len(t.Genres) > 0 {
+		gs := make([]string, 0, len(t.Genres))
+
+		for _, g := range t.Genres {
+			emoji := "- "
+			if e, ok := genreEmojiMap[g]; ok {
+				emoji = e + " "
+			}
+
+			gs = append(gs, fmt.Sprintf("%s#%s", emoji, escapeHTML(g)))
+		}
+
+		cb.Add(2, fmt.Sprintf("<i>Genres: </i>%s\n", strings.Join(gs, " ")))
+	}
+
+	if !compact && len(t.Themes) > 0 {
+		ts := make([]string, 0, len(t.Themes))
+		for _, theme := range t.Themes {
+			ts = append(ts, "#"+escapeHTML(strings.ReplaceAll(theme, " ", "_")))
+		}
+
+		cb.Add(4, fmt.Sprintf("<i>Themes: </i>%s\n", strings.Join(ts, " ")))
+	}
+
+	if !compact && (len(t.Languages) > 0 || len(t.Countries) > 0) {
+		langs := make([]string, 0, len(t.Languages))
+		for _, l := range t.Languages {
+			langs = append(langs, "#"+escapeHTML(l))
+		}
+
+		countries := make([]string, 0, len(t.Countries))
+
+		for _, c := range t.Countries {
+			flag := ""
+			if f, ok := countryFlagMap[c]; ok {
+				flag = f + " "
+			}
+
+			countries = append(countries, fmt.Sprintf("%s#%s", flag, escapeHTML(strings.ReplaceAll(c, " ", "_"))))
+		}
+
+		cb.Add(4, fmt.Sprintf("<i>Language (Country): </i>%s (%s)", strings.Join(langs, " "), strings.Join(countries, " ")))
+	}
+
+	cb.EndQuote()
+
+	if !compact && t.Tagline != "" {
+		cb.Add(4, fmt.Sprintf("<b>\"%s\"</b>\n\n", escapeHTML(t.Tagline)))
+	}
+
+	if t.Plot != "" {
+		cb.Add(1, fmt.Sprintf("<blockquote><b>Story Line: </b><i>%s</i></blockquote>\n\n", escapeHTML(t.Plot)))
+	}
+
+	if !compact && enableAIReview && t.AIReview != "" {
+		cb.Add(5, fmt.Sprintf("<blockquote><b>AI Review: </b><i>%s</i></blockquote>\n\n", htmlToText(t.AIReview)))
+	}
+
+	cb.StartQuote()
+
+	if len(t.Directors) > 0 {
+		cb.Add(2, fmt.Sprintf("<i><b>Directors:</b></i> %s\n", strings.Join(personLinks(t.Directors), ", ")))
+	}
+
+	if !compact && len(t.Writers) > 0 {
+		cb.Add(4, fmt.Sprintf("<i><b>Writers:</b></i> %s\n", strings.Join(personLinks(t.Writers), ", ")))
+	}
+
+	if !compact && len(t.Producers) > 0 {
+		cb.Add(4, fmt.Sprintf("<i><b>Producers:</b></i> %s\n", strings.Join(personLinks(t.Producers), ", ")))
+	}
+
+	if len(t.Stars) > 0 {
+		cb.Add(2, fmt.Sprintf("<i><b>Stars:</b></i> %s\n", strings.Join(personLinks(t.Stars), ", ")))
+	}
+
+	if !compact && len(t.TopCast) > 0 {
+		topCast := t.TopCast
+		if len(topCast) > topCastLimit {
+			topCast = topCast[:topCastLimit]
+		}
+
+		cb.Add(6, fmt.Sprintf("<i><b>Top Cast:</b></i> %s", strings.Join(personLinks(topCast), ", ")))
+	}
+
+	cb.EndQuote()
+
+	cb.StartQuote()
+
+	if !compact && t.Awards != "" {
+		cb.Add(4, fmt.Sprintf("<b>Awards: </b><a href=\"%s/awards\">%s</a>\n", t.URL(), escapeHTML(t.Awards)))
+	}
+
+	cb.Add(1, fmt.Sprintf("<b>OTT Info: </b><a href=\"https://www.justwatch.com/%s/search?q=%s\">Find on JustWatch</a>", escapeHTML(strings.ToLower(prefs.Country)), url.QueryEscape(t.Name)))
+
+	cb.EndQuote()
+
+	cb.Add(0, fmt.Sprintf("<a href=\"%s\">Read More...</a>", t.URL()))
+
+	if telegraphURL != "" {
+		cb.Add(0, fmt.Sprintf(" | <a href=\"%s\">Full Details</a>", escapeHTML(telegraphURL)))
+	}
+
+	trailer := t.Trailer
+	if trailer == "" {
+		trailer = fmt.Sprintf("https://www.youtube.com/results?search_query=%s", url.QueryEscape(t.Name+" trailer"))
+	}
+
+	cb.Add(0, fmt.Sprintf(" | <a href=\"%s\">Trailer</a>", escapeHTML(trailer)))
+
+	if t.PosterDownload != "" {
+		cb.Add(0, fmt.Sprintf(" | <a href=\"%s\">Download Poster</a>", escapeHTML(t.PosterDownload)))
+	}
+
+	// One character is taken by the hidden link to the poster.
+	return cb.Fit(messageLimit - 1)
+}
+
+// titleDuration returns the runtime of a title or of each episode of a series.
+func titleDuration(t *Title) string {
+	if t.Runtime == "" || !t.IsSeries {
+		return t.Runtime
+	}
+
+	return t.Runtime + "/Episode"
+}
+
+// titleReleaseDate returns the release date of a title followed by the country it's from.
+func titleReleaseDate(t *Title) string {
+	if t.ReleaseDate.IsZero() {
+		return ""
+	}
+
+	date := t.ReleaseDate.Format("02 January 2006")
+
+	if t.ReleaseCountry != "" {
+		if flag := getFlag(t.ReleaseCountry); flag != "" {
+			date += " (" + flag + ")"
+		} else {
+			date += " (" + t.ReleaseCountry + ")"
+		}
+	}
+
+	if t.IsSeries {
+		date += " - For First Episode"
+	}
+
+	return date
+}
+
+// renderRating renders the rating and metascore of a title as html or plain text.
+func renderRating(t *Title, asHTML bool) string {
+	var parts []string
+
+	if t.Rating > 0 {
+		if asHTML {
+			parts = append(parts, fmt.Sprintf("<i>Rating ⭐️ </i><b>%.1f / 10</b> (from %d votes)", t.Rating, t.Votes))
+		} else {
+			parts = append(parts, fmt.Sprintf("%.1f / 10 (from %d votes)", t.Rating, t.Votes))
+		}
+	}
+
+	if t.Metascore > 0 {
+		if asHTML {
+			parts = append(parts, fmt.Sprintf("<b>Ⓜ️ %d/100</b>", t.Metascore))
+		} else {
+			parts = append(parts, fmt.Sprintf("Metascore %d/100", t.Metascore))
+		}
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// renderTelegraph renders the node tree of a telegraph page with the full details of a title.
+func renderTelegraph(t *Title) []tgNode {
+	var nodes []tgNode
+
+	nodes = append(nodes, tgNode{Tag: "h3", Children: []any{fmt.Sprintf("%s (%d)", t.Name, t.StartYear)}})
+
+	if t.Poster != "" {
+		nodes = append(nodes, tgNode{Tag: "figure", Children: []any{tgNode{Tag: "img", Attrs: &tgAttrs{Src: t.Poster}}}})
+	}
+
+	nodes = append(nodes, makeHeader("Info"), makeRow("Type", t.Type))
+
+	if t.OriginalName != "" && t.OriginalName != t.Name {
+		nodes = append(nodes, makeRow("Original Title", t.OriginalName))
+	}
+
+	if t.AKA != "" && t.AKA != t.Name {
+		nodes = append(nodes, makeRow("AKA", t.AKA))
+	}
+
+	if t.IsSeries && t.Seasons > 0 {
+		seasons := fmt.Sprintf("%d", t.Seasons)
+		if t.Episodes > 0 {
+			seasons += fmt.Sprintf(" (%d Episodes)", t.Episodes)
+		}
+
+		nodes = append(nodes, makeRow("Seasons", seasons))
+	}
+
+	if dur := titleDuration(t); dur != "" {
+		nodes = append(nodes, makeRow("Duration", dur))
+	}
+
+	if date := titleReleaseDate(t); date != "" {
+		nodes = append(nodes, makeRow("Release Date", date))
+	}
+
+	if len(t.Genres) > 0 {
+		nodes = append(nodes, makeRow("Genres", strings.Join(t.Genres, ", ")))
+	}
+
+	if len(t.Themes) > 0 {
+		nodes = append(nodes, makeRow("Themes", strings.Join(t.Themes, ", ")))
+	}
+
+	if len(t.Languages) > 0 {
+		nodes = append(nodes, makeRow("Languages", strings.Join(t.Languages, ", ")))
+	}
+
+	if len(t.Countries) > 0 {
+		nodes = append(nodes, makeRow("Countries", strings.Join(t.Countries, ", ")))
+	}
+
+	if rating := renderRating(t, false); rating != "" {
+		nodes = append(nodes, makeRow("Rating", rating))
+	}
+
+	if t.Certificate != "" {
+		nodes = append(nodes, makeRow("Content Rating", t.Certificate))
+	}
+
+	if len(t.Directors) > 0 {
+		nodes = append(nodes, makeRow("Directors", strings.Join(personNames(t.Directors), ", ")))
+	}
+
+	if len(t.Writers) > 0 {
+		nodes = append(nodes, makeRow("Writers", strings.Join(personNames(t.Writers), ", ")))
+	}
+
+	if len(t.Producers) > 0 {
+		nodes = append(nodes, makeRow("Producers", strings.Join(personNames(t.Producers), ", ")))
+	}
+
+	if len(t.Stars) > 0 {
+		nodes = append(nodes, makeRow("Stars", strings.Join(personNames(t.Stars), ", ")))
+	}
+
+	if t.Awards != "" {
+		nodes = append(nodes, makeRow("Awards", t.Awards))
+	}
+
+	if t.Tagline != "" {
+		nodes = append(nodes, makeRow("Tagline", t.Tagline))
+	}
+
+	if t.Plot != "" {
+		nodes = append(nodes, makeHeader("Plot"), tgNode{Tag: "p", Children: []any{t.Plot}})
+	}
+
+	if t.AIReview != "" {
+		nodes = append(nodes, makeHeader("AI Review Summary"), tgNode{Tag: "i", Children: []any{t.AIReview}})
+	}
+
+	if len(t.Credits) > 0 {
+		nodes = append(nodes, makeHeader("Full Cast & Crew"))
+
+		for _, g := range t.Credits {
+			members := make([]string, 0, len(g.People))
+
+			for i, p := range g.People {
+				if i >= telegraphCastLimit {
+					break
+				}
+
+				if p.Role != "" {
+					members = append(members, p.Name+" as "+p.Role)
+				} else {
+					members = append(members, p.Name)
+				}
+			}
+
+			nodes = append(nodes, makeSubHeader(g.Name), tgNode{Tag: "p", Children: []any{strings.Join(members, ", ")}})
+		}
+	}
+
+	if len(t.Reviews) > 0 {
+		nodes = append(nodes, makeHeader("Featured Reviews"))
+		for _, r := range t.Reviews {
+			nodes = append(nodes, tgNode{Tag: "blockquote", Children: []any{tgNode{Tag: "b", Children: []any{fmt.Sprintf("%d/10: ", r.Rating)}}, r.Text}})
+		}
+	}
+
+	if t.Budget != "" || t.Revenue != "" {
+		nodes = append(nodes, makeHeader("Box Office"))
+
+		if t.Budget != "" {
+			nodes = append(nodes, makeRow("Budget", t.Budget))
+		}
+
+		if t.Revenue != "" {
+			nodes = append(nodes, makeRow("Revenue", t.Revenue))
+		}
+	}
+
+	if len(t.Companies) > 0 {
+		nodes = append(nodes, makeHeader("Production Companies"), tgNode{Tag: "p", Children: []any{strings.Join(t.Companies, ", ")}})
+	}
+
+	if len(t.Trivia) > 0 {
+		nodes = append(nodes, makeHeader("Trivia"))
+
+		for i, x := range t.Trivia {
+			if i >= telegraphListLimit {
+				break
+			}
+
+			nodes = append(nodes, tgNode{Tag: "blockquote", Children: []any{x}})
+		}
+	}
+
+	if len(t.Goofs) > 0 {
+		nodes = append(nodes, makeHeader("Goofs"))
+
+		for i, x := range t.Goofs {
+			if i >= telegraphListLimit {
+				break
+			}
+
+			nodes = append(nodes, tgNode{Tag: "p", Children: []any{"• " + x}})
+		}
+	}
+
+	return nodes
+}
+
+// renderInlineResult renders a search result from a hybrid provider into an inline article.
+func renderInlineResult(info *ProviderInfo, item UniversalSearchResult) gotgbot.InlineQueryResult {
+	posterURL := item.Poster
+	if posterURL == "" || posterURL == notAvailable {
+		posterURL = info.Banner
+	}
+
+	title := item.Title
+	if item.Year > 0 {
+		title = fmt.Sprintf("%s [%d]", item.Title, item.Year)
+	}
+
+	var description string
+	if item.Rating > 0 {
+		description = fmt.Sprintf("%s | Ratings: %.1f ⭐", item.Type, item.Rating)
+	} else {
+		description = fmt.Sprintf("%s | Ratings: N/A", item.Type)
+	}
+
+	return gotgbot.InlineQueryResultArticle{
+		Id:           info.Name + "_" + item.ID,
+		Title:        title,
+		Description:  description,
+		ThumbnailUrl: posterURL,
+		InputMessageContent: gotgbot.InputTextMessageContent{
+			MessageText: fmt.Sprintf("<i>Loading details for %s...</i>", escapeHTML(item.Title)),
+			ParseMode:   gotgbot.ParseModeHTML,
+		},
+		ReplyMarkup: &gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: "Open " + info.Label, CallbackData: fmt.Sprintf("open_%s_%s", info.Name, item.ID)}},
+		}},
+	}
+}
+
+// renderFederatedResult renders an inline result found in one or more sources with a button to open each source.
+func renderFederatedResult(info *ProviderInfo, item UniversalSearchResult) gotgbot.InlineQueryResult {
+	if len(item.Sources) < 1 {
+		return renderInlineResult(info, item)
+	}
+
+	var (
+		labels  = make([]string, 0, len(item.Sources))
+		buttons = make([]gotgbot.InlineKeyboardButton, 0, len(item.Sources))
+	)
+
+	for _, s := range item.Sources {
+		p, ok := getProvider(s.Method)
+		if !ok {
+			continue
+		}
+
+		labels = append(labels, p.Info().Label)
+		buttons = append(buttons, gotgbot.InlineKeyboardButton{Text: "Open " + p.Info().Label, CallbackData: fmt.Sprintf("open_%s_%s", s.Method, s.ID)})
+	}
+
+	primary := item.Sources[0]
+
+	result := renderInlineResult(info, item).(gotgbot.InlineQueryResultArticle) //nolint:errcheck // always an article.
+	result.Id = primary.Method + "_" + primary.ID
+	result.Description += " | " + strings.Join(labels, " · ")
+	result.ReplyMarkup = &gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{buttons}}
+
+	return result
+}
+
+// getFlag returns the flag and code of a country name or code.
+func getFlag(country string) string {
+	flagMap := map[string]string{
+		"United States": "🇺🇸 US", "USA": "🇺🇸 US", "US": "🇺🇸 US",
+		"United Kingdom": "🇬🇧 UK", "UK": "🇬🇧 UK", "GB": "🇬🇧 UK",
+		"India": "🇮🇳 IN", "IN": "🇮🇳 IN",
+		"France": "🇫🇷 FR", "FR": "🇫🇷 FR",
+		"Japan": "🇯🇵 JP", "JP": "🇯🇵 JP",
+		"Canada": "🇨🇦 CA", "CA": "🇨🇦 CA",
+		"Germany": "🇩🇪 DE", "DE": "🇩🇪 DE",
+		"Australia": "🇦🇺 AU", "AU": "🇦🇺 AU",
+		"Korea": "🇰🇷 KR", "South Korea": "🇰🇷 KR", "KR": "🇰🇷 KR",
+		"China": "🇨🇳 CN", "CN": "🇨🇳 CN",
+		"Russia": "🇷🇺 RU", "RU": "🇷🇺 RU",
+		"Italy": "🇮🇹 IT", "IT": "🇮🇹 IT",
+		"Spain": "🇪🇸 ES", "ES": "🇪🇸 ES",
+		"Brazil": "🇧🇷 BR", "BR": "🇧🇷 BR",
+	}
+
+	if val, ok := flagMap[country]; ok {
+		return val
+	}
+
+	for k, v := range flagMap {
+		if strings.Contains(country, k) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// link returns a html link to a person on imdb if id is a string or tmdb if it's an int.
+func link(name string, id any) string {
+	if idStr, ok := id.(string); ok {
+		return htmlLink("https://imdb.com/name/"+idStr, name)
+	}
+
+	if idInt, ok := id.(int); ok {
+		return htmlLink(fmt.Sprintf("https://www.themoviedb.org/person/%d", idInt), name)
+	}
+
+	return escapeHTML(name)
+}
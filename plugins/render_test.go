@@ -0,0 +1,305 @@
+// (c) Jisin0
+// Tests for rendering titles into captions, telegraph pages and inline results.
+
+package plugins
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// sampleTitle returns a title with every field the renderers use.
+func sampleTitle() *Title {
+	return &Title{
+		ID:             "tt0903747",
+		Type:           "TV Series",
+		IsSeries:       true,
+		Name:           "Breaking Bad",
+		OriginalName:   "Breaking Bad",
+		AKA:            "Reviravolta",
+		StartYear:      2008,
+		EndYear:        2013,
+		Runtime:        "49m",
+		ReleaseDate:    time.Date(2008, time.January, 20, 0, 0, 0, 0, time.UTC),
+		ReleaseCountry: "United States",
+		Rating:         9.5,
+		Votes:          2200000,
+		Metascore:      87,
+		Certificate:    "TV-MA",
+		Genres:         []string{"Crime", "Drama", "Thriller"},
+		Themes:         []string{"Drug Crime", "Tragedy"},
+		Languages:      []string{"English", "Spanish"},
+		Countries:      []string{"United States"},
+		Tagline:        "Remember my name",
+		Plot:           "A chemistry teacher diagnosed with cancer turns to making meth & selling it <to secure his family's future>.",
+		Directors:      []Person{{Name: "Vince Gilligan", ID: "nm0319213"}},
+		Writers:        []Person{{Name: "Peter Gould", TmdbID: 29779}},
+		Stars:          []Person{{Name: "Bryan Cranston", ID: "nm0186505"}, {Name: "Aaron Paul", ID: "nm0666739"}},
+		TopCast:        []Person{{Name: "Anna Gunn", ID: "nm0348152", Role: "Skyler White"}},
+		Credits: []CreditGroup{
+			{Name: "Cast", People: []Person{{Name: "Bryan Cranston", Role: "Walter White"}, {Name: "Aaron Paul", Role: "Jesse Pinkman"}}},
+			{Name: "Writing", People: []Person{{Name: "Peter Gould"}}},
+		},
+		Awards:   "Won 16 Primetime Emmys. 162 wins & 266 nominations total.",
+		Seasons:  5,
+		Episodes: 62,
+		Poster:   "https://m.media-amazon.com/images/M/bb.jpg",
+		Reviews:  []Review{{Rating: 10, Text: "Best show ever."}},
+		Trivia:   []string{"Bryan Cranston shaved his head for the role."},
+		Goofs:    []string{"The RV changes color."},
+	}
+}
+
+func TestRenderCaption(t *testing.T) {
+	caption := renderCaption(sampleTitle(), "https://telegra.ph/Breaking-Bad-01-01", UserPrefs{Country: "IN"})
+
+	if err := validateHTML(caption); err != nil {
+		t.Fatalf("caption isn't valid telegram html: %v\n%s", err, caption)
+	}
+
+	for _, want := range []string{
+		"<i>TV Series: </i><b>Breaking Bad [2008-2013]</b> | <a href=\"https://imdb.com/title/tt0903747\">IMDb Link</a>\n",
+		"<i>(AKA: Reviravolta)</i>",
+		"<b>5 Seasons (62 Episodes)</b>",
+		"<i>Duration: </i>49m/Episode",
+		"<i>Release Date: </i>20 January 2008 (🇺🇸 US) - For First Episode",
+		"<i>Rating ⭐️ </i><b>9.5 / 10</b> (from 2200000 votes) | <b>Ⓜ️ 87/100</b>",
+		"<i>Genres: </i>- #Crime 🎭 #Drama 🔪 #Thriller",
+		"<i>Themes: </i>#Drug_Crime #Tragedy",
+		"#English #Spanish (🇺🇸 #United_States)",
+		"<b>\"Remember my name\"</b>",
+		"making meth &amp; selling it &lt;to secure his family&#39;s future&gt;.",
+		"<a href='https://imdb.com/name/nm0319213'>Vince Gilligan</a>",
+		"<a href='https://www.themoviedb.org/person/29779'>Peter Gould</a>",
+		"<i><b>Top Cast:</b></i> <a href='https://imdb.com/name/nm0348152'>Anna Gunn</a>",
+		"https://www.justwatch.com/in/search?q=Breaking+Bad",
+		" | <a href=\"https://telegra.ph/Breaking-Bad-01-01\">Full Details</a>",
+		"https://www.youtube.com/results?search_query=Breaking+Bad+trailer",
+	} {
+		if !strings.Contains(caption, want) {
+			t.Errorf("caption is missing %q\n%s", want, caption)
+		}
+	}
+
+	// The original name is the same as the name so it's left out.
+	if strings.Contains(caption, "Original Title") {
+		t.Error("caption shows an original title that's the same as the name")
+	}
+
+	if strings.Count(caption, "<blockquote>") < 3 {
+		t.Errorf("caption should have the genres, credits and links in quotes\n%s", caption)
+	}
+}
+
+func TestRenderCaptionCompact(t *testing.T) {
+	caption := renderCaption(sampleTitle(), "", UserPrefs{Verbosity: verbosityCompact})
+
+	if err := validateHTML(caption); err != nil {
+		t.Fatalf("caption isn't valid telegram html: %v", err)
+	}
+
+	for _, skipped := range []string{"AKA", "Themes", "Remember my name", "Writers", "Top Cast", "Awards", "Language (Country)", "Full Details"} {
+		if strings.Contains(caption, skipped) {
+			t.Errorf("compact caption shouldn't have %q", skipped)
+		}
+	}
+
+	for _, kept := range []string{"Breaking Bad [2008-2013]", "Story Line", "Directors", "Stars", "Genres"} {
+		if !strings.Contains(caption, kept) {
+			t.Errorf("compact caption is missing %q", kept)
+		}
+	}
+}
+
+func TestRenderCaptionFitsLimit(t *testing.T) {
+	title := sampleTitle()
+	title.Plot = strings.Repeat("A very long plot. ", 200)
+
+	for i := 0; i < 200; i++ {
+		title.TopCast = append(title.TopCast, Person{Name: fmt.Sprintf("Actor %d", i), ID: fmt.Sprintf("nm%07d", i)})
+	}
+
+	caption := renderCaption(title, "", UserPrefs{})
+
+	if l := visibleLength(caption); l > messageLimit-1 {
+		t.Fatalf("caption has %d characters; want at most %d", l, messageLimit-1)
+	}
+
+	if err := validateHTML(caption); err != nil {
+		t.Fatalf("caption isn't valid telegram html after fitting: %v", err)
+	}
+
+	// The top cast is dropped before the plot since it has a lower priority.
+	if strings.Contains(caption, "Top Cast") {
+		t.Error("the top cast should be dropped first")
+	}
+
+	for _, kept := range []string{"Breaking Bad [2008-2013]", "Story Line", "Read More..."} {
+		if !strings.Contains(caption, kept) {
+			t.Errorf("caption is missing %q after fitting", kept)
+		}
+	}
+}
+
+func TestRenderRating(t *testing.T) {
+	title := &Title{Rating: 7.25, Votes: 100}
+
+	if got, want := renderRating(title, false), "7.2 / 10 (from 100 votes)"; got != want {
+		t.Errorf("renderRating() = %q; want %q", got, want)
+	}
+
+	title.Metascore = 60
+
+	if got, want := renderRating(title, false), "7.2 / 10 (from 100 votes) | Metascore 60/100"; got != want {
+		t.Errorf("renderRating() = %q; want %q", got, want)
+	}
+
+	if got := renderRating(&Title{}, true); got != "" {
+		t.Errorf("renderRating() of an unrated title = %q; want nothing", got)
+	}
+}
+
+// nodeText returns the text of a telegraph node and it's children.
+func nodeText(n any) string {
+	switch v := n.(type) {
+	case string:
+		return v
+	case tgNode:
+		var sb strings.Builder
+		for _, c := range v.Children {
+			sb.WriteString(nodeText(c))
+		}
+
+		return sb.String()
+	default:
+		return ""
+	}
+}
+
+func TestRenderTelegraph(t *testing.T) {
+	title := sampleTitle()
+	title.Trivia = nil
+
+	for i := 0; i < telegraphListLimit+10; i++ {
+		title.Trivia = append(title.Trivia, fmt.Sprintf("Trivia %d", i))
+	}
+
+	nodes := renderTelegraph(title)
+
+	if nodes[0].Tag != "h3" || nodeText(nodes[0]) != "Breaking Bad (2008)" {
+		t.Errorf("first node = %+v; want the title as h3", nodes[0])
+	}
+
+	if nodes[1].Tag != "figure" {
+		t.Fatalf("second node = %+v; want the poster", nodes[1])
+	}
+
+	if img := nodes[1].Children[0].(tgNode); img.Attrs == nil || img.Attrs.Src != title.Poster { //nolint:forcetypeassert // checked by the test.
+		t.Errorf("poster node = %+v", img)
+	}
+
+	var (
+		texts  []string
+		trivia int
+	)
+
+	for _, n := range nodes {
+		texts = append(texts, nodeText(n))
+
+		if n.Tag == "blockquote" && strings.HasPrefix(nodeText(n), "Trivia ") {
+			trivia++
+		}
+	}
+
+	all := strings.Join(texts, "\n")
+
+	for _, want := range []string{
+		"Info", "Type: TV Series", "AKA: Reviravolta", "Seasons: 5 (62 Episodes)", "Duration: 49m/Episode",
+		"Release Date: 20 January 2008 (🇺🇸 US) - For First Episode", "Genres: Crime, Drama, Thriller", "Themes: Drug Crime, Tragedy",
+		"Languages: English, Spanish", "Countries: United States", "Rating: 9.5 / 10 (from 2200000 votes) | Metascore 87/100", "Content Rating: TV-MA",
+		"Directors: Vince Gilligan", "Writers: Peter Gould", "Stars: Bryan Cranston, Aaron Paul",
+		"Awards: Won 16 Primetime Emmys. 162 wins & 266 nominations total.", "Tagline: Remember my name",
+		"Full Cast & Crew", "Bryan Cranston as Walter White, Aaron Paul as Jesse Pinkman",
+		"10/10: Best show ever.", "Goofs", "• The RV changes color.",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("telegraph page is missing %q", want)
+		}
+	}
+
+	if trivia != telegraphListLimit {
+		t.Errorf("telegraph page has %d trivia; want %d", trivia, telegraphListLimit)
+	}
+
+	// Empty sections are left out.
+	if strings.Contains(all, "Box Office") || strings.Contains(all, "Production Companies") || strings.Contains(all, "Original Title") {
+		t.Error("telegraph page has sections without data")
+	}
+}
+
+func TestRenderInlineResult(t *testing.T) {
+	info := &ProviderInfo{Name: searchMethodIMDb, Label: "IMDb", Banner: omdbBanner}
+
+	result := renderInlineResult(info, UniversalSearchResult{ID: "tt0903747", Title: "Breaking <Bad>", Year: 2008, Type: "TV Series", Rating: 9.5, Poster: "https://example.com/bb.jpg"})
+
+	article, ok := result.(gotgbot.InlineQueryResultArticle)
+	if !ok {
+		t.Fatalf("result is a %T; want an article", result)
+	}
+
+	if article.Id != "imdb_tt0903747" || article.Title != "Breaking <Bad> [2008]" || article.Description != "TV Series | Ratings: 9.5 ⭐" {
+		t.Errorf("article = %q, %q, %q", article.Id, article.Title, article.Description)
+	}
+
+	if article.ThumbnailUrl != "https://example.com/bb.jpg" {
+		t.Errorf("thumbnail = %q", article.ThumbnailUrl)
+	}
+
+	content := article.InputMessageContent.(gotgbot.InputTextMessageContent) //nolint:forcetypeassert // checked by the test.
+	if content.MessageText != "<i>Loading details for Breaking &lt;Bad&gt;...</i>" {
+		t.Errorf("message text = %q", content.MessageText)
+	}
+
+	if data := article.ReplyMarkup.InlineKeyboard[0][0].CallbackData; data != "open_imdb_tt0903747" {
+		t.Errorf("button data = %q", data)
+	}
+
+	// Missing posters, years and ratings.
+	article = renderInlineResult(info, UniversalSearchResult{ID: "tt1", Title: "Untitled", Type: "Movie", Poster: notAvailable}).(gotgbot.InlineQueryResultArticle) //nolint:forcetypeassert // always an article.
+
+	if article.ThumbnailUrl != omdbBanner || article.Title != "Untitled" || article.Description != "Movie | Ratings: N/A" {
+		t.Errorf("article without details = %q, %q, %q", article.ThumbnailUrl, article.Title, article.Description)
+	}
+}
+
+func TestTitleButtonsPrivateOnly(t *testing.T) {
+	defer func(s Store) { userStore = s }(userStore)
+
+	userStore = newMemoryStore()
+
+	title := sampleTitle()
+
+	if err := addToWatchlist(7, watchlistItem{Method: searchMethodIMDb, ID: title.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chat := range []gotgbot.Chat{{Id: 7, Type: gotgbot.ChatTypePrivate}, {Id: -100, Type: gotgbot.ChatTypeSupergroup}} {
+		ctx, cancel := requestContext(&ext.Context{EffectiveUser: &gotgbot.User{Id: 7}, EffectiveChat: &chat}, time.Minute)
+
+		want := "➕ Watchlist"
+		if chat.Type == gotgbot.ChatTypePrivate {
+			want = "✅ In Watchlist"
+		}
+
+		if got := titleButtons(ctx, title)[0][2].Text; got != want {
+			t.Errorf("watchlist button in a %s chat = %s; want %s", chat.Type, got, want)
+		}
+
+		cancel()
+	}
+}
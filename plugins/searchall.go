@@ -0,0 +1,338 @@
+// (c) Jisin0
+// Federated search across imdb and justwatch.
+
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+const (
+	searchMethodAll = "all"
+
+	// Constant added to the rank of a result when merging lists, higher values flatten the difference between ranks.
+	rankFusionK = 60
+	// Number of justwatch results whose imdb ids are looked up.
+	allLookupLimit = 8
+	// Time kept aside from the deadline to merge results.
+	allMergeMargin = 300 * time.Millisecond
+)
+
+// Sources searched in order of preference, the first source of a title is used to open it.
+var allSources = []Provider{imdbProvider, jWProvider}
+
+var allProvider = registerProvider(&federatedProvider{info: ProviderInfo{
+	Name:         searchMethodAll,
+	Label:        "All Sources",
+	Banner:       imdbBanner,
+	SearchButton: "🌐 Search All Sources",
+	IDPattern:    regexp.MustCompile(`tt\d+|tm\d+|ts\d+`),
+}})
+
+// federatedProvider searches every source at once and merges the results.
+type federatedProvider struct {
+	info ProviderInfo
+}
+
+func (p *federatedProvider) Info() *ProviderInfo {
+	return &p.info
+}
+
+func (p *federatedProvider) Search(ctx context.Context, query string, limit int) ([]UniversalSearchResult, error) {
+	results, _, err := p.search(ctx, query, limit)
+	return results, err
+}
+
+// SearchPage merges the results of every source on the first page and saves them, the following pages are read from the saved list so it's order doesn't change while scrolling.
+// The cursor is the number of results before the page.
+func (p *federatedProvider) SearchPage(ctx context.Context, query, cursor string, limit int) ([]UniversalSearchResult, string, error) {
+	var (
+		prefs   = prefsFrom(ctx)
+		key     = cacheKey("allsearch", strconv.FormatInt(userFrom(ctx), 10), prefs.Country, jWSearchLanguage(ctx), query)
+		results []UniversalSearchResult
+	)
+
+	data, ok := responseCache.Get(key)
+	if cursor == "" || !ok || json.Unmarshal(data, &results) != nil {
+		var err error
+
+		results, _, err = p.search(ctx, query, maxInlineResults)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if data, err := json.Marshal(results); err == nil {
+			responseCache.Set(key, data, searchCacheTTL)
+		}
+	}
+
+	start, _ := strconv.Atoi(cursor)
+	start = min(max(start, 0), len(results))
+	end := min(start+limit, len(results))
+
+	var next string
+	if end < len(results) {
+		next = strconv.Itoa(end)
+	}
+
+	return results[start:end], next, nil
+}
+
+// search merges up to limit results from every source, complete reports whether every source answered in time.
+func (p *federatedProvider) search(ctx context.Context, query string, limit int) ([]UniversalSearchResult, bool, error) {
+	// Sources must answer a little before the deadline so there's time left to merge them.
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-allMergeMargin))
+		defer cancel()
+	}
+
+	searches, complete := searchProviders(ctx, allSources, query, "", limit)
+
+	lists := make([][]UniversalSearchResult, len(searches))
+	for i, s := range searches {
+		lists[i] = s.Items
+	}
+
+	if jw := indexOf(allSources, jWProvider); jw >= 0 {
+		lists[jw] = withJWIMDbIDs(ctx, lists[jw])
+	}
+
+	results := mergeSearchResults(allSources, lists)
+	if len(results) < 1 {
+		return nil, complete, fmt.Errorf("no results found for %s", query)
+	}
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, complete, nil
+}
+
+func (p *federatedProvider) InlineResult(item UniversalSearchResult) gotgbot.InlineQueryResult {
+	return renderFederatedResult(&p.info, item)
+}
+
+func (p *federatedProvider) GetTitle(ctx context.Context, id string, progress func(string)) (*TitleCard, error) {
+	if imdbIDPattern.MatchString(id) {
+		return imdbProvider.GetTitle(ctx, id, progress)
+	}
+
+	return jWProvider.GetTitle(ctx, id, progress)
+}
+
+// indexOf returns the index of a provider in a list or -1.
+func indexOf(providers []Provider, p Provider) int {
+	for i, v := range providers {
+		if v == p {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// jWIMDbKey marks the source holding the imdb id of a justwatch result while merging.
+const jWIMDbKey = "imdb:"
+
+// withJWIMDbIDs looks up the imdb ids of the top justwatch results, lookups that don't finish before ctx ends are skipped.
+func withJWIMDbIDs(ctx context.Context, items []UniversalSearchResult) []UniversalSearchResult {
+	var (
+		mu  sync.Mutex
+		ids = make([]string, len(items))
+		wg  sync.WaitGroup
+	)
+
+	for i := range items {
+		if i >= allLookupLimit {
+			break
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			id, err := jWIMDbID(ctx, items[i].ID)
+			if err == nil {
+				mu.Lock()
+				ids[i] = id
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	out := make([]UniversalSearchResult, len(items))
+	copy(out, items)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, id := range ids {
+		if id != "" {
+			out[i].Sources = []SearchSource{{Method: jWIMDbKey, ID: id}}
+		}
+	}
+
+	return out
+}
+
+// mergedResult is a title found in one or more sources.
+type mergedResult struct {
+	item  UniversalSearchResult
+	score float64
+	index int
+}
+
+// mergeSearchResults merges ranked lists from each source into one list using reciprocal rank fusion.
+// Titles are matched by imdb id or by their name and year when the id is unknown.
+func mergeSearchResults(sources []Provider, lists [][]UniversalSearchResult) []UniversalSearchResult {
+	var (
+		merged []*mergedResult
+		byKey  = make(map[string]*mergedResult)
+	)
+
+	for s, list := range lists {
+		method := sources[s].Info().Name
+
+		for rank, item := range list {
+			id, name := titleKeys(method, item)
+
+			// Titles with a known imdb id are only matched by it so remakes with the same name aren't merged.
+			lookup := id
+			if lookup == "" {
+				lookup = name
+			}
+
+			m := byKey[lookup]
+
+			if m == nil {
+				display := item
+				display.Sources = nil
+				m = &mergedResult{item: display, index: len(merged)}
+				merged = append(merged, m)
+			} else {
+				fillMissing(&m.item, item)
+			}
+
+			if !hasSource(m.item.Sources, method) {
+				m.item.Sources = append(m.item.Sources, SearchSource{Method: method, ID: item.ID})
+				m.score += 1.0 / float64(rankFusionK+rank+1)
+			}
+
+			if id != "" {
+				byKey[id] = m
+			}
+
+			if _, ok := byKey[name]; !ok {
+				byKey[name] = m
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].score != merged[j].score {
+			return merged[i].score > merged[j].score
+		}
+
+		return merged[i].index < merged[j].index
+	})
+
+	results := make([]UniversalSearchResult, 0, len(merged))
+	for _, m := range merged {
+		results = append(results, m.item)
+	}
+
+	return results
+}
+
+// titleKeys returns the imdb id of a result if it's known and a key made of it's name and year.
+func titleKeys(method string, item UniversalSearchResult) (id, name string) {
+	switch {
+	case imdbIDPattern.MatchString(item.ID):
+		id = item.ID
+	case len(item.Sources) > 0 && item.Sources[0].Method == jWIMDbKey:
+		id = item.Sources[0].ID
+	}
+
+	if n := normalizeTitle(item.Title); n != "" {
+		name = fmt.Sprintf("%s:%d", n, item.Year)
+	} else {
+		name = method + ":" + item.ID
+	}
+
+	return id, name
+}
+
+// normalizeTitle lowercases a title and removes everything except letters and numbers.
+func normalizeTitle(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r > 127: //nolint:mnd // non ascii letters are kept.
+			return r
+		default:
+			return -1
+		}
+	}, strings.ToLower(s))
+}
+
+func hasSource(sources []SearchSource, method string) bool {
+	for _, s := range sources {
+		if s.Method == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fillMissing copies fields that are empty in dst from src.
+func fillMissing(dst *UniversalSearchResult, src UniversalSearchResult) {
+	if dst.Poster == "" || dst.Poster == notAvailable {
+		dst.Poster = src.Poster
+	}
+
+	if dst.Rating == 0 {
+		dst.Rating = src.Rating
+	}
+
+	if dst.Year == 0 {
+		dst.Year = src.Year
+	}
+
+	if dst.Description == "" {
+		dst.Description = src.Description
+	}
+
+	if len(dst.Genres) == 0 {
+		dst.Genres = src.Genres
+	}
+
+	if dst.URL == "" {
+		dst.URL = src.URL
+	}
+}
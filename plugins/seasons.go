@@ -0,0 +1,405 @@
+// (c) Jisin0
+// Browse the seasons and episodes of a series from tmdb.
+
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	// Number of season buttons on each page of the season list.
+	seasonsPageSize = 12
+	// Number of episodes on each page of a season.
+	episodesPageSize = 5
+	// Maximum length of an episode plot.
+	episodePlotLength = 300
+)
+
+// tmdbSeries is the part of a tmdb series used to list it's seasons.
+type tmdbSeries struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	PosterPath string `json:"poster_path"`
+	Seasons    []struct {
+		SeasonNumber int    `json:"season_number"`
+		Name         string `json:"name"`
+		EpisodeCount int    `json:"episode_count"`
+		AirDate      string `json:"air_date"`
+	} `json:"seasons"`
+}
+
+// tmdbSeason is a single season of a series with all of it's episodes.
+type tmdbSeason struct {
+	Name         string `json:"name"`
+	SeasonNumber int    `json:"season_number"`
+	PosterPath   string `json:"poster_path"`
+	Episodes     []struct {
+		EpisodeNumber int     `json:"episode_number"`
+		Name          string  `json:"name"`
+		AirDate       string  `json:"air_date"`
+		Overview      string  `json:"overview"`
+		Runtime       int     `json:"runtime"`
+		VoteAverage   float64 `json:"vote_average"`
+	} `json:"episodes"`
+}
+
+// getTMDBJSON decodes the response of a tmdb api path into v.
+func getTMDBJSON(ctx context.Context, path string, v any) error {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+
+	r, err := upstream.Get(ctx, apiTMDB+path+sep+"api_key="+tmdbKey)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != 200 { //nolint:mnd // http ok.
+		return fmt.Errorf("tmdb: %s returned %s", path, r.Status)
+	}
+
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// tmdbRef is the id of a movie or series on tmdb.
+type tmdbRef struct {
+	// Either movie or tv.
+	MediaType string `json:"media_type"`
+	ID        int    `json:"id"`
+}
+
+// tmdbFindTitle returns the tmdb id of a movie or series using it's imdb id.
+func tmdbFindTitle(ctx context.Context, imdbID string) (tmdbRef, error) {
+	return cached(cacheKey("tmdbfind", imdbID), titleCacheTTL, func() (tmdbRef, error) {
+		var res tmdbFindRes
+		if err := getTMDBJSON(ctx, fmt.Sprintf("/find/%s?external_source=imdb_id", imdbID), &res); err != nil {
+			return tmdbRef{}, err
+		}
+
+		switch {
+		case len(res.MovieResults) > 0:
+			return tmdbRef{MediaType: "movie", ID: res.MovieResults[0].ID}, nil
+		case len(res.TVResults) > 0:
+			return tmdbRef{MediaType: "tv", ID: res.TVResults[0].ID}, nil
+		default:
+			return tmdbRef{}, errors.New("tmdb: nothing found for " + imdbID)
+		}
+	})
+}
+
+// tmdbSeriesID returns the tmdb id of a series using it's imdb id.
+func tmdbSeriesID(ctx context.Context, imdbID string) (int, error) {
+	ref, err := tmdbFindTitle(ctx, imdbID)
+	if err != nil {
+		return 0, err
+	}
+
+	if ref.MediaType != "tv" {
+		return 0, errors.New("tmdb: " + imdbID + " isn't a series")
+	}
+
+	return ref.ID, nil
+}
+
+// getTMDBSeries gets the list of seasons of a series by it's imdb id.
+func getTMDBSeries(ctx context.Context, imdbID string) (*tmdbSeries, error) {
+	return cached(cacheKey("tmdbseries", imdbID), titleCacheTTL, func() (*tmdbSeries, error) {
+		id, err := tmdbSeriesID(ctx, imdbID)
+		if err != nil {
+			return nil, err
+		}
+
+		var s tmdbSeries
+
+		return &s, getTMDBJSON(ctx, fmt.Sprintf("/tv/%d", id), &s)
+	})
+}
+
+// getTMDBSeason gets the episodes of a season of a series by it's imdb id.
+func getTMDBSeason(ctx context.Context, imdbID string, season int) (*tmdbSeason, error) {
+	return cached(cacheKey("tmdbseason", imdbID, strconv.Itoa(season)), titleCacheTTL, func() (*tmdbSeason, error) {
+		id, err := tmdbSeriesID(ctx, imdbID)
+		if err != nil {
+			return nil, err
+		}
+
+		var s tmdbSeason
+
+		return &s, getTMDBJSON(ctx, fmt.Sprintf("/tv/%d/season/%d", id, season), &s)
+	})
+}
+
+// tmdbImage returns the full url of a tmdb image path or fallback if it's empty.
+func tmdbImage(path, fallback string) string {
+	if path == "" {
+		return fallback
+	}
+
+	return "https://image.tmdb.org/t/p/original" + path
+}
+
+// formatAirDate formats a date like 2008-01-20 as 20 Jan 2008.
+func formatAirDate(date string) string {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+
+	return d.Format("02 Jan 2006")
+}
+
+// dateYear returns the year of a date like 2008-01-20 or an empty string if it's unknown.
+func dateYear(date string) string {
+	if len(date) < 4 { //nolint:mnd // length of a year.
+		return ""
+	}
+
+	return date[:4]
+}
+
+// pageButtons returns previous and next buttons for a page, data is called with the page number of each button.
+func pageButtons(page, pages int, data func(page int) string) []gotgbot.InlineKeyboardButton {
+	var row []gotgbot.InlineKeyboardButton
+
+	if page > 0 {
+		row = append(row, gotgbot.InlineKeyboardButton{Text: "◀️ Prev", CallbackData: data(page - 1)})
+	}
+
+	if pages > 1 {
+		row = append(row, gotgbot.InlineKeyboardButton{Text: fmt.Sprintf("%d/%d", page+1, pages), CallbackData: data(page)})
+	}
+
+	if page+1 < pages {
+		row = append(row, gotgbot.InlineKeyboardButton{Text: "Next ▶️", CallbackData: data(page + 1)})
+	}
+
+	return row
+}
+
+// pageCount returns the number of pages needed to show n items.
+func pageCount(n, size int) int {
+	return (n + size - 1) / size
+}
+
+func seasonsData(id string, page int) string {
+	return fmt.Sprintf("ssn_%s_%d", id, page)
+}
+
+func episodesData(id string, season, page int) string {
+	return fmt.Sprintf("eps_%s_%d_%d", id, season, page)
+}
+
+// backToTitleButton opens the card of a title again.
+func backToTitleButton(id string) gotgbot.InlineKeyboardButton {
+	return gotgbot.InlineKeyboardButton{Text: "⬅️ Back to Title", CallbackData: fmt.Sprintf("open_%s_%s", searchMethodIMDb, id)}
+}
+
+// seasonsCard renders a page of the season list of a series.
+func seasonsCard(s *tmdbSeries, id string, page int) *TitleCard {
+	var (
+		sb      strings.Builder
+		buttons [][]gotgbot.InlineKeyboardButton
+		row     []gotgbot.InlineKeyboardButton
+		pages   = pageCount(len(s.Seasons), seasonsPageSize)
+	)
+
+	if page >= pages {
+		page = pages - 1
+	}
+
+	sb.WriteString(fmt.Sprintf("<b>📺 %s</b> | <i>%d Seasons</i>\n\n", escapeHTML(s.Name), len(s.Seasons)))
+
+	if page >= 0 {
+		for _, season := range s.Seasons[page*seasonsPageSize : min((page+1)*seasonsPageSize, len(s.Seasons))] {
+			sb.WriteString(fmt.Sprintf("<b>%s</b> · <i>%d Episodes</i>", escapeHTML(season.Name), season.EpisodeCount))
+
+			if season.AirDate != "" {
+				sb.WriteString(fmt.Sprintf(" · <i>%s</i>", escapeHTML(formatAirDate(season.AirDate))))
+			}
+
+			sb.WriteRune('\n')
+
+			label := fmt.Sprintf("S%d", season.SeasonNumber)
+			if season.SeasonNumber == 0 {
+				label = "Specials"
+			}
+
+			row = append(row, gotgbot.InlineKeyboardButton{Text: label, CallbackData: episodesData(id, season.SeasonNumber, 0)})
+
+			if len(row) == 4 { //nolint:mnd // buttons in each row.
+				buttons = append(buttons, row)
+				row = nil
+			}
+		}
+	}
+
+	if len(row) > 0 {
+		buttons = append(buttons, row)
+	}
+
+	if nav := pageButtons(page, pages, func(p int) string { return seasonsData(id, p) }); len(nav) > 0 {
+		buttons = append(buttons, nav)
+	}
+
+	sb.WriteString("\n<i>Pick a season to see it's episodes 👇</i>")
+
+	return &TitleCard{
+		Poster:  tmdbImage(s.PosterPath, omdbBanner),
+		Caption: sb.String(),
+		Buttons: append(buttons, []gotgbot.InlineKeyboardButton{backToTitleButton(id)}),
+	}
+}
+
+// episodesCard renders a page of the episodes of a season.
+func episodesCard(s *tmdbSeason, seriesName, poster, id string, page int) *TitleCard {
+	var (
+		cb    captionBuilder
+		pages = pageCount(len(s.Episodes), episodesPageSize)
+	)
+
+	if page >= pages {
+		page = pages - 1
+	}
+
+	cb.Add(0, fmt.Sprintf("<b>📺 %s</b> | <i>%s</i>\n\n", escapeHTML(seriesName), escapeHTML(s.Name)))
+
+	if page < 0 {
+		cb.Add(0, "<i>No episodes have been announced yet !</i>")
+	} else {
+		for _, e := range s.Episodes[page*episodesPageSize : min((page+1)*episodesPageSize, len(s.Episodes))] {
+			cb.Add(0, fmt.Sprintf("<b>E%d · %s</b>\n", e.EpisodeNumber, escapeHTML(e.Name)))
+
+			details := make([]string, 0, 3) //nolint:mnd // air date, rating and runtime.
+
+			if e.AirDate != "" {
+				details = append(details, "🗓️ "+formatAirDate(e.AirDate))
+			}
+
+			if e.VoteAverage > 0 {
+				details = append(details, fmt.Sprintf("⭐ %.1f", e.VoteAverage))
+			}
+
+			if e.Runtime > 0 {
+				details = append(details, fmt.Sprintf("%d mins", e.Runtime))
+			}
+
+			if len(details) > 0 {
+				cb.Add(0, fmt.Sprintf("<i>%s</i>\n", escapeHTML(strings.Join(details, " · "))))
+			}
+
+			if e.Overview != "" {
+				cb.Add(1, fmt.Sprintf("<blockquote expandable>%s</blockquote>", truncateHTML(escapeHTML(e.Overview), episodePlotLength)))
+			}
+
+			cb.Add(0, "\n")
+		}
+	}
+
+	var buttons [][]gotgbot.InlineKeyboardButton
+
+	if nav := pageButtons(page, pages, func(p int) string { return episodesData(id, s.SeasonNumber, p) }); len(nav) > 0 {
+		buttons = append(buttons, nav)
+	}
+
+	buttons = append(buttons, []gotgbot.InlineKeyboardButton{
+		{Text: "⬅️ Seasons", CallbackData: seasonsData(id, 0)},
+		backToTitleButton(id),
+	})
+
+	return &TitleCard{
+		Poster:  tmdbImage(s.PosterPath, poster),
+		Caption: cb.Fit(messageLimit - 1),
+		Buttons: buttons,
+	}
+}
+
+// CbSeasons handles the buttons of the season list, data is like ssn_<imdb id>_<page>.
+func CbSeasons(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.Split(update.Data, "_")
+	)
+
+	if len(split) < 3 || !imdbIDPattern.MatchString(split[1]) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	id := split[1]
+	page, _ := strconv.Atoi(split[2])
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	series, err := getTMDBSeries(reqCtx, id)
+	if err != nil || len(series.Seasons) < 1 {
+		fmt.Printf("cbseasons: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Find The Seasons of This Series 🤧", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	if err := editCard(bot, callbackTarget(update), seasonsCard(series, id, page)); err != nil {
+		fmt.Printf("cbseasons: %v\n", err)
+	}
+
+	update.Answer(bot, nil)
+
+	return ext.EndGroups
+}
+
+// CbEpisodes handles the buttons of a season's episode list, data is like eps_<imdb id>_<season>_<page>.
+func CbEpisodes(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.Split(update.Data, "_")
+	)
+
+	if len(split) < 4 || !imdbIDPattern.MatchString(split[1]) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	id := split[1]
+	season, _ := strconv.Atoi(split[2])
+	page, _ := strconv.Atoi(split[3])
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	series, err := getTMDBSeries(reqCtx, id)
+	if err != nil {
+		fmt.Printf("cbepisodes: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Find The Episodes of This Season 🤧", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	s, err := getTMDBSeason(reqCtx, id, season)
+	if err != nil {
+		fmt.Printf("cbepisodes: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Find The Episodes of This Season 🤧", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	if err := editCard(bot, callbackTarget(update), episodesCard(s, series.Name, tmdbImage(series.PosterPath, omdbBanner), id, page)); err != nil {
+		fmt.Printf("cbepisodes: %v\n", err)
+	}
+
+	update.Answer(bot, nil)
+
+	return ext.EndGroups
+}
@@ -0,0 +1,349 @@
+// (c) Jisin0
+// Recommend titles similar to the one on a card.
+
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	// Number of titles on each page of recommendations.
+	similarPageSize = 8
+	// Maximum number of similar titles fetched from justwatch.
+	jWSimilarLimit = 24
+)
+
+// similarTitle is a recommended title, only one of ID or TmdbID is set.
+type similarTitle struct {
+	// Id of the title in the provider it's opened with.
+	ID string `json:"id,omitempty"`
+	// Tmdb id of the title, it's converted into an imdb id only when shown.
+	TmdbID    int    `json:"tmdb_id,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Title     string `json:"title"`
+	Year      string `json:"year,omitempty"`
+}
+
+// jWSimilarQuery gets the titles justwatch considers similar to a title.
+const jWSimilarQuery = `query GetSimilarTitles($id: ID!, $country: Country!, $language: Language!, $first: Int!) {
+  node(id: $id) {
+    ... on MovieOrShow {
+      similarTitlesV2(country: $country, first: $first) {
+        edges {
+          node {
+            id
+            content(country: $country, language: $language) {
+              title
+              originalReleaseYear
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// similarData returns the callback data of a page of recommendations, country is only used by regional providers.
+func similarData(method, id string, page int, country string) string {
+	data := fmt.Sprintf("sim_%s_%s_%d", method, id, page)
+	if country != "" {
+		data += "_" + country
+	}
+
+	return data
+}
+
+// similarButton returns the button that shows titles similar to a title.
+func similarButton(method, id, country string) gotgbot.InlineKeyboardButton {
+	return gotgbot.InlineKeyboardButton{Text: "🎯 Similar", CallbackData: similarData(method, id, 0, country)}
+}
+
+// tmdbRecommendations gets titles recommended by tmdb for a title by it's imdb id.
+func tmdbRecommendations(ctx context.Context, imdbID string) ([]similarTitle, error) {
+	return cached(cacheKey("similar", searchMethodIMDb, imdbID), titleCacheTTL, func() ([]similarTitle, error) {
+		ref, err := tmdbFindTitle(ctx, imdbID)
+		if err != nil {
+			return nil, err
+		}
+
+		var res struct {
+			Results []tmdbCredit `json:"results"`
+		}
+
+		if err := getTMDBJSON(ctx, fmt.Sprintf("/%s/%d/recommendations", ref.MediaType, ref.ID), &res); err != nil {
+			return nil, err
+		}
+
+		titles := make([]similarTitle, 0, len(res.Results))
+
+		for _, r := range res.Results {
+			mediaType := r.MediaType
+			if mediaType == "" {
+				mediaType = ref.MediaType
+			}
+
+			titles = append(titles, similarTitle{TmdbID: r.ID, MediaType: mediaType, Title: r.Title + r.Name, Year: dateYear(r.ReleaseDate + r.FirstAirDate)})
+		}
+
+		return titles, nil
+	})
+}
+
+// jWSimilarTitles gets titles similar to a justwatch title in the country of the user's preferences.
+func jWSimilarTitles(ctx context.Context, id string) ([]similarTitle, error) {
+	prefs := prefsFrom(ctx)
+
+	return cached(cacheKey("similar", searchMethodJW, prefs.Country, prefs.Language, id), titleCacheTTL, func() ([]similarTitle, error) {
+		var res struct {
+			Node struct {
+				SimilarTitlesV2 struct {
+					Edges []struct {
+						Node struct {
+							ID      string `json:"id"`
+							Content struct {
+								Title               string `json:"title"`
+								OriginalReleaseYear int    `json:"originalReleaseYear"`
+							} `json:"content"`
+						} `json:"node"`
+					} `json:"edges"`
+				} `json:"similarTitlesV2"`
+			} `json:"node"`
+		}
+
+		err := jWGraphQL(ctx, jWSimilarQuery, map[string]any{
+			"id":       id,
+			"country":  prefs.Country,
+			"language": prefs.Language,
+			"first":    jWSimilarLimit,
+		}, &res)
+		if err != nil {
+			return nil, err
+		}
+
+		edges := res.Node.SimilarTitlesV2.Edges
+		if len(edges) < 1 {
+			return nil, errors.New("justwatch: no similar titles for " + id)
+		}
+
+		titles := make([]similarTitle, 0, len(edges))
+
+		for _, e := range edges {
+			if !jWIDPattern.MatchString(e.Node.ID) {
+				continue
+			}
+
+			t := similarTitle{ID: e.Node.ID, Title: e.Node.Content.Title}
+			if e.Node.Content.OriginalReleaseYear > 0 {
+				t.Year = strconv.Itoa(e.Node.Content.OriginalReleaseYear)
+			}
+
+			titles = append(titles, t)
+		}
+
+		return titles, nil
+	})
+}
+
+// similarTitles returns the recommendations for a title and the method used to open them.
+// Justwatch titles fall back to tmdb recommendations using their imdb id.
+func similarTitles(ctx context.Context, method, id string) ([]similarTitle, string, error) {
+	if method != searchMethodJW {
+		titles, err := tmdbRecommendations(ctx, id)
+		return titles, searchMethodIMDb, err
+	}
+
+	titles, err := jWSimilarTitles(ctx, id)
+	if err == nil {
+		return titles, searchMethodJW, nil
+	}
+
+	fmt.Printf("similar: %v\n", err)
+
+	imdbID, err := jWIMDbID(ctx, id)
+	if err != nil || imdbID == "" {
+		return nil, "", fmt.Errorf("similar: no recommendations for %s", id)
+	}
+
+	titles, err = tmdbRecommendations(ctx, imdbID)
+
+	return titles, searchMethodIMDb, err
+}
+
+// similarName returns the name and poster of the title that recommendations are shown for.
+func similarName(ctx context.Context, method, id string) (name, poster string) {
+	if method == searchMethodJW {
+		title, err := getJWTitleData(ctx, id)
+		if err != nil || title.Content == nil {
+			return "", jWBanner
+		}
+
+		poster = jWBanner
+		if title.Content.Poster != nil {
+			poster = title.Content.Poster.FullURL()
+		}
+
+		return title.Content.Title, poster
+	}
+
+	t, err := getTitleData(ctx, id, nil)
+	if err != nil {
+		return "", omdbBanner
+	}
+
+	poster = t.Poster
+	if poster == "" || poster == notAvailable {
+		poster = omdbBanner
+	}
+
+	return t.Name, poster
+}
+
+// similarCard renders a page of titles similar to a title with a button to open each of them.
+func similarCard(ctx context.Context, method, id string, page int) (*TitleCard, error) {
+	titles, openMethod, err := similarTitles(ctx, method, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(titles) < 1 {
+		return nil, fmt.Errorf("similar: no recommendations for %s", id)
+	}
+
+	var (
+		cb      captionBuilder
+		country string
+		photo   = method == searchMethodJW
+		limit   = messageLimit - 1
+		pages   = pageCount(len(titles), similarPageSize)
+	)
+
+	// Justwatch cards are photos with shorter captions.
+	if photo {
+		country = prefsFrom(ctx).Country
+		limit = captionLimit
+	}
+
+	page = max(0, min(page, pages-1))
+	titles = titles[page*similarPageSize : min((page+1)*similarPageSize, len(titles))]
+
+	// Tmdb titles are opened with their imdb ids.
+	var wg sync.WaitGroup
+
+	for i := range titles {
+		if titles[i].TmdbID == 0 {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(t *similarTitle) {
+			defer wg.Done()
+
+			t.ID, _ = tmdbToIMDb(ctx, t.MediaType, t.TmdbID)
+		}(&titles[i])
+	}
+
+	wg.Wait()
+
+	name, poster := similarName(ctx, method, id)
+
+	cb.Add(0, fmt.Sprintf("<b>🎯 Titles Similar to %s</b>\n\n", escapeHTML(name)))
+
+	var buttons [][]gotgbot.InlineKeyboardButton
+
+	for i, t := range titles {
+		text := t.Title
+		if t.Year != "" {
+			text += " (" + t.Year + ")"
+		}
+
+		cb.Add(0, fmt.Sprintf("%d. %s\n", page*similarPageSize+i+1, escapeHTML(text)))
+
+		if t.ID == "" {
+			continue
+		}
+
+		data := fmt.Sprintf("open_%s_%s", openMethod, t.ID)
+		if openMethod == searchMethodJW {
+			data += "_" + country
+		}
+
+		buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: text, CallbackData: data}})
+	}
+
+	if nav := pageButtons(page, pages, func(n int) string { return similarData(method, id, n, country) }); len(nav) > 0 {
+		buttons = append(buttons, nav)
+	}
+
+	back := fmt.Sprintf("open_%s_%s", method, id)
+	if country != "" {
+		back += "_" + country
+	}
+
+	buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: "⬅️ Back to Title", CallbackData: back}})
+
+	return &TitleCard{
+		Poster:  poster,
+		Caption: cb.Fit(limit),
+		Buttons: buttons,
+		Photo:   photo,
+	}, nil
+}
+
+// CbSimilar handles the buttons of recommendations, data is like sim_<method>_<id>_<page> with an optional country for regional providers.
+func CbSimilar(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.Split(update.Data, "_")
+	)
+
+	if len(split) < 4 {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	var (
+		method = split[1]
+		id     = split[2]
+	)
+
+	page, _ := strconv.Atoi(split[3])
+
+	p, ok := getProvider(method)
+	if !ok || !p.Info().IDPattern.MatchString(id) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	if len(split) > 4 && p.Info().Regional && isCountryCode(split[4]) {
+		reqCtx = withCountry(reqCtx, split[4])
+	}
+
+	card, err := similarCard(reqCtx, method, id, page)
+	if err != nil {
+		fmt.Printf("cbsimilar: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Find Any Similar Titles 🤧", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	if err := editCard(bot, callbackTarget(update), card); err != nil {
+		fmt.Printf("cbsimilar: %v\n", err)
+	}
+
+	update.Answer(bot, nil)
+
+	return ext.EndGroups
+}
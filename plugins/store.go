@@ -0,0 +1,298 @@
+// (c) Jisin0
+// Key-value store for user data like preferences.
+
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Json file user data is saved in if STORE_PATH isn't set.
+	defaultStorePath = "data/store.json"
+	// Prefix of user data keys on redis so they don't mix with cached responses.
+	redisStorePrefix = "store:"
+	// Number of keys asked for in each SCAN.
+	redisScanCount = 100
+)
+
+// Store saves user data permanently unlike the response cache.
+type Store interface {
+	// Get returns the value saved for a key.
+	Get(key string) ([]byte, bool)
+	// Set saves the value of a key.
+	Set(key string, value []byte) error
+	// Delete removes a key from the store.
+	Delete(key string) error
+	// Keys returns all keys starting with prefix in sorted order.
+	Keys(prefix string) []string
+}
+
+// userStore is the store used for all user data.
+var userStore Store
+
+// initStore sets up the user store from the environment.
+// Data is saved on redis if it's used so serverless instances share it, otherwise in a json file.
+func initStore() {
+	if RedisURL != "" {
+		c, ok := responseCache.(*redisCache)
+		if !ok {
+			var err error
+
+			if c, err = newRedisCache(RedisURL); err != nil {
+				fmt.Printf("failed to connect to redis, using file store: %v\n", err)
+			}
+		}
+
+		if c != nil {
+			userStore = &redisStore{c: c}
+			return
+		}
+	}
+
+	path := StorePath
+	if path == "" {
+		path = defaultStorePath
+	}
+
+	s, err := newFileStore(path)
+	if err == nil {
+		userStore = s
+		return
+	}
+
+	fmt.Printf("failed to open store, using in-memory store: %v\n", err)
+
+	userStore = newMemoryStore()
+}
+
+// userDataKeys returns the key of each record saved for a user.
+func userDataKeys(userID int64) []string {
+	return []string{prefsKey(userID), watchlistKey(userID), alertsKey(userID), remindersKey(userID)}
+}
+
+// forgetUser deletes all data saved for a user and removes them from the movie night polls they started.
+func forgetUser(userID int64) error {
+	for _, key := range userDataKeys(userID) {
+		if err := userStore.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return forgetMovieNights(userID)
+}
+
+// loadRecord decodes the json value saved at key into v and reports whether it was found.
+func loadRecord(key string, v any) bool {
+	data, ok := userStore.Get(key)
+	if !ok {
+		return false
+	}
+
+	return json.Unmarshal(data, v) == nil
+}
+
+// saveRecord encodes v as json and saves it at key.
+func saveRecord(key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return userStore.Set(key, data)
+}
+
+// memoryStore keeps data in memory, it's lost on restarts.
+type memoryStore struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{data: make(map[string][]byte)}
+}
+
+func (s *memoryStore) Get(key string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.data[key]
+
+	return v, ok
+}
+
+func (s *memoryStore) Set(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[key] = value
+
+	return nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.data, key)
+
+	return nil
+}
+
+func (s *memoryStore) Keys(prefix string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []string
+
+	for k := range s.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// fileStore keeps data in memory and writes all of it to a json file after each change.
+type fileStore struct {
+	*memoryStore
+	path string
+
+	// Serializes writes to the file.
+	fileMu sync.Mutex
+}
+
+// newFileStore opens a store saved at path, the file is created on the first write.
+func newFileStore(path string) (*fileStore, error) {
+	s := &fileStore{memoryStore: newMemoryStore(), path: path}
+
+	var data map[string]json.RawMessage
+
+	if err := loadJSONFile(path, &data); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	for k, v := range data {
+		s.data[k] = v
+	}
+
+	return s, nil
+}
+
+func (s *fileStore) Set(key string, value []byte) error {
+	if !json.Valid(value) {
+		return fmt.Errorf("filestore: value of %s isn't valid json", key)
+	}
+
+	s.memoryStore.Set(key, value) //nolint:errcheck // never fails.
+
+	return s.save()
+}
+
+func (s *fileStore) Delete(key string) error {
+	s.memoryStore.Delete(key) //nolint:errcheck // never fails.
+
+	return s.save()
+}
+
+func (s *fileStore) save() error {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+
+	s.mu.RLock()
+
+	data := make(map[string]json.RawMessage, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+
+	s.mu.RUnlock()
+
+	return saveJSONFile(s.path, data)
+}
+
+// redisStore saves data on a redis compatible server using the pool of the response cache.
+type redisStore struct {
+	c *redisCache
+}
+
+func (s *redisStore) Get(key string) ([]byte, bool) {
+	return s.c.Get(redisStorePrefix + key)
+}
+
+func (s *redisStore) Set(key string, value []byte) error {
+	_, err := s.c.do("SET", redisStorePrefix+key, string(value))
+	return err
+}
+
+func (s *redisStore) Delete(key string) error {
+	_, err := s.c.do("DEL", redisStorePrefix+key)
+	return err
+}
+
+func (s *redisStore) Keys(prefix string) []string {
+	var (
+		keys   []string
+		cursor = "0"
+		match  = redisGlobEscape(redisStorePrefix+prefix) + "*"
+	)
+
+	for {
+		reply, err := s.c.do("SCAN", cursor, "MATCH", match, "COUNT", fmt.Sprint(redisScanCount))
+		if err != nil {
+			fmt.Printf("redisstore: %v\n", err)
+			break
+		}
+
+		// The reply is the next cursor followed by a list of keys.
+		parts, _ := reply.([]any)
+		if len(parts) != 2 { //nolint:mnd // cursor and keys.
+			fmt.Printf("redisstore: bad scan reply %v\n", reply)
+			break
+		}
+
+		next, _ := parts[0].([]byte)
+		items, _ := parts[1].([]any)
+
+		for _, item := range items {
+			if k, ok := item.([]byte); ok {
+				keys = append(keys, strings.TrimPrefix(string(k), redisStorePrefix))
+			}
+		}
+
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+
+	// Keys can be returned more than once while the server is resizing.
+	sort.Strings(keys)
+
+	return slices.Compact(keys)
+}
+
+// redisGlobEscape escapes characters that have a special meaning in a MATCH pattern.
+func redisGlobEscape(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteRune('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,7 +14,7 @@ import (
 // https://github.com/StarkBotsIndustries/telegraph
 // Upload photo/video to Telegra.ph on the '/upload' endpoint.
 // Media type should either be "video" or "photo". "Animation" is considered "video" here.
-func UploadTelegraph(data *bytes.Buffer, mediaType string) (string, error) {
+func UploadTelegraph(ctx context.Context, data *bytes.Buffer, mediaType string) (string, error) {
 	var (
 		name string
 		b    = &bytes.Buffer{}
@@ -38,16 +39,14 @@ func UploadTelegraph(data *bytes.Buffer, mediaType string) (string, error) {
 
 	w.Close()
 
-	r, err := http.NewRequest("POST", "https://te.legra.ph/upload", bytes.NewReader(b.Bytes()))
+	r, err := http.NewRequestWithContext(ctx, "POST", "https://te.legra.ph/upload", bytes.NewReader(b.Bytes()))
 	if err != nil {
 		return "", err
 	}
 
 	r.Header.Set("Content-Type", w.FormDataContentType())
 
-	c := &http.Client{}
-
-	resp, err := c.Do(r)
+	resp, err := upstream.Do(r)
 	if err != nil {
 		return "", err
 	}
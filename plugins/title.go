@@ -0,0 +1,152 @@
+// (c) Jisin0
+// Normalized title model filled by the hybrid apis.
+
+package plugins
+
+import (
+	"fmt"
+	"time"
+)
+
+// Title is the normalized data about a movie or show collected from the hybrid apis.
+type Title struct {
+	// IMDb id of the title.
+	ID string
+	// TMDB id of the title, zero if it wasn't found.
+	TmdbID int
+	// TMDB media type of the title either movie or tv.
+	TmdbType string
+
+	// Type of the title for ex: Movie or TV Series.
+	Type     string
+	IsSeries bool
+
+	Name         string
+	OriginalName string
+	AKA          string
+
+	StartYear int
+	// Year in which a series ended, zero if it's still running.
+	EndYear int
+	// Human-readable runtime of the movie or an episode for ex: 2h 28m.
+	Runtime string
+
+	ReleaseDate    time.Time
+	ReleaseCountry string
+
+	Rating    float64
+	Votes     int
+	Metascore int
+
+	Certificate string
+	// Current production stage for ex: Released or Filming.
+	Status string
+
+	Genres    []string
+	Themes    []string
+	Languages []string
+	Countries []string
+
+	Tagline  string
+	Plot     string
+	AIReview string
+
+	Directors []Person
+	Writers   []Person
+	Producers []Person
+	Stars     []Person
+	// Cast members that aren't stars in order of billing.
+	TopCast []Person
+	// Full cast and crew grouped by department or role.
+	Credits []CreditGroup
+
+	Awards  string
+	Trailer string
+
+	Poster string
+	// Full resolution poster to download.
+	PosterDownload string
+
+	Seasons  int
+	Episodes int
+
+	Budget    string
+	Revenue   string
+	Companies []string
+	Reviews   []Review
+	Trivia    []string
+	Goofs     []string
+}
+
+// Person is a cast or crew member of a title.
+type Person struct {
+	Name string
+	// IMDb id of the person, empty if only the TMDB id is known.
+	ID string
+	// TMDB id of the person, zero if only the IMDb id is known.
+	TmdbID int
+	// Character played or job done by the person.
+	Role string
+}
+
+// CreditGroup is a list of people grouped under a department or role like Directors.
+type CreditGroup struct {
+	Name   string
+	People []Person
+}
+
+// Review is a featured user review of a title.
+type Review struct {
+	Rating int
+	Text   string
+}
+
+// URL returns the link to the title on imdb.
+func (t *Title) URL() string {
+	return omdbHomepage + "/title/" + t.ID
+}
+
+// YearString returns the year of a movie or the years a series ran for ex: [2008-2013].
+func (t *Title) YearString() string {
+	switch {
+	case !t.IsSeries:
+		return fmt.Sprintf("[%d]", t.StartYear)
+	case t.EndYear > 0:
+		return fmt.Sprintf("[%d-%d]", t.StartYear, t.EndYear)
+	default:
+		return fmt.Sprintf("[%d-Present]", t.StartYear)
+	}
+}
+
+// Link returns a html link to the person's page on imdb or tmdb.
+func (p Person) Link() string {
+	if p.ID != "" {
+		return link(p.Name, p.ID)
+	}
+
+	if p.TmdbID != 0 {
+		return link(p.Name, p.TmdbID)
+	}
+
+	return escapeHTML(p.Name)
+}
+
+// personLinks returns html links to each person.
+func personLinks(people []Person) []string {
+	links := make([]string, 0, len(people))
+	for _, p := range people {
+		links = append(links, p.Link())
+	}
+
+	return links
+}
+
+// personNames returns the names of each person.
+func personNames(people []Person) []string {
+	names := make([]string, 0, len(people))
+	for _, p := range people {
+		names = append(names, p.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,187 @@
+// (c) Jisin0
+// Tests for building titles from recorded responses of the primary and fallback apis.
+
+package plugins
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// personNamesOf returns the names of people for comparing them in tests.
+func personNamesOf(people []Person) []string {
+	names := make([]string, 0, len(people))
+	for _, p := range people {
+		names = append(names, p.Name)
+	}
+
+	return names
+}
+
+func checkEqual(t *testing.T, field string, got, want any) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s = %#v; want %#v", field, got, want)
+	}
+}
+
+func TestPrimaryTitle(t *testing.T) {
+	var p primaryDetailData
+
+	loadFixture(t, "primary_title.json", &p)
+
+	title := primaryTitle("tt0903747", &p)
+
+	checkEqual(t, "ID", title.ID, "tt0903747")
+	checkEqual(t, "Type", title.Type, "TV Series")
+	checkEqual(t, "IsSeries", title.IsSeries, true)
+	checkEqual(t, "Name", title.Name, "Breaking Bad")
+	checkEqual(t, "YearString", title.YearString(), "[2008-2013]")
+	checkEqual(t, "Runtime", title.Runtime, "49m")
+	checkEqual(t, "Rating", title.Rating, 9.5)
+	checkEqual(t, "Votes", title.Votes, 2200000)
+	checkEqual(t, "Metascore", title.Metascore, 0)
+	checkEqual(t, "Certificate", title.Certificate, "TV-MA")
+	checkEqual(t, "Status", title.Status, "Released")
+	checkEqual(t, "Trailer", title.Trailer, "https://www.imdb.com/video/imdb/vi547929369/imdb/embed")
+	checkEqual(t, "AKA", title.AKA, "Reviravolta")
+	checkEqual(t, "Seasons", title.Seasons, 5)
+	checkEqual(t, "Episodes", title.Episodes, 62)
+	checkEqual(t, "ReleaseDate", title.ReleaseDate, time.Date(2008, time.January, 20, 0, 0, 0, 0, time.UTC))
+	checkEqual(t, "ReleaseCountry", title.ReleaseCountry, "United States")
+	checkEqual(t, "Genres", title.Genres, []string{"Crime", "Drama"})
+	// Interests that are also genres aren't repeated as themes.
+	checkEqual(t, "Themes", title.Themes, []string{"Drug Crime"})
+	checkEqual(t, "Languages", title.Languages, []string{"English", "Spanish"})
+	checkEqual(t, "Countries", title.Countries, []string{"United States"})
+	checkEqual(t, "AIReview", title.AIReview, "Viewers praise the \"acting\".")
+
+	// Series without directors fall back to their creators.
+	checkEqual(t, "Directors", title.Directors, []Person{{Name: "Vince Gilligan", ID: "nm0319213"}})
+	checkEqual(t, "Stars", personNamesOf(title.Stars), []string{"Bryan Cranston", "Aaron Paul"})
+	// Stars aren't repeated in the top cast.
+	checkEqual(t, "TopCast", title.TopCast, []Person{{Name: "Anna Gunn", ID: "nm0348152", Role: "Skyler White"}})
+
+	if len(title.Credits) != 1 || title.Credits[0].Name != "Top Cast" || len(title.Credits[0].People) != 3 {
+		t.Errorf("Credits = %+v; want the top cast group with 3 people", title.Credits)
+	}
+
+	checkEqual(t, "Awards", title.Awards, "Won 16 Oscars. 162 wins & 266 nominations total.")
+	checkEqual(t, "Reviews", title.Reviews, []Review{{Rating: 10, Text: "Line one\nLine & two"}})
+	checkEqual(t, "Trivia", title.Trivia, []string{"Bryan Cranston shaved his head."})
+	checkEqual(t, "Goofs", title.Goofs, []string{"The RV's color changes."})
+	checkEqual(t, "Budget", title.Budget, "3000000 USD")
+	checkEqual(t, "Revenue", title.Revenue, "")
+	checkEqual(t, "Companies", title.Companies, []string{"Sony Pictures Television"})
+	checkEqual(t, "Poster", title.Poster, "https://m.media-amazon.com/images/M/MV5BYmQ4._V1_FMjpg_UX2000_.jpg")
+	checkEqual(t, "PosterDownload", title.PosterDownload, "https://m.media-amazon.com/images/M/MV5BYmQ4._V1_FMjpg_UX3000_.jpg")
+}
+
+// fallbackFixture returns the recorded responses of the fallback apis, tmdb is only set if withTMDB is true.
+func fallbackFixture(t *testing.T, withTMDB bool) *fallbackSources {
+	t.Helper()
+
+	src := &fallbackSources{}
+
+	loadFixture(t, "imdbapi_title.json", &src.Base)
+	loadFixture(t, "imdbapi_credits.json", &src.Credits)
+	loadFixture(t, "imdbapi_akas.json", &src.AKAs)
+	loadFixture(t, "omdb_title.json", &src.OMDb)
+
+	if withTMDB {
+		src.TMDB = &tmdbDetailRes{}
+		src.TMDBID, src.TMDBType = 438631, "movie"
+
+		loadFixture(t, "tmdb_movie.json", src.TMDB)
+	}
+
+	return src
+}
+
+func TestMergeFallbackWithTMDB(t *testing.T) {
+	title := mergeFallback("tt1160419", fallbackFixture(t, true))
+
+	checkEqual(t, "TmdbID", title.TmdbID, 438631)
+	checkEqual(t, "TmdbType", title.TmdbType, "movie")
+	checkEqual(t, "Type", title.Type, "Movie")
+	checkEqual(t, "IsSeries", title.IsSeries, false)
+	// Tmdb is preferred for the name, release date, country, credits and poster.
+	checkEqual(t, "Name", title.Name, "Dune")
+	checkEqual(t, "OriginalName", title.OriginalName, "Dune")
+	checkEqual(t, "Tagline", title.Tagline, "Beyond fear, destiny awaits.")
+	// Titles from the us use the indian aka.
+	checkEqual(t, "AKA", title.AKA, "Dune (Part 1)")
+	checkEqual(t, "ReleaseDate", title.ReleaseDate, time.Date(2021, time.September, 15, 0, 0, 0, 0, time.UTC))
+	checkEqual(t, "ReleaseCountry", title.ReleaseCountry, "United States of America")
+	checkEqual(t, "Poster", title.Poster, "https://image.tmdb.org/t/p/original/d5NXSklXo0qyIYkgV94XAgMIckC.jpg")
+	checkEqual(t, "PosterDownload", title.PosterDownload, title.Poster)
+	checkEqual(t, "Budget", title.Budget, "$165000000")
+	checkEqual(t, "Revenue", title.Revenue, "$407573628")
+	checkEqual(t, "Companies", title.Companies, []string{"Legendary Pictures"})
+
+	// Imdbapi.dev is used for the rest.
+	checkEqual(t, "Runtime", title.Runtime, "2h 36m")
+	checkEqual(t, "Rating", title.Rating, 8.0)
+	checkEqual(t, "Votes", title.Votes, 950000)
+	checkEqual(t, "Metascore", title.Metascore, 74)
+	checkEqual(t, "Genres", title.Genres, []string{"Action", "Adventure", "Drama"})
+	checkEqual(t, "Themes", title.Themes, []string{"Space Sci-Fi"})
+	checkEqual(t, "Countries", title.Countries, []string{"United States", "Canada"})
+	checkEqual(t, "Awards", title.Awards, "Won 6 Oscars. 174 wins & 297 nominations total")
+
+	checkEqual(t, "Directors", title.Directors, []Person{{Name: "Denis Villeneuve", TmdbID: 137427, Role: "Director"}})
+	checkEqual(t, "Writers", personNamesOf(title.Writers), []string{"Denis Villeneuve", "Jon Spaihts"})
+	checkEqual(t, "Producers", personNamesOf(title.Producers), []string{"Mary Parent"})
+	checkEqual(t, "Stars", personNamesOf(title.Stars), []string{"Timothée Chalamet", "Rebecca Ferguson", "Oscar Isaac", "Zendaya"})
+	checkEqual(t, "TopCast", title.TopCast, []Person{{Name: "Josh Brolin", TmdbID: 12835, Role: "Gurney Halleck"}})
+
+	groups := make([]string, 0, len(title.Credits))
+	for _, g := range title.Credits {
+		groups = append(groups, g.Name)
+	}
+
+	// The cast comes first followed by departments in the order they appear.
+	checkEqual(t, "Credits", groups, []string{"Cast", "Directing", "Writing", "Production"})
+}
+
+func TestMergeFallbackWithoutTMDB(t *testing.T) {
+	title := mergeFallback("tt1160419", fallbackFixture(t, false))
+
+	checkEqual(t, "TmdbID", title.TmdbID, 0)
+	checkEqual(t, "Name", title.Name, "Dune: Part One")
+	checkEqual(t, "OriginalName", title.OriginalName, "")
+	checkEqual(t, "AKA", title.AKA, "Dune: Première partie")
+	// OMDb is used for the release date and the first origin country for the release country.
+	checkEqual(t, "ReleaseDate", title.ReleaseDate, time.Date(2021, time.October, 22, 0, 0, 0, 0, time.UTC))
+	checkEqual(t, "ReleaseCountry", title.ReleaseCountry, "United States")
+	checkEqual(t, "Poster", title.Poster, "https://m.media-amazon.com/images/M/dune.jpg")
+	checkEqual(t, "Budget", title.Budget, "")
+
+	checkEqual(t, "Directors", title.Directors, []Person{{Name: "Denis Villeneuve", ID: "nm0898288"}})
+	checkEqual(t, "Writers", title.Writers, []Person{{Name: "Jon Spaihts", ID: "nm0783398"}})
+	checkEqual(t, "Stars", title.Stars, []Person{{Name: "Timothée Chalamet", ID: "nm3154303"}})
+	checkEqual(t, "TopCast", title.TopCast, []Person{{Name: "Timothée Chalamet", ID: "nm3154303", Role: "Paul Atreides"}, {Name: "Rebecca Ferguson", ID: "nm1727304"}})
+
+	if len(title.Credits) != 1 || title.Credits[0].Name != "Cast" {
+		t.Errorf("Credits = %+v; want only the cast", title.Credits)
+	}
+}
+
+func TestMergeFallbackSeriesWithoutTMDB(t *testing.T) {
+	src := fallbackFixture(t, false)
+	src.Base.Type = "tvMiniSeries"
+	src.Base.EndYear = 2022
+	src.OMDb.TotalSeasons = "3"
+	src.OMDb.Released = notAvailable
+
+	title := mergeFallback("tt1160419", src)
+
+	checkEqual(t, "Type", title.Type, "TV Mini Series")
+	checkEqual(t, "IsSeries", title.IsSeries, true)
+	checkEqual(t, "YearString", title.YearString(), "[2021-2022]")
+	checkEqual(t, "Seasons", title.Seasons, 3)
+	// The release date of imdbapi.dev is used when omdb doesn't have one.
+	checkEqual(t, "ReleaseDate", title.ReleaseDate, time.Date(2021, time.October, 22, 0, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,318 @@
+// (c) Jisin0
+// Find the best trailer of a title and send it as a video or a link preview.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Jisin0/filmigo/justwatch"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// trailerCandidate is a video of a title that could be sent as it's trailer.
+type trailerCandidate struct {
+	URL  string `json:"url"`
+	Name string `json:"name,omitempty"`
+	// Kind of video either Trailer, Teaser or Clip.
+	Kind string `json:"kind,omitempty"`
+	// Site hosting the video for ex: YouTube or IMDb.
+	Site     string `json:"site,omitempty"`
+	Official bool   `json:"official,omitempty"`
+	// Language code of the video, empty if it's unknown.
+	Language string `json:"language,omitempty"`
+}
+
+// Direct reports whether the url points to a video file that telegram can send natively.
+func (c trailerCandidate) Direct() bool {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(path.Ext(u.Path), ".mp4")
+}
+
+// trailerKindScore ranks kinds of videos, unknown kinds are ranked last.
+var trailerKindScore = map[string]int{"trailer": 3, "teaser": 2, "clip": 1}
+
+// score ranks a candidate, trailers rank above everything else followed by direct videos, official videos and the user's language.
+func (c trailerCandidate) score(language string) int {
+	s := trailerKindScore[strings.ToLower(c.Kind)] * 100 //nolint:mnd // kind outweighs every other criteria.
+
+	if c.Direct() {
+		s += 30
+	}
+
+	if c.Official {
+		s += 20
+	}
+
+	if language != "" && strings.EqualFold(c.Language, language) {
+		s += 10
+	}
+
+	return s
+}
+
+// pickTrailer returns the best trailer among the candidates, the earlier one wins a tie.
+func pickTrailer(candidates []trailerCandidate, language string) (trailerCandidate, bool) {
+	var (
+		best  trailerCandidate
+		score = -1
+	)
+
+	for _, c := range candidates {
+		if c.URL == "" {
+			continue
+		}
+
+		if s := c.score(language); s > score {
+			best, score = c, s
+		}
+	}
+
+	return best, score >= 0
+}
+
+// clipKind guesses the kind of a clip from it's name.
+func clipKind(name string) string {
+	lower := strings.ToLower(name)
+
+	switch {
+	case strings.Contains(lower, "trailer"):
+		return "Trailer"
+	case strings.Contains(lower, "teaser"):
+		return "Teaser"
+	default:
+		return "Clip"
+	}
+}
+
+// tmdbVideosRes is the response of the videos endpoint of a tmdb movie or series.
+type tmdbVideosRes struct {
+	Results []struct {
+		Key      string `json:"key"`
+		Name     string `json:"name"`
+		Site     string `json:"site"`
+		Type     string `json:"type"`
+		Official bool   `json:"official"`
+		Language string `json:"iso_639_1"`
+	} `json:"results"`
+}
+
+// Candidates returns the videos hosted on youtube or vimeo as trailer candidates.
+func (res *tmdbVideosRes) Candidates() []trailerCandidate {
+	videos := make([]trailerCandidate, 0, len(res.Results))
+
+	for _, v := range res.Results {
+		var u string
+
+		switch v.Site {
+		case "YouTube":
+			u = "https://www.youtube.com/watch?v=" + url.QueryEscape(v.Key)
+		case "Vimeo":
+			u = "https://vimeo.com/" + url.PathEscape(v.Key)
+		default:
+			continue
+		}
+
+		videos = append(videos, trailerCandidate{URL: u, Name: v.Name, Kind: v.Type, Site: v.Site, Official: v.Official, Language: v.Language})
+	}
+
+	return videos
+}
+
+// tmdbVideos returns the videos of a title on tmdb by it's imdb id.
+func tmdbVideos(ctx context.Context, imdbID string) ([]trailerCandidate, error) {
+	return cached(cacheKey("videos", imdbID), titleCacheTTL, func() ([]trailerCandidate, error) {
+		ref, err := tmdbFindTitle(ctx, imdbID)
+		if err != nil {
+			return nil, err
+		}
+
+		var res tmdbVideosRes
+
+		if err := getTMDBJSON(ctx, fmt.Sprintf("/%s/%d/videos", ref.MediaType, ref.ID), &res); err != nil {
+			return nil, err
+		}
+
+		return res.Candidates(), nil
+	})
+}
+
+// jWClipCandidates returns the clips of a justwatch title as trailer candidates.
+func jWClipCandidates(clips []*justwatch.Clip) []trailerCandidate {
+	candidates := make([]trailerCandidate, 0, len(clips))
+
+	for _, clip := range clips {
+		if clip != nil {
+			candidates = append(candidates, trailerCandidate{URL: clip.URL, Name: clip.Name, Kind: clipKind(clip.Name), Site: capitalizeFirstLetter(strings.ToLower(clip.Provider)), Official: true})
+		}
+	}
+
+	return candidates
+}
+
+// trailerCandidates collects the videos of a title from imdb, tmdb and justwatch.
+// The name of the title is returned along with them.
+func trailerCandidates(ctx context.Context, method, id string) (string, []trailerCandidate) {
+	var (
+		name       string
+		imdbID     string
+		candidates []trailerCandidate
+	)
+
+	if method == searchMethodJW {
+		title, err := getJWTitleData(ctx, id)
+		if err == nil && title.Content != nil {
+			name = title.Content.Title
+
+			candidates = append(candidates, jWClipCandidates(title.Content.Clips)...)
+
+			if title.Content.ExteranlIDs != nil {
+				imdbID = title.Content.ExteranlIDs.ImdbID
+			}
+		}
+	} else {
+		imdbID = id
+
+		if t, err := getTitleData(ctx, id, nil); err == nil {
+			name = t.Name
+
+			if t.Trailer != "" {
+				candidates = append(candidates, trailerCandidate{URL: t.Trailer, Name: t.Name + " Trailer", Kind: "Trailer", Site: "IMDb", Official: true})
+			}
+		}
+	}
+
+	if imdbID != "" {
+		videos, err := tmdbVideos(ctx, imdbID)
+		if err != nil {
+			fmt.Printf("trailer: %v\n", err)
+		}
+
+		candidates = append(candidates, videos...)
+	}
+
+	return name, candidates
+}
+
+// trailerButton returns the button that sends the trailer of a title.
+func trailerButton(method, id, country string) gotgbot.InlineKeyboardButton {
+	data := fmt.Sprintf("trl_%s_%s", method, id)
+	if country != "" {
+		data += "_" + country
+	}
+
+	return gotgbot.InlineKeyboardButton{Text: "▶️ Trailer", CallbackData: data}
+}
+
+// sendTrailer sends a trailer as a native video if possible or as a message with a large link preview.
+func sendTrailer(bot *gotgbot.Bot, chatID int64, reply *gotgbot.ReplyParameters, name string, trailer trailerCandidate) error {
+	title := trailer.Name
+	if title == "" {
+		title = name + " Trailer"
+	}
+
+	caption := fmt.Sprintf("<b>▶️ %s</b>", escapeHTML(title))
+
+	if trailer.Direct() {
+		_, err := bot.SendVideo(chatID, gotgbot.InputFileByURL(trailer.URL), &gotgbot.SendVideoOpts{
+			Caption:           caption,
+			ParseMode:         gotgbot.ParseModeHTML,
+			SupportsStreaming: true,
+			ReplyParameters:   reply,
+		})
+		if err == nil {
+			return nil
+		}
+
+		fmt.Printf("trailer: sending video failed, sending link: %v\n", err)
+	}
+
+	site := trailer.Site
+	if site == "" {
+		site = "Web"
+	}
+
+	text := fmt.Sprintf("%s\n<a href=\"%s\">Watch on %s</a>", caption, escapeHTML(trailer.URL), escapeHTML(site))
+
+	_, err := bot.SendMessage(chatID, text, &gotgbot.SendMessageOpts{
+		ParseMode:       gotgbot.ParseModeHTML,
+		ReplyParameters: reply,
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			Url:              trailer.URL,
+			PreferLargeMedia: true,
+			ShowAboveText:    true,
+		},
+	})
+
+	return err
+}
+
+// CbTrailer handles trailer buttons, data is like trl_<method>_<id> with an optional country for regional providers.
+// The trailer is sent as a reply to the card or privately to the user for inline messages.
+func CbTrailer(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.Split(update.Data, "_")
+	)
+
+	if len(split) < 3 {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	var (
+		method = split[1]
+		id     = split[2]
+	)
+
+	p, ok := getProvider(method)
+	if !ok || !p.Info().IDPattern.MatchString(id) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	if len(split) > 3 && p.Info().Regional && isCountryCode(split[3]) {
+		reqCtx = withCountry(reqCtx, split[3])
+	}
+
+	name, candidates := trailerCandidates(reqCtx, method, id)
+
+	trailer, ok := pickTrailer(candidates, prefsFrom(reqCtx).Language)
+	if !ok {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Find a Trailer for This Title 🤧", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	var (
+		chatID = update.From.Id
+		reply  *gotgbot.ReplyParameters
+	)
+
+	if update.Message != nil {
+		chatID = update.Message.GetChat().Id
+		reply = &gotgbot.ReplyParameters{MessageId: update.Message.GetMessageId(), AllowSendingWithoutReply: true}
+	}
+
+	if err := sendTrailer(bot, chatID, reply, name, trailer); err != nil {
+		fmt.Printf("cbtrailer: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Send You The Trailer 🤧\nStart Me in Private and Try Again !", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	update.Answer(bot, nil)
+
+	return ext.EndGroups
+}
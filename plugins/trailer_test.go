@@ -0,0 +1,208 @@
+// (c) Jisin0
+// Tests for picking the best trailer from recorded tmdb and justwatch responses.
+
+package plugins
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jisin0/filmigo/justwatch"
+)
+
+// loadFixture decodes a json file from testdata into v.
+func loadFixture(t *testing.T, name string, v any) {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+func tmdbFixture(t *testing.T, name string) []trailerCandidate {
+	t.Helper()
+
+	var res tmdbVideosRes
+
+	loadFixture(t, name, &res)
+
+	return res.Candidates()
+}
+
+func jWClipsFixture(t *testing.T) []trailerCandidate {
+	t.Helper()
+
+	var clips []*justwatch.Clip
+
+	loadFixture(t, "jw_clips.json", &clips)
+
+	return jWClipCandidates(clips)
+}
+
+func TestTMDBVideosCandidates(t *testing.T) {
+	videos := tmdbFixture(t, "tmdb_videos.json")
+
+	// The dailymotion video is dropped since only youtube and vimeo links are supported.
+	if len(videos) != 7 {
+		t.Fatalf("got %d candidates; want 7", len(videos))
+	}
+
+	for _, v := range videos {
+		if v.Site != "YouTube" && v.Site != "Vimeo" {
+			t.Errorf("unsupported site %q wasn't dropped", v.Site)
+		}
+	}
+
+	if got, want := videos[len(videos)-1].URL, "https://vimeo.com/459137331"; got != want {
+		t.Errorf("vimeo url = %q; want %q", got, want)
+	}
+
+	if got, want := videos[0].URL, "https://www.youtube.com/watch?v=pW5yZc3TXDo"; got != want {
+		t.Errorf("youtube url = %q; want %q", got, want)
+	}
+
+	if !videos[0].Official || videos[0].Language != "en" || videos[0].Kind != "Behind the Scenes" {
+		t.Errorf("fields weren't decoded: %+v", videos[0])
+	}
+}
+
+func TestJWClipCandidates(t *testing.T) {
+	clips := jWClipsFixture(t)
+
+	// The null clip is skipped.
+	if len(clips) != 4 {
+		t.Fatalf("got %d candidates; want 4", len(clips))
+	}
+
+	want := []string{"Clip", "Teaser", "Trailer", "Trailer"}
+	for i, c := range clips {
+		if c.Kind != want[i] {
+			t.Errorf("clip %q has kind %q; want %q", c.Name, c.Kind, want[i])
+		}
+
+		if c.Site != "Youtube" {
+			t.Errorf("clip %q has site %q; want Youtube", c.Name, c.Site)
+		}
+	}
+}
+
+func TestPickTrailer(t *testing.T) {
+	var (
+		tmdb      = tmdbFixture(t, "tmdb_videos.json")
+		localized = tmdbFixture(t, "tmdb_videos_localized.json")
+		clips     = jWClipsFixture(t)
+		imdbMP4   = trailerCandidate{URL: "https://imdb-video.media-imdb.com/vi1208731417/1434659607842-pgv4ql-1616202333253.mp4?Expires=1", Name: "Dune Trailer", Kind: "Trailer", Site: "IMDb", Official: true}
+		mp4Teaser = trailerCandidate{URL: "https://example.com/teaser.MP4", Kind: "Teaser", Official: true}
+	)
+
+	tests := []struct {
+		name       string
+		candidates []trailerCandidate
+		language   string
+		want       string
+	}{
+		{
+			name:       "trailer beats teaser and clip",
+			candidates: clips,
+			want:       "https://www.youtube.com/watch?v=8g18jFHCLXk",
+		},
+		{
+			name:       "teaser beats clip",
+			candidates: clips[:2],
+			want:       "https://www.youtube.com/watch?v=n9xhJrPXop4",
+		},
+		{
+			name:       "clip is used when nothing else is available",
+			candidates: clips[:1],
+			want:       "https://www.youtube.com/watch?v=3r1jQm6Ux2w",
+		},
+		{
+			name:       "official trailers beat fan made ones and the earlier one wins a tie",
+			candidates: tmdb,
+			language:   "en",
+			want:       "https://www.youtube.com/watch?v=8g18jFHCLXk",
+		},
+		{
+			name:       "direct video beats youtube",
+			candidates: append(append([]trailerCandidate{}, tmdb...), imdbMP4),
+			language:   "en",
+			want:       imdbMP4.URL,
+		},
+		{
+			name:       "kind outweighs a direct video",
+			candidates: append([]trailerCandidate{mp4Teaser}, tmdb...),
+			want:       "https://www.youtube.com/watch?v=8g18jFHCLXk",
+		},
+		{
+			name:       "direct teaser beats youtube teaser",
+			candidates: append([]trailerCandidate{}, clips[1], mp4Teaser),
+			want:       mp4Teaser.URL,
+		},
+		{
+			name:       "user's language breaks a tie",
+			candidates: localized,
+			language:   "es",
+			want:       "https://www.youtube.com/watch?v=ApEa1mSnVvA",
+		},
+		{
+			name:       "earlier candidate wins without a language",
+			candidates: localized,
+			want:       "https://www.youtube.com/watch?v=w0HgHet0sxg",
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := pickTrailer(tt.candidates, tt.language)
+		if !ok {
+			t.Errorf("%s: no trailer was picked", tt.name)
+			continue
+		}
+
+		if got.URL != tt.want {
+			t.Errorf("%s: picked %q (%s); want %q", tt.name, got.URL, got.Name, tt.want)
+		}
+	}
+}
+
+func TestPickTrailerSkipsEmptyURLs(t *testing.T) {
+	clips := jWClipsFixture(t)
+
+	// The third clip is a trailer without a url.
+	if _, ok := pickTrailer(clips[2:3], ""); ok {
+		t.Error("a candidate without a url was picked")
+	}
+
+	got, ok := pickTrailer(clips[:3], "")
+	if !ok || got.URL != "https://www.youtube.com/watch?v=n9xhJrPXop4" {
+		t.Errorf("picked %q; want the teaser since the trailer has no url", got.URL)
+	}
+
+	if _, ok := pickTrailer(nil, "en"); ok {
+		t.Error("a trailer was picked from no candidates")
+	}
+}
+
+func TestClipKind(t *testing.T) {
+	tests := map[string]string{
+		"Dune | Official Main Trailer": "Trailer",
+		"DUNE - TRAILER 2":             "Trailer",
+		"Official Teaser":              "Teaser",
+		"Teaser Trailer":               "Trailer",
+		"Paul and Chani Clip":          "Clip",
+		"Behind the Scenes":            "Clip",
+		"":                             "Clip",
+	}
+
+	for name, want := range tests {
+		if got := clipKind(name); got != want {
+			t.Errorf("clipKind(%q) = %q; want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+// (c) Jisin0
+// Per-user watchlist of saved titles.
+
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+const (
+	// Number of titles on each page of the watchlist.
+	watchlistPageSize = 8
+	// Maximum number of titles a user can save.
+	maxWatchlistItems = 200
+	// Inline queries starting with this prefix search the user's watchlist.
+	watchlistInlinePrefix = "wl"
+
+	watchlistKeyPrefix = "watchlist:"
+)
+
+var errWatchlistFull = errors.New("watchlist: too many titles")
+
+// watchlistItem is a title saved to a watchlist.
+type watchlistItem struct {
+	// Search method used to open the title.
+	Method  string    `json:"method"`
+	ID      string    `json:"id"`
+	Title   string    `json:"title"`
+	Year    int       `json:"year,omitempty"`
+	Type    string    `json:"type,omitempty"`
+	Poster  string    `json:"poster,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// Name returns the title followed by it's year if it's known.
+func (i watchlistItem) Name() string {
+	if i.Year > 0 {
+		return fmt.Sprintf("%s (%d)", i.Title, i.Year)
+	}
+
+	return i.Title
+}
+
+// watchlistMu serializes changes to watchlists so concurrent button presses don't overwrite each other.
+var watchlistMu sync.Mutex
+
+func watchlistKey(userID int64) string {
+	return watchlistKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+// getWatchlist returns the titles saved by a user with the latest first.
+func getWatchlist(userID int64) []watchlistItem {
+	var items []watchlistItem
+
+	loadRecord(watchlistKey(userID), &items)
+
+	return items
+}
+
+// saveWatchlist saves the watchlist of a user, empty lists are deleted.
+func saveWatchlist(userID int64, items []watchlistItem) error {
+	if len(items) < 1 {
+		return userStore.Delete(watchlistKey(userID))
+	}
+
+	return saveRecord(watchlistKey(userID), items)
+}
+
+// watchlistIndex returns the position of a title in a watchlist or -1.
+func watchlistIndex(items []watchlistItem, method, id string) int {
+	for i, item := range items {
+		if item.Method == method && item.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// removeFromWatchlist removes a title from a user's watchlist and reports whether it was saved.
+func removeFromWatchlist(userID int64, method, id string) (bool, error) {
+	watchlistMu.Lock()
+	defer watchlistMu.Unlock()
+
+	items := getWatchlist(userID)
+
+	i := watchlistIndex(items, method, id)
+	if i < 0 {
+		return false, nil
+	}
+
+	return true, saveWatchlist(userID, append(items[:i], items[i+1:]...))
+}
+
+// addToWatchlist saves a title at the top of a user's watchlist.
+func addToWatchlist(userID int64, item watchlistItem) error {
+	watchlistMu.Lock()
+	defer watchlistMu.Unlock()
+
+	items := getWatchlist(userID)
+
+	if i := watchlistIndex(items, item.Method, item.ID); i >= 0 {
+		items = append(items[:i], items[i+1:]...)
+	}
+
+	if len(items) >= maxWatchlistItems {
+		return errWatchlistFull
+	}
+
+	return saveWatchlist(userID, append([]watchlistItem{item}, items...))
+}
+
+// watchlistEntry gets the details of a title that are saved to a watchlist.
+func watchlistEntry(ctx context.Context, method, id string) (watchlistItem, error) {
+	item := watchlistItem{Method: method, ID: id, AddedAt: time.Now()}
+
+	if method == searchMethodJW {
+		title, err := getJWTitleData(ctx, id)
+		if err != nil {
+			return item, err
+		}
+
+		if title.Content == nil {
+			return item, fmt.Errorf("watchlist: no content for %s", id)
+		}
+
+		item.Title = title.Content.Title
+		item.Year = title.Content.ReleaseYear
+
+		item.Type = "Movie"
+		if title.Type == "SHOW" {
+			item.Type = "TV Series"
+		}
+
+		if title.Content.Poster != nil {
+			item.Poster = title.Content.Poster.FullURL()
+		}
+
+		return item, nil
+	}
+
+	t, err := getTitleData(ctx, id, nil)
+	if err != nil {
+		return item, err
+	}
+
+	item.Title = t.Name
+	item.Year = t.StartYear
+	item.Type = t.Type
+
+	if t.Poster != notAvailable {
+		item.Poster = t.Poster
+	}
+
+	return item, nil
+}
+
+// inWatchlist reports whether a title is saved to a user's watchlist.
+func inWatchlist(userID int64, method, id string) bool {
+	return userID != 0 && watchlistIndex(getWatchlist(userID), method, id) >= 0
+}
+
+// watchlistButton returns the button that adds a title to or removes it from the user's watchlist, saved is shown on the label.
+func watchlistButton(method, id string, saved bool) gotgbot.InlineKeyboardButton {
+	text := "➕ Watchlist"
+	if saved {
+		text = "✅ In Watchlist"
+	}
+
+	return gotgbot.InlineKeyboardButton{Text: text, CallbackData: fmt.Sprintf("wla_%s_%s", method, id)}
+}
+
+func watchlistPageData(userID int64, page int) string {
+	return fmt.Sprintf("wlp_%d_%d", userID, page)
+}
+
+// watchlistMenu renders a page of a user's watchlist with buttons to open or remove each title.
+func watchlistMenu(userID int64, page int) (string, [][]gotgbot.InlineKeyboardButton) {
+	items := getWatchlist(userID)
+	if len(items) < 1 {
+		return "<i>Your watchlist is empty 🫙\nUse the <b>➕ Watchlist</b> button on any title to save it here.</i>", [][]gotgbot.InlineKeyboardButton{}
+	}
+
+	pages := pageCount(len(items), watchlistPageSize)
+	page = max(0, min(page, pages-1))
+
+	var (
+		text    strings.Builder
+		buttons [][]gotgbot.InlineKeyboardButton
+	)
+
+	fmt.Fprintf(&text, "<b>📝 Your Watchlist (%d)</b>\n\n", len(items))
+
+	for i, item := range items[page*watchlistPageSize : min((page+1)*watchlistPageSize, len(items))] {
+		n := page*watchlistPageSize + i + 1
+
+		fmt.Fprintf(&text, "%d. %s <i>(added %s)</i>\n", n, escapeHTML(item.Name()), item.AddedAt.Format("02 Jan 2006"))
+
+		buttons = append(buttons, []gotgbot.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%d. %s", n, item.Name()), CallbackData: fmt.Sprintf("open_%s_%s", item.Method, item.ID)},
+			{Text: "❌", CallbackData: fmt.Sprintf("wlr_%d_%d_%s_%s", userID, page, item.Method, item.ID)},
+		})
+	}
+
+	if nav := pageButtons(page, pages, func(n int) string { return watchlistPageData(userID, n) }); len(nav) > 0 {
+		buttons = append(buttons, nav)
+	}
+
+	shareQuery := watchlistInlinePrefix + " "
+	buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: "📤 Share", SwitchInlineQuery: &shareQuery}})
+
+	return text.String(), buttons
+}
+
+// Watchlist handles the /watchlist command.
+func Watchlist(bot *gotgbot.Bot, ctx *ext.Context) error {
+	update := ctx.EffectiveMessage
+
+	text, buttons := watchlistMenu(ctx.EffectiveUser.Id, 0)
+
+	_, err := bot.SendMessage(update.Chat.Id, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons}})
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	return ext.EndGroups
+}
+
+// CbWatchlistToggle handles the watchlist button of title cards, data is like wla_<method>_<id>.
+// The title is saved if it's not on the user's watchlist and removed otherwise.
+func CbWatchlistToggle(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.Split(update.Data, "_")
+		userID = update.From.Id
+	)
+
+	if len(split) < 3 {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	var (
+		method = split[1]
+		id     = split[2]
+	)
+
+	p, ok := getProvider(method)
+	if !ok || !p.Info().IDPattern.MatchString(id) {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	removed, err := removeFromWatchlist(userID, method, id)
+	if err != nil {
+		fmt.Printf("cbwatchlist: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Update Your Watchlist 🤧\nPlease Try Again Later !", ShowAlert: true})
+
+		return ext.EndGroups
+	}
+
+	if removed {
+		relabelButton(bot, update, watchlistButton(method, id, false))
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Removed From Your Watchlist 🗑"})
+
+		return ext.EndGroups
+	}
+
+	reqCtx, cancel := requestContext(ctx, updateBudget)
+	defer cancel()
+
+	item, err := watchlistEntry(reqCtx, method, id)
+	if err == nil {
+		err = addToWatchlist(userID, item)
+	}
+
+	switch {
+	case errors.Is(err, errWatchlistFull):
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: fmt.Sprintf("Your Watchlist is Full 🫠\nRemove Some Titles With /watchlist to Save More Than %d !", maxWatchlistItems), ShowAlert: true})
+	case err != nil:
+		fmt.Printf("cbwatchlist: %v\n", err)
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Update Your Watchlist 🤧\nPlease Try Again Later !", ShowAlert: true})
+	default:
+		relabelButton(bot, update, watchlistButton(method, id, true))
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Added to Your Watchlist ✅\nSee it Anytime With /watchlist"})
+	}
+
+	return ext.EndGroups
+}
+
+// CbWatchlist handles the buttons of the watchlist, data is like wlp_<user id>_<page> or wlr_<user id>_<page>_<method>_<id> to remove a title.
+func CbWatchlist(bot *gotgbot.Bot, ctx *ext.Context) error {
+	var (
+		update = ctx.CallbackQuery
+		split  = strings.SplitN(update.Data, "_", 5)
+	)
+
+	if len(split) < 3 {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	userID, _ := strconv.ParseInt(split[1], 10, 64)
+	if userID != update.From.Id {
+		update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "This Isn't Your Watchlist 🙅\nSee Yours With /watchlist", ShowAlert: true})
+		return ext.EndGroups
+	}
+
+	page, _ := strconv.Atoi(split[2])
+
+	if split[0] == "wlr" {
+		if len(split) < 5 {
+			update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "Bad Callback Data !", ShowAlert: true})
+			return ext.EndGroups
+		}
+
+		if _, err := removeFromWatchlist(userID, split[3], split[4]); err != nil {
+			fmt.Printf("cbwatchlist: %v\n", err)
+			update.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: "I Couldn't Update Your Watchlist 🤧\nPlease Try Again Later !", ShowAlert: true})
+
+			return ext.EndGroups
+		}
+	}
+
+	text, buttons := watchlistMenu(userID, page)
+
+	_, _, err := update.Message.EditText(bot, text, &gotgbot.EditMessageTextOpts{ParseMode: gotgbot.ParseModeHTML, ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons}})
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	update.Answer(bot, nil)
+
+	return ext.EndGroups
+}
+
+// watchlistInlineResults returns a page of the titles on a user's watchlist whose names contain query.
+func watchlistInlineResults(userID int64, query string, page int) ([]gotgbot.InlineQueryResult, string) {
+	var (
+		items   []watchlistItem
+		results []gotgbot.InlineQueryResult
+	)
+
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	for _, item := range getWatchlist(userID) {
+		if strings.Contains(strings.ToLower(item.Title), query) {
+			items = append(items, item)
+		}
+	}
+
+	if page*inlinePageSize >= len(items) {
+		return nil, ""
+	}
+
+	for _, item := range items[page*inlinePageSize : min((page+1)*inlinePageSize, len(items))] {
+		p, ok := getProvider(item.Method)
+		if !ok {
+			continue
+		}
+
+		results = append(results, p.InlineResult(UniversalSearchResult{ID: item.ID, Title: item.Title, Year: item.Year, Poster: item.Poster, Type: item.Type}))
+	}
+
+	var nextOffset string
+	if (page+1)*inlinePageSize < len(items) {
+		nextOffset = strconv.Itoa(page + 1)
+	}
+
+	return results, nextOffset
+}
+
+// answerWatchlistQuery answers an inline query with titles from the user's watchlist so they can be shared in any chat.
+func answerWatchlistQuery(bot *gotgbot.Bot, update *gotgbot.InlineQuery, query string) error {
+	page, _ := strconv.Atoi(update.Offset)
+
+	results, nextOffset := watchlistInlineResults(update.From.Id, query, page)
+	if results == nil {
+		results = []gotgbot.InlineQueryResult{}
+	}
+
+	_, err := update.Answer(bot, results, &gotgbot.AnswerInlineQueryOpts{
+		CacheTime:  0, // watchlists change with every button press.
+		IsPersonal: true,
+		Button:     &gotgbot.InlineQueryResultsButton{Text: "📝 Manage Your Watchlist", StartParameter: "watchlist"},
+		NextOffset: nextOffset,
+	})
+
+	return err
+}